@@ -6,16 +6,166 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/oapi-codegen/runtime"
+	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
+// Defines values for BatchResultStatus.
+const (
+	BatchResultStatusCreated BatchResultStatus = "created"
+	BatchResultStatusFailed  BatchResultStatus = "failed"
+	BatchResultStatusUpdated BatchResultStatus = "updated"
+)
+
+// Defines values for FailurePolicy.
+const (
+	AllOrNothing FailurePolicy = "all_or_nothing"
+	Partial      FailurePolicy = "partial"
+)
+
+// Defines values for JobStatus.
+const (
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+)
+
+// Defines values for StoreResponsePendingStores.
+const (
+	Text   StoreResponsePendingStores = "text"
+	Vector StoreResponsePendingStores = "vector"
+)
+
+// Defines values for QueryDocumentsParamsFusion.
+const (
+	Borda   QueryDocumentsParamsFusion = "borda"
+	Combmnz QueryDocumentsParamsFusion = "combmnz"
+	Combsum QueryDocumentsParamsFusion = "combsum"
+	Dbsf    QueryDocumentsParamsFusion = "dbsf"
+	Minmax  QueryDocumentsParamsFusion = "minmax"
+	Rrf     QueryDocumentsParamsFusion = "rrf"
+	Zscore  QueryDocumentsParamsFusion = "zscore"
+)
+
+// AnswerRequest defines model for AnswerRequest.
+type AnswerRequest struct {
+	// MaxContextTokens Overrides the server's token budget for the sources included in the prompt.
+	MaxContextTokens *int `json:"max_context_tokens,omitempty"`
+
+	// Question The question to answer.
+	Question string `json:"question"`
+
+	// Reranker Selects a configured reranker, or `none`, as for `/query`.
+	Reranker *string `json:"reranker,omitempty"`
+
+	// Rewrite Selects a configured query rewriter, or `none`, as for `/query`.
+	Rewrite *string `json:"rewrite,omitempty"`
+
+	// Stream Stream the answer as server-sent events.
+	Stream *bool `json:"stream,omitempty"`
+
+	// TopK The number of results requested from each retriever. Defaults to 8.
+	TopK *int `json:"top_k,omitempty"`
+}
+
+// AnswerResponse defines model for AnswerResponse.
+type AnswerResponse struct {
+	Answer *string `json:"answer,omitempty"`
+
+	// Citations The sources given to the model, in the order they were numbered.
+	Citations *[]Citation `json:"citations,omitempty"`
+}
+
+// BatchDocument defines model for BatchDocument.
+type BatchDocument struct {
+	// Id The client's ID for the document. It must be unique within the batch and must not contain `#`.
+	Id string `json:"id"`
+
+	// Metadata Attributes, such as tags, that queries can filter on with `field:value`.
+	Metadata *map[string]string `json:"metadata,omitempty"`
+
+	// Text The text content of the document to store.
+	Text string `json:"text"`
+
+	// Title An optional title. Titles are offered as autocomplete suggestions by `/suggest`.
+	Title *string `json:"title,omitempty"`
+}
+
+// BatchResult defines model for BatchResult.
+type BatchResult struct {
+	// Error Why the document failed. Only set when status is `failed`.
+	Error  *string            `json:"error,omitempty"`
+	Id     *string            `json:"id,omitempty"`
+	Status *BatchResultStatus `json:"status,omitempty"`
+
+	// Version The version the write was recorded as, for use in `If-Match`. Only set when the server records document versions.
+	Version *int `json:"version,omitempty"`
+}
+
+// BatchResultStatus defines model for BatchResult.Status.
+type BatchResultStatus string
+
+// BatchStoreRequest defines model for BatchStoreRequest.
+type BatchStoreRequest struct {
+	Documents []BatchDocument `json:"documents"`
+}
+
+// BatchStoreResponse defines model for BatchStoreResponse.
+type BatchStoreResponse struct {
+	// Results One result per document, in request order.
+	Results *[]BatchResult `json:"results,omitempty"`
+}
+
+// ChunkFailure defines model for ChunkFailure.
+type ChunkFailure struct {
+	ChunkId *string `json:"chunk_id,omitempty"`
+	Error   *string `json:"error,omitempty"`
+}
+
+// Citation defines model for Citation.
+type Citation struct {
+	// Cited Whether the answer references this source.
+	Cited      *bool   `json:"cited,omitempty"`
+	DocumentId *string `json:"document_id,omitempty"`
+
+	// Number The number the answer uses to cite this source, as in `[1]`.
+	Number           *int    `json:"number,omitempty"`
+	ParentDocumentId *string `json:"parent_document_id,omitempty"`
+
+	// Score The source's rerank score if it was reranked, otherwise its fused score.
+	Score *float64 `json:"score,omitempty"`
+}
+
 // Document defines model for Document.
 type Document struct {
-	DocumentId       *string `json:"document_id,omitempty"`
-	ParentDocumentId *string `json:"parent_document_id,omitempty"`
-	Text             *string `json:"text,omitempty"`
+	DocumentId       *string            `json:"document_id,omitempty"`
+	Metadata         *map[string]string `json:"metadata,omitempty"`
+	ParentDocumentId *string            `json:"parent_document_id,omitempty"`
+	Text             *string            `json:"text,omitempty"`
+}
+
+// DocumentVersion defines model for DocumentVersion.
+type DocumentVersion struct {
+	CreatedAt *time.Time         `json:"created_at,omitempty"`
+	Metadata  *map[string]string `json:"metadata,omitempty"`
+
+	// RestoredFrom The version a rollback restored. Only set for versions written by a rollback.
+	RestoredFrom *int    `json:"restored_from,omitempty"`
+	Text         *string `json:"text,omitempty"`
+	Title        *string `json:"title,omitempty"`
+	Version      *int    `json:"version,omitempty"`
+}
+
+// DocumentVersionList defines model for DocumentVersionList.
+type DocumentVersionList struct {
+	DocumentId *string `json:"document_id,omitempty"`
+
+	// Versions Every recorded version, oldest first. The last one is current.
+	Versions *[]DocumentVersion `json:"versions,omitempty"`
 }
 
 // Error defines model for Error.
@@ -23,40 +173,341 @@ type Error struct {
 	Message *string `json:"message,omitempty"`
 }
 
+// FailurePolicy defines model for FailurePolicy.
+type FailurePolicy string
+
+// Job defines model for Job.
+type Job struct {
+	ChunksEmbedded *int `json:"chunks_embedded,omitempty"`
+	ChunksFailed   *int `json:"chunks_failed,omitempty"`
+
+	// ChunksTotal The number of chunks the document was split into. Zero until the job starts.
+	ChunksTotal   *int       `json:"chunks_total,omitempty"`
+	ChunksWritten *int       `json:"chunks_written,omitempty"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+
+	// DocumentId The ID the document is stored under.
+	DocumentId *string `json:"document_id,omitempty"`
+
+	// Error Why the job failed. Only set when status is `failed`.
+	Error     *string    `json:"error,omitempty"`
+	Id        *string    `json:"id,omitempty"`
+	Status    *JobStatus `json:"status,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// JobStatus defines model for Job.Status.
+type JobStatus string
+
+// RetrieverContribution defines model for RetrieverContribution.
+type RetrieverContribution struct {
+	// Contribution The amount this retriever added to the fused score.
+	Contribution *float64 `json:"contribution,omitempty"`
+
+	// Rank The 1-based rank the retriever gave the document.
+	Rank *int `json:"rank,omitempty"`
+
+	// Retriever The retriever that returned the document, e.g. `lexical` or `semantic`.
+	Retriever *string `json:"retriever,omitempty"`
+
+	// Score The raw score the retriever gave the document.
+	Score *float64 `json:"score,omitempty"`
+}
+
+// RollbackRequest defines model for RollbackRequest.
+type RollbackRequest struct {
+	// Version The version to restore.
+	Version int `json:"version"`
+}
+
+// SearchResponse defines model for SearchResponse.
+type SearchResponse struct {
+	// CorrectedQuery The corrected query whose results were returned, when `autocorrect` replaced the results.
+	CorrectedQuery *string `json:"corrected_query,omitempty"`
+
+	// Results The results, ordered by fused score or, for reranked results, by rerank score.
+	Results *[]SearchResult `json:"results,omitempty"`
+
+	// Suggestions Spelling corrections of the query, best first. Only present when the query matches fewer than `SUGGEST_MIN_RESULTS` documents lexically.
+	Suggestions *[]string `json:"suggestions,omitempty"`
+}
+
+// SearchResult defines model for SearchResult.
+type SearchResult struct {
+	DocumentId *string `json:"document_id,omitempty"`
+
+	// Explanation Per-retriever breakdown of the fused score. Only present when `explain=true`.
+	Explanation *[]RetrieverContribution `json:"explanation,omitempty"`
+
+	// Highlights Fragments of the text with query matches marked. Only present when `highlight=true`.
+	Highlights       *[]string          `json:"highlights,omitempty"`
+	Metadata         *map[string]string `json:"metadata,omitempty"`
+	ParentDocumentId *string            `json:"parent_document_id,omitempty"`
+
+	// RerankScore The reranker's relevance score. Only present for results the reranker scored.
+	RerankScore *float64 `json:"rerank_score,omitempty"`
+
+	// Score The fused score of the result.
+	Score *float64 `json:"score,omitempty"`
+	Text  *string  `json:"text,omitempty"`
+}
+
 // StoreRequest defines model for StoreRequest.
 type StoreRequest struct {
+	// Metadata Attributes, such as tags, that queries can filter on with `field:value`.
+	Metadata *map[string]string `json:"metadata,omitempty"`
+
 	// Text The text content of the document to store.
 	Text string `json:"text"`
+
+	// Title An optional title. Titles are offered as autocomplete suggestions by `/suggest`.
+	Title *string `json:"title,omitempty"`
 }
 
+// StoreResponse defines model for StoreResponse.
+type StoreResponse struct {
+	DocumentId *string `json:"document_id,omitempty"`
+
+	// FailedChunks The chunks that could not be stored. Only set with the `partial` policy.
+	FailedChunks *[]ChunkFailure `json:"failed_chunks,omitempty"`
+	Message      *string         `json:"message,omitempty"`
+
+	// PendingStores The stores the document is still waiting to be written to. Only set with `202`.
+	PendingStores *[]StoreResponsePendingStores `json:"pending_stores,omitempty"`
+}
+
+// StoreResponsePendingStores defines model for StoreResponse.PendingStores.
+type StoreResponsePendingStores string
+
 // SuccessMessage defines model for SuccessMessage.
 type SuccessMessage struct {
 	Message *string `json:"message,omitempty"`
 }
 
+// SuggestResponse defines model for SuggestResponse.
+type SuggestResponse struct {
+	// Suggestions Completions of the prefix, most popular first.
+	Suggestions *[]string `json:"suggestions,omitempty"`
+}
+
+// VersionResponse defines model for VersionResponse.
+type VersionResponse struct {
+	DocumentId *string `json:"document_id,omitempty"`
+	Message    *string `json:"message,omitempty"`
+
+	// Version The version the write recorded.
+	Version *int `json:"version,omitempty"`
+}
+
+// UploadDocumentMultipartBody defines parameters for UploadDocument.
+type UploadDocumentMultipartBody struct {
+	// File The file to extract text from.
+	File openapi_types.File `json:"file"`
+
+	// Metadata A JSON object of string attributes to store with the document, such as `{"tag": "billing"}`.
+	Metadata *string `json:"metadata,omitempty"`
+
+	// Title A title for the document. Defaults to the title found in the file, or its name.
+	Title *string `json:"title,omitempty"`
+}
+
+// UploadDocumentParams defines parameters for UploadDocument.
+type UploadDocumentParams struct {
+	// Async Queue the document for ingestion in the background, as with `/store`.
+	Async *bool `form:"async,omitempty" json:"async,omitempty"`
+
+	// FailurePolicy What to do when chunks still fail after retries, as with `/store`.
+	FailurePolicy *FailurePolicy `form:"failure_policy,omitempty" json:"failure_policy,omitempty"`
+}
+
+// PutDocumentParams defines parameters for PutDocument.
+type PutDocumentParams struct {
+	// IfMatch The version the update is based on, as returned in `ETag`, such as `"3"`. `"0"` only creates a document that has no versions, and `*` only updates one that has.
+	IfMatch *string `json:"If-Match,omitempty"`
+}
+
+// RollbackDocumentParams defines parameters for RollbackDocument.
+type RollbackDocumentParams struct {
+	// IfMatch The version the rollback replaces, as with `PUT /documents/{id}`.
+	IfMatch *string `json:"If-Match,omitempty"`
+}
+
 // QueryDocumentsParams defines parameters for QueryDocuments.
 type QueryDocumentsParams struct {
-	// Q The search query text.
+	// Q The search query. Supports `"exact phrases"`, exclusions (`-word` or `NOT word`), `AND`/`OR` with parentheses, and field filters such as `tag:billing` or `parent_document_id:<id>`. These apply to the lexical retriever; the semantic retriever is given only the free text.
 	Q string `form:"q" json:"q"`
+
+	// Fusion Overrides the server's fusion strategy for this request.
+	Fusion *QueryDocumentsParamsFusion `form:"fusion,omitempty" json:"fusion,omitempty"`
+
+	// Explain Include each retriever's raw score, rank and contribution to the fused score.
+	Explain *bool `form:"explain,omitempty" json:"explain,omitempty"`
+
+	// RrfK Overrides the server's RRF k constant for this request.
+	RrfK *float64 `form:"rrf_k,omitempty" json:"rrf_k,omitempty"`
+
+	// LexicalWeight Overrides the fusion weight of the lexical (Elasticsearch) retriever for this request.
+	LexicalWeight *float64 `form:"lexical_weight,omitempty" json:"lexical_weight,omitempty"`
+
+	// SemanticWeight Overrides the fusion weight of the semantic (vector) retriever for this request.
+	SemanticWeight *float64 `form:"semantic_weight,omitempty" json:"semantic_weight,omitempty"`
+
+	// MmrLambda Enables Maximal Marginal Relevance diversification of the fused results. 1 keeps the fused order and lower values trade relevance for diversity. Requests fail with 400 when no document vectors are available.
+	MmrLambda *float64 `form:"mmr_lambda,omitempty" json:"mmr_lambda,omitempty"`
+
+	// MaxPerParent The maximum number of results returned for any single parent document.
+	MaxPerParent *int `form:"max_per_parent,omitempty" json:"max_per_parent,omitempty"`
+
+	// Reranker Selects a configured reranker (for example `http` or `lexical`) to re-order the top fused results, or `none` to skip reranking. Defaults to the server's default reranker.
+	Reranker *string `form:"reranker,omitempty" json:"reranker,omitempty"`
+
+	// Rewrite Selects a configured query rewriter to search with in addition to the query: `multi_query` for paraphrases or `hyde` for a hypothetical answer document. `none` skips rewriting. Defaults to the server's default rewriter. Rewritten searches are always fused with RRF.
+	Rewrite *string `form:"rewrite,omitempty" json:"rewrite,omitempty"`
+
+	// Autocorrect When the query finds too few results, search again with the top spelling suggestion and return its results if it finds more. The corrected query is reported in the `X-Corrected-Query` header.
+	Autocorrect *bool `form:"autocorrect,omitempty" json:"autocorrect,omitempty"`
+
+	// Highlight Include highlighted fragments of each result's text. Lexical matches are highlighted by Elasticsearch; other results get the snippet closest to the query in meaning.
+	Highlight *bool `form:"highlight,omitempty" json:"highlight,omitempty"`
+
+	// HighlightPreTag The tag inserted before each highlighted match. Defaults to the server's setting. Only used with `highlight=true`.
+	HighlightPreTag *string `form:"highlight_pre_tag,omitempty" json:"highlight_pre_tag,omitempty"`
+
+	// HighlightPostTag The tag inserted after each highlighted match. Defaults to the server's setting. Only used with `highlight=true`.
+	HighlightPostTag *string `form:"highlight_post_tag,omitempty" json:"highlight_post_tag,omitempty"`
+
+	// FragmentSize The approximate length of each fragment, in characters. Defaults to the server's setting. Only used with `highlight=true`.
+	FragmentSize *int `form:"fragment_size,omitempty" json:"fragment_size,omitempty"`
+}
+
+// QueryDocumentsParamsFusion defines parameters for QueryDocuments.
+type QueryDocumentsParamsFusion string
+
+// StoreDocumentParams defines parameters for StoreDocument.
+type StoreDocumentParams struct {
+	// Async Queue the document for ingestion in the background and respond with `202` and a job right away, instead of waiting for it to be stored. Poll `GET /jobs/{id}` for progress.
+	Async *bool `form:"async,omitempty" json:"async,omitempty"`
+
+	// FailurePolicy What to do when chunks still fail after retries. `all_or_nothing` removes everything already written and responds with `500`; `partial` keeps the chunks that were stored and responds with `207`, listing the failed chunks. Defaults to the server's policy. Can't be combined with `async=true`, since jobs use the server's policy.
+	FailurePolicy *FailurePolicy `form:"failure_policy,omitempty" json:"failure_policy,omitempty"`
+}
+
+// SuggestCompletionsParams defines parameters for SuggestCompletions.
+type SuggestCompletionsParams struct {
+	// Prefix The text typed so far.
+	Prefix string `form:"prefix" json:"prefix"`
+
+	// Size The maximum number of completions to return.
+	Size *int `form:"size,omitempty" json:"size,omitempty"`
 }
 
+// AnswerQuestionJSONRequestBody defines body for AnswerQuestion for application/json ContentType.
+type AnswerQuestionJSONRequestBody = AnswerRequest
+
+// UploadDocumentMultipartRequestBody defines body for UploadDocument for multipart/form-data ContentType.
+type UploadDocumentMultipartRequestBody UploadDocumentMultipartBody
+
+// PutDocumentJSONRequestBody defines body for PutDocument for application/json ContentType.
+type PutDocumentJSONRequestBody = StoreRequest
+
+// RollbackDocumentJSONRequestBody defines body for RollbackDocument for application/json ContentType.
+type RollbackDocumentJSONRequestBody = RollbackRequest
+
+// BatchStoreDocumentsJSONRequestBody defines body for BatchStoreDocuments for application/json ContentType.
+type BatchStoreDocumentsJSONRequestBody = BatchStoreRequest
+
 // StoreDocumentJSONRequestBody defines body for StoreDocument for application/json ContentType.
 type StoreDocumentJSONRequestBody = StoreRequest
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Reload the synonym set from the synonyms file
+	// (POST /admin/synonyms/reload)
+	ReloadSynonyms(w http.ResponseWriter, r *http.Request)
+	// Answer a question from the stored documents
+	// (POST /answer)
+	AnswerQuestion(w http.ResponseWriter, r *http.Request)
+	// Upload a file and store its text
+	// (POST /documents/upload)
+	UploadDocument(w http.ResponseWriter, r *http.Request, params UploadDocumentParams)
+	// Create or replace a document under a client ID
+	// (PUT /documents/{id})
+	PutDocument(w http.ResponseWriter, r *http.Request, id string, params PutDocumentParams)
+	// Restore an earlier version of a document
+	// (POST /documents/{id}/rollback)
+	RollbackDocument(w http.ResponseWriter, r *http.Request, id string, params RollbackDocumentParams)
+	// List the versions of a document
+	// (GET /documents/{id}/versions)
+	ListDocumentVersions(w http.ResponseWriter, r *http.Request, id string)
+	// Store a batch of documents
+	// (POST /documents:batch)
+	BatchStoreDocuments(w http.ResponseWriter, r *http.Request)
+	// Get the status of an ingestion job
+	// (GET /jobs/{id})
+	GetJob(w http.ResponseWriter, r *http.Request, id string)
 	// Query for documents
 	// (GET /query)
 	QueryDocuments(w http.ResponseWriter, r *http.Request, params QueryDocumentsParams)
 	// Store a new document
 	// (POST /store)
-	StoreDocument(w http.ResponseWriter, r *http.Request)
+	StoreDocument(w http.ResponseWriter, r *http.Request, params StoreDocumentParams)
+	// Autocomplete a partially typed query
+	// (GET /suggest)
+	SuggestCompletions(w http.ResponseWriter, r *http.Request, params SuggestCompletionsParams)
 }
 
 // Unimplemented server implementation that returns http.StatusNotImplemented for each endpoint.
 
 type Unimplemented struct{}
 
+// Reload the synonym set from the synonyms file
+// (POST /admin/synonyms/reload)
+func (_ Unimplemented) ReloadSynonyms(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Answer a question from the stored documents
+// (POST /answer)
+func (_ Unimplemented) AnswerQuestion(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Upload a file and store its text
+// (POST /documents/upload)
+func (_ Unimplemented) UploadDocument(w http.ResponseWriter, r *http.Request, params UploadDocumentParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Create or replace a document under a client ID
+// (PUT /documents/{id})
+func (_ Unimplemented) PutDocument(w http.ResponseWriter, r *http.Request, id string, params PutDocumentParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Restore an earlier version of a document
+// (POST /documents/{id}/rollback)
+func (_ Unimplemented) RollbackDocument(w http.ResponseWriter, r *http.Request, id string, params RollbackDocumentParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// List the versions of a document
+// (GET /documents/{id}/versions)
+func (_ Unimplemented) ListDocumentVersions(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Store a batch of documents
+// (POST /documents:batch)
+func (_ Unimplemented) BatchStoreDocuments(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Get the status of an ingestion job
+// (GET /jobs/{id})
+func (_ Unimplemented) GetJob(w http.ResponseWriter, r *http.Request, id string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Query for documents
 // (GET /query)
 func (_ Unimplemented) QueryDocuments(w http.ResponseWriter, r *http.Request, params QueryDocumentsParams) {
@@ -65,7 +516,13 @@ func (_ Unimplemented) QueryDocuments(w http.ResponseWriter, r *http.Request, pa
 
 // Store a new document
 // (POST /store)
-func (_ Unimplemented) StoreDocument(w http.ResponseWriter, r *http.Request) {
+func (_ Unimplemented) StoreDocument(w http.ResponseWriter, r *http.Request, params StoreDocumentParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Autocomplete a partially typed query
+// (GET /suggest)
+func (_ Unimplemented) SuggestCompletions(w http.ResponseWriter, r *http.Request, params SuggestCompletionsParams) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
@@ -78,6 +535,231 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(http.Handler) http.Handler
 
+// ReloadSynonyms operation middleware
+func (siw *ServerInterfaceWrapper) ReloadSynonyms(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ReloadSynonyms(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// AnswerQuestion operation middleware
+func (siw *ServerInterfaceWrapper) AnswerQuestion(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.AnswerQuestion(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UploadDocument operation middleware
+func (siw *ServerInterfaceWrapper) UploadDocument(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params UploadDocumentParams
+
+	// ------------- Optional query parameter "async" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "async", r.URL.Query(), &params.Async)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "async", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "failure_policy" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "failure_policy", r.URL.Query(), &params.FailurePolicy)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "failure_policy", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UploadDocument(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PutDocument operation middleware
+func (siw *ServerInterfaceWrapper) PutDocument(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PutDocumentParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-Match", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-Match", valueList[0], &IfMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-Match", Err: err})
+			return
+		}
+
+		params.IfMatch = &IfMatch
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PutDocument(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RollbackDocument operation middleware
+func (siw *ServerInterfaceWrapper) RollbackDocument(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params RollbackDocumentParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-Match", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-Match", valueList[0], &IfMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-Match", Err: err})
+			return
+		}
+
+		params.IfMatch = &IfMatch
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RollbackDocument(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListDocumentVersions operation middleware
+func (siw *ServerInterfaceWrapper) ListDocumentVersions(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListDocumentVersions(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// BatchStoreDocuments operation middleware
+func (siw *ServerInterfaceWrapper) BatchStoreDocuments(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.BatchStoreDocuments(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetJob operation middleware
+func (siw *ServerInterfaceWrapper) GetJob(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", chi.URLParam(r, "id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetJob(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // QueryDocuments operation middleware
 func (siw *ServerInterfaceWrapper) QueryDocuments(w http.ResponseWriter, r *http.Request) {
 
@@ -101,6 +783,118 @@ func (siw *ServerInterfaceWrapper) QueryDocuments(w http.ResponseWriter, r *http
 		return
 	}
 
+	// ------------- Optional query parameter "fusion" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "fusion", r.URL.Query(), &params.Fusion)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fusion", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "explain" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "explain", r.URL.Query(), &params.Explain)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "explain", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "rrf_k" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "rrf_k", r.URL.Query(), &params.RrfK)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "rrf_k", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "lexical_weight" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "lexical_weight", r.URL.Query(), &params.LexicalWeight)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "lexical_weight", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "semantic_weight" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "semantic_weight", r.URL.Query(), &params.SemanticWeight)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "semantic_weight", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "mmr_lambda" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "mmr_lambda", r.URL.Query(), &params.MmrLambda)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "mmr_lambda", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "max_per_parent" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "max_per_parent", r.URL.Query(), &params.MaxPerParent)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "max_per_parent", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "reranker" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "reranker", r.URL.Query(), &params.Reranker)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "reranker", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "rewrite" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "rewrite", r.URL.Query(), &params.Rewrite)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "rewrite", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "autocorrect" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "autocorrect", r.URL.Query(), &params.Autocorrect)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "autocorrect", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "highlight" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "highlight", r.URL.Query(), &params.Highlight)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "highlight", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "highlight_pre_tag" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "highlight_pre_tag", r.URL.Query(), &params.HighlightPreTag)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "highlight_pre_tag", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "highlight_post_tag" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "highlight_post_tag", r.URL.Query(), &params.HighlightPostTag)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "highlight_post_tag", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "fragment_size" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "fragment_size", r.URL.Query(), &params.FragmentSize)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "fragment_size", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.QueryDocuments(w, r, params)
 	}))
@@ -115,8 +909,71 @@ func (siw *ServerInterfaceWrapper) QueryDocuments(w http.ResponseWriter, r *http
 // StoreDocument operation middleware
 func (siw *ServerInterfaceWrapper) StoreDocument(w http.ResponseWriter, r *http.Request) {
 
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params StoreDocumentParams
+
+	// ------------- Optional query parameter "async" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "async", r.URL.Query(), &params.Async)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "async", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "failure_policy" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "failure_policy", r.URL.Query(), &params.FailurePolicy)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "failure_policy", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.StoreDocument(w, r)
+		siw.Handler.StoreDocument(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// SuggestCompletions operation middleware
+func (siw *ServerInterfaceWrapper) SuggestCompletions(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params SuggestCompletionsParams
+
+	// ------------- Required query parameter "prefix" -------------
+
+	if paramValue := r.URL.Query().Get("prefix"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "prefix"})
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "prefix", r.URL.Query(), &params.Prefix)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "prefix", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "size" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "size", r.URL.Query(), &params.Size)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "size", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.SuggestCompletions(w, r, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -239,12 +1096,39 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/admin/synonyms/reload", wrapper.ReloadSynonyms)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/answer", wrapper.AnswerQuestion)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/documents/upload", wrapper.UploadDocument)
+	})
+	r.Group(func(r chi.Router) {
+		r.Put(options.BaseURL+"/documents/{id}", wrapper.PutDocument)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/documents/{id}/rollback", wrapper.RollbackDocument)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/documents/{id}/versions", wrapper.ListDocumentVersions)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/documents:batch", wrapper.BatchStoreDocuments)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/jobs/{id}", wrapper.GetJob)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/query", wrapper.QueryDocuments)
 	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/store", wrapper.StoreDocument)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/suggest", wrapper.SuggestCompletions)
+	})
 
 	return r
 }
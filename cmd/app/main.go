@@ -5,55 +5,236 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/internal/config"
+	"github.com/chr1sbest/hybrid-search/pkg/answer"
+	"github.com/chr1sbest/hybrid-search/pkg/autocomplete"
 	"github.com/chr1sbest/hybrid-search/pkg/embeddings"
 	"github.com/chr1sbest/hybrid-search/pkg/handlers"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	"github.com/chr1sbest/hybrid-search/pkg/jobs"
+	"github.com/chr1sbest/hybrid-search/pkg/llm"
+	"github.com/chr1sbest/hybrid-search/pkg/outbox"
+	"github.com/chr1sbest/hybrid-search/pkg/ranking"
+	"github.com/chr1sbest/hybrid-search/pkg/rerank"
+	"github.com/chr1sbest/hybrid-search/pkg/retry"
+	"github.com/chr1sbest/hybrid-search/pkg/rewrite"
 	"github.com/chr1sbest/hybrid-search/pkg/search"
 	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/chr1sbest/hybrid-search/pkg/synonyms"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/joho/godotenv"
 	"github.com/swaggest/swgui/v5emb"
 )
 
 func main() {
 	// Load environment variables from .env file
-	err := godotenv.Load()
-	if err != nil {
-		// This is a normal and expected condition when running in a containerized environment
-		// where environment variables are injected directly.
-		log.Println("No .env file found, relying on environment variables.")
-	}
-
-	pineconeAPIKey := getEnv("PINECONE_API_KEY", "")
-	pineconeIndexName := getEnv("PINECONE_INDEX_NAME", "semantic-search-api")
-	elasticAddress := getEnv("ELASTICSEARCH_ADDRESS", "http://localhost:9200")
-	elasticIndexName := getEnv("ELASTICSEARCH_INDEX", "go-semantic-search")
+	config.Load()
 
 	ctx := context.Background()
 
 	// Initialize Pinecone client
-	vectorStore, err := storage.NewPineconeClient(ctx, pineconeAPIKey, pineconeIndexName)
+	vectorStore, err := config.NewVectorStore(ctx)
 	if err != nil {
 		log.Fatalf("Failed to create Pinecone client: %v", err)
 	}
 
-	// Initialize Elasticsearch client
-	textStore, err := storage.NewElasticsearchClient(elasticAddress, elasticIndexName)
+	// Initialize Elasticsearch client, with the synonyms, fuzziness, refresh policy and bulk
+	// limits the command-line tools use too.
+	textStore, err := config.NewTextStore()
 	if err != nil {
 		log.Fatalf("Failed to create Elasticsearch client: %v", err)
 	}
 
 	embeddingClient := embeddings.NewPassthroughEmbeddingService()
 
-	searchService := search.NewSearchService(embeddingClient, vectorStore, textStore)
+	fusionConfig := ranking.FusionConfig{
+		Strategy: ranking.Strategy(config.String("FUSION_STRATEGY", string(ranking.StrategyRRF))),
+		K:        config.Float("RRF_K", ranking.DefaultK),
+		Weights: map[string]float64{
+			ranking.RetrieverLexical:  config.Float("RRF_LEXICAL_WEIGHT", 1.0),
+			ranking.RetrieverSemantic: config.Float("RRF_SEMANTIC_WEIGHT", 1.0),
+		},
+	}
+
+	if _, err := ranking.NewFuser(fusionConfig.Strategy); err != nil {
+		log.Fatalf("Invalid FUSION_STRATEGY: %v", err)
+	}
+
+	searchOptions := []search.Option{
+		search.WithFusionConfig(fusionConfig),
+		search.WithSpellingSuggestions(config.Int("SUGGEST_MIN_RESULTS", 3)),
+		search.WithHighlightDefaults(storage.Highlight{
+			PreTag:            config.String("HIGHLIGHT_PRE_TAG", search.DefaultHighlight.PreTag),
+			PostTag:           config.String("HIGHLIGHT_POST_TAG", search.DefaultHighlight.PostTag),
+			FragmentSize:      config.Int("HIGHLIGHT_FRAGMENT_SIZE", search.DefaultHighlight.FragmentSize),
+			NumberOfFragments: config.Int("HIGHLIGHT_FRAGMENTS", search.DefaultHighlight.NumberOfFragments),
+		}),
+	}
+
+	// Set up the rerankers. The lexical reranker needs no model and is always available.
+	rerankTopN := config.Int("RERANK_TOP_N", 20)
+	rerankTimeout := config.Duration("RERANK_TIMEOUT", 2*time.Second)
+	rerankers := map[string]bool{"lexical": true}
+	searchOptions = append(searchOptions, search.WithReranker("lexical", rerank.NewLexicalReranker(), rerankTopN, rerankTimeout))
+
+	defaultReranker := ""
+	if rerankerURL := config.String("RERANKER_URL", ""); rerankerURL != "" {
+		httpReranker, err := rerank.NewHTTPReranker(
+			rerankerURL,
+			rerank.Protocol(config.String("RERANKER_PROTOCOL", string(rerank.ProtocolTEI))),
+			config.String("RERANKER_MODEL", ""),
+			config.String("RERANKER_API_KEY", ""),
+		)
+		if err != nil {
+			log.Fatalf("Failed to create reranker: %v", err)
+		}
+		rerankers["http"] = true
+		searchOptions = append(searchOptions, search.WithReranker("http", httpReranker, rerankTopN, rerankTimeout))
+		defaultReranker = "http"
+	}
+
+	// The chat model backs the LLM reranker and the /answer endpoint.
+	var chatClient llm.ChatClient
+	if llmURL := config.String("LLM_BASE_URL", ""); llmURL != "" {
+		chatClient = llm.NewOpenAIClient(llmURL, config.String("LLM_MODEL", ""), config.String("LLM_API_KEY", ""))
+	}
+
+	// The LLM reranker is slower and more expensive, so it has its own limits and is only
+	// used when a request asks for it or RERANKER_DEFAULT selects it.
+	if chatClient != nil {
+		llmReranker := rerank.NewLLMReranker(
+			chatClient,
+			config.Int("LLM_RERANK_MAX_TOKENS", 3000),
+			config.Int("LLM_RERANK_CACHE_SIZE", 1000),
+			config.Duration("LLM_RERANK_CACHE_TTL", time.Hour),
+		)
+		rerankers["llm"] = true
+		searchOptions = append(searchOptions, search.WithReranker(
+			"llm",
+			llmReranker,
+			config.Int("LLM_RERANK_TOP_N", 10),
+			config.Duration("LLM_RERANK_TIMEOUT", 10*time.Second),
+		))
+	}
+
+	// Query rewriting also needs the chat model. It is off unless a request or
+	// REWRITE_DEFAULT selects a rewriter.
+	rewriters := map[string]bool{}
+	if chatClient != nil {
+		rewriteCacheSize := config.Int("REWRITE_CACHE_SIZE", 1000)
+		rewriteCacheTTL := config.Duration("REWRITE_CACHE_TTL", time.Hour)
+		rewriteTimeout := config.Duration("REWRITE_TIMEOUT", 5*time.Second)
+		rewriters["multi_query"] = true
+		rewriters["hyde"] = true
+		searchOptions = append(searchOptions,
+			search.WithRewriter("multi_query", rewrite.NewMultiQueryRewriter(chatClient, config.Int("REWRITE_QUERIES", 3), rewriteCacheSize, rewriteCacheTTL), rewriteTimeout),
+			search.WithRewriter("hyde", rewrite.NewHyDERewriter(chatClient, rewriteCacheSize, rewriteCacheTTL), rewriteTimeout),
+		)
+	}
+
+	defaultRewriter := config.String("REWRITE_DEFAULT", "")
+	if !rewriters[defaultRewriter] && defaultRewriter != "" && defaultRewriter != search.NoRewriter {
+		log.Fatalf("Unknown REWRITE_DEFAULT %q", defaultRewriter)
+	}
+	searchOptions = append(searchOptions, search.WithDefaultRewriter(defaultRewriter))
+
+	defaultReranker = config.String("RERANKER_DEFAULT", defaultReranker)
+	if !rerankers[defaultReranker] && defaultReranker != "" && defaultReranker != search.NoReranker {
+		log.Fatalf("Unknown RERANKER_DEFAULT %q", defaultReranker)
+	}
+	searchOptions = append(searchOptions, search.WithDefaultReranker(defaultReranker))
+
+	searchService := search.NewSearchService(embeddingClient, vectorStore, textStore, searchOptions...)
 
 	env := &handlers.Env{
-		EmbeddingClient: embeddingClient,
-		VectorStore:     vectorStore,
-		TextStore:       textStore,
-		SearchService:   searchService,
+		SearchService: searchService,
+		Autocompleter: textStore,
+	}
+
+	// Every write is recorded as a new version in VERSIONS_DIR: PUT /documents/{id} and
+	// rollbacks by their handlers, and everything else the pipeline or the outbox writes.
+	env.History, err = config.NewHistory()
+	if err != nil {
+		log.Fatalf("Failed to create version store: %v", err)
+	}
+
+	// Every write goes through the ingest pipeline, which retries each step with exponential
+	// backoff. /store and background jobs then apply the failure policy to chunks that still
+	// fail.
+	failurePolicy := ingest.FailurePolicy(config.String("STORE_FAILURE_POLICY", string(ingest.AllOrNothing)))
+	if failurePolicy != ingest.AllOrNothing && failurePolicy != ingest.Partial {
+		log.Fatalf("Invalid STORE_FAILURE_POLICY %q: must be all_or_nothing or partial", failurePolicy)
+	}
+	pipeline := ingest.NewPipeline(
+		config.NewChunker(),
+		embeddingClient,
+		vectorStore,
+		textStore,
+		config.Int("INGEST_BATCH_SIZE", 20),
+		config.Int("INGEST_CONCURRENCY", 4),
+		ingest.WithHistory(env.History),
+		ingest.WithRetry(config.NewStoreRetry()),
+		ingest.WithFailurePolicy(failurePolicy),
+	)
+	env.Pipeline = pipeline
+	env.IngestService = pipeline
+	env.MaxBatchDocuments = config.Int("BATCH_MAX_DOCUMENTS", handlers.DefaultMaxBatchDocuments)
+	env.MaxUploadBytes = int64(config.Int("UPLOAD_MAX_BYTES", handlers.DefaultMaxUploadBytes))
+
+	// With OUTBOX_DIR set, /store records each document in a local journal first and retries
+	// failed writes in the background until both stores have the document.
+	if outboxDir := config.String("OUTBOX_DIR", ""); outboxDir != "" {
+		journal, err := outbox.NewFileJournal(outboxDir)
+		if err != nil {
+			log.Fatalf("Failed to create outbox journal: %v", err)
+		}
+		env.Outbox, err = outbox.New(journal, pipeline, retry.Policy{
+			InitialBackoff: config.Duration("OUTBOX_RETRY_BACKOFF", time.Second),
+			MaxBackoff:     config.Duration("OUTBOX_RETRY_MAX_BACKOFF", 5*time.Minute),
+		})
+		if err != nil {
+			log.Fatalf("Failed to load outbox: %v", err)
+		}
+		if pending := env.Outbox.Len(); pending > 0 {
+			log.Printf("Resuming %d pending outbox entries", pending)
+		}
+		go env.Outbox.Run(ctx, config.Duration("OUTBOX_POLL_INTERVAL", 5*time.Second))
+	}
+	if synonymsFile := config.String("SYNONYMS_FILE", ""); synonymsFile != "" {
+		env.Synonyms = synonyms.NewManager(synonymsFile, textStore)
+	}
+	env.AdminToken = config.String("ADMIN_TOKEN", "")
+	if env.AdminToken == "" {
+		log.Printf("ADMIN_TOKEN is not set; the /admin endpoints are unauthenticated")
+	}
+	// Frequent queries are offered as completions once they reach AUTOCOMPLETE_MIN_QUERY_COUNT
+	// searches. Counts are flushed to the text store every AUTOCOMPLETE_FLUSH_INTERVAL.
+	env.QueryLog = autocomplete.NewQueryLog(textStore, config.Int("AUTOCOMPLETE_MIN_QUERY_COUNT", 3))
+	go env.QueryLog.Run(ctx, config.Duration("AUTOCOMPLETE_FLUSH_INTERVAL", time.Minute))
+
+	// Background ingestion jobs (/store?async=true) are saved in JOBS_DIR, so pending jobs
+	// are picked up again after a restart.
+	jobStore, err := jobs.NewFileStore(config.String("JOBS_DIR", "data/jobs"))
+	if err != nil {
+		log.Fatalf("Failed to create job store: %v", err)
+	}
+	env.Jobs, err = jobs.NewManager(
+		jobStore,
+		pipeline,
+		config.Int("JOBS_WORKERS", 2),
+		config.Int("JOBS_QUEUE_SIZE", 1000),
+		config.Duration("JOBS_RETENTION", 24*time.Hour),
+	)
+	if err != nil {
+		log.Fatalf("Failed to load ingestion jobs: %v", err)
+	}
+	go env.Jobs.Run(ctx)
+
+	if chatClient != nil {
+		env.AnswerService = answer.NewGenerator(searchService, chatClient, config.Int("ANSWER_MAX_CONTEXT_TOKENS", 3000))
 	}
 
 	// Create the router from the generated OpenAPI spec.
@@ -87,11 +268,3 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
-
-// getEnv reads an environment variable or returns a default value.
-func getEnv(key, fallback string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return fallback
-}
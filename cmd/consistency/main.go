@@ -0,0 +1,164 @@
+// Command consistency compares the documents in the text store with the chunks in the vector
+// store, reports where they disagree, and optionally repairs them.
+//
+// Usage:
+//
+//	go run ./cmd/consistency [-rechunk] [-delete-orphans] [-dry-run] [-show N]
+//
+// It reads the same environment variables as the API server. Without a repair flag it exits
+// with status 1 when the stores are inconsistent.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/chr1sbest/hybrid-search/internal/config"
+	"github.com/chr1sbest/hybrid-search/pkg/consistency"
+	"github.com/chr1sbest/hybrid-search/pkg/embeddings"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	"github.com/chr1sbest/hybrid-search/pkg/jobs"
+	"github.com/chr1sbest/hybrid-search/pkg/outbox"
+)
+
+func main() {
+	rechunk := flag.Bool("rechunk", false, "re-chunk documents with missing chunks from their text in the text store")
+	deleteOrphans := flag.Bool("delete-orphans", false, "delete orphan chunks, and orphan documents unless -rechunk is set")
+	dryRun := flag.Bool("dry-run", false, "report what would be repaired without changing either store")
+	show := flag.Int("show", 10, "the number of IDs to list for each kind of problem")
+	flag.Parse()
+
+	config.Load()
+	ctx := context.Background()
+
+	vectorStore, err := config.NewVectorStore(ctx)
+	if err != nil {
+		log.Fatalf("Failed to create Pinecone client: %v", err)
+	}
+	textStore, err := config.NewTextStore()
+	if err != nil {
+		log.Fatalf("Failed to create Elasticsearch client: %v", err)
+	}
+
+	// Documents are re-chunked through the ingest pipeline, so their chunks are written the
+	// same way the server writes them.
+	pipeline := ingest.NewPipeline(
+		config.NewChunker(),
+		embeddings.NewPassthroughEmbeddingService(),
+		vectorStore,
+		textStore,
+		config.Int("INGEST_BATCH_SIZE", 20),
+		1,
+		ingest.WithRetry(config.NewStoreRetry()),
+	)
+	checker, err := consistency.NewChecker(textStore, vectorStore, pipeline, config.NewChunker())
+	if err != nil {
+		log.Fatalf("Failed to create consistency checker: %v", err)
+	}
+
+	report, err := checker.Check(ctx)
+	if err != nil {
+		log.Fatalf("Consistency check failed: %v", err)
+	}
+	printReport(report, *show)
+
+	if !*rechunk && !*deleteOrphans {
+		if !report.Consistent() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	inFlight, err := loadInFlight()
+	if err != nil {
+		log.Fatalf("Failed to load pending writes: %v", err)
+	}
+	result, err := checker.Repair(ctx, report, consistency.RepairOptions{
+		Rechunk:       *rechunk,
+		DeleteOrphans: *deleteOrphans,
+		DryRun:        *dryRun,
+		InFlight:      inFlight,
+	})
+	verb := "Repaired"
+	if *dryRun {
+		verb = "Would repair"
+	}
+	fmt.Printf("%s: re-chunked %d documents (%d chunks), deleted %d orphan chunks and %d orphan documents\n",
+		verb, result.RechunkedParents, result.WrittenChunks, result.DeletedChunks, result.DeletedParents)
+	if err != nil {
+		log.Fatalf("Repair failed: %v", err)
+	}
+}
+
+// loadInFlight returns the documents that the server hasn't finished writing: those with
+// entries in the outbox journal in OUTBOX_DIR, and those of unfinished jobs in JOBS_DIR. They
+// are left out of repairs, so a write that lands while the stores are enumerated isn't undone.
+func loadInFlight() (map[string]bool, error) {
+	inFlight := make(map[string]bool)
+	if outboxDir := config.String("OUTBOX_DIR", ""); outboxDir != "" {
+		journal, err := outbox.NewFileJournal(outboxDir)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := journal.Load()
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			inFlight[entry.Document.DocumentID] = true
+		}
+	}
+	jobStore, err := jobs.NewFileStore(config.String("JOBS_DIR", "data/jobs"))
+	if err != nil {
+		return nil, err
+	}
+	pending, err := jobStore.Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range pending {
+		if !job.Finished() {
+			inFlight[job.Document.DocumentID] = true
+		}
+	}
+	return inFlight, nil
+}
+
+// printReport writes a summary of report, listing at most show IDs of each kind.
+func printReport(report *consistency.Report, show int) {
+	fmt.Printf("Checked %d documents and %d chunks\n", report.Parents, report.Chunks)
+
+	missing := make([]string, 0, len(report.MissingChunks))
+	for parent, ids := range report.MissingChunks {
+		missing = append(missing, fmt.Sprintf("%s (%d missing)", parent, len(ids)))
+	}
+	slices.Sort(missing)
+
+	printIDs("Orphan documents (no chunks)", report.OrphanParents, show)
+	printIDs("Documents with missing chunks", missing, show)
+	printIDs("Orphan chunks", report.OrphanChunks, show)
+	printIDs("Unrecognized chunk IDs", report.UnrecognizedChunks, show)
+	printIDs("Documents with unrecognized chunks (not checked)", report.LegacyParents, show)
+	if report.Consistent() {
+		fmt.Println("The stores are consistent.")
+	}
+}
+
+// printIDs writes a heading with the number of IDs and lists at most show of them.
+func printIDs(heading string, ids []string, show int) {
+	if len(ids) == 0 {
+		return
+	}
+	fmt.Printf("%s: %d\n", heading, len(ids))
+	show = max(show, 0)
+	for _, id := range ids[:min(show, len(ids))] {
+		fmt.Printf("  %s\n", id)
+	}
+	if len(ids) > show {
+		fmt.Printf("  ... and %d more\n", len(ids)-show)
+	}
+}
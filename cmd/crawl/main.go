@@ -0,0 +1,106 @@
+// Command crawl fetches pages from seed URLs or sitemaps and stores their main content, with
+// each page's URL as its document ID, either in-process or through the POST /documents:batch
+// endpoint of a running server.
+//
+// Usage:
+//
+//	go run ./cmd/crawl -seed https://docs.example.com/ [-depth 2] [-server http://localhost:8080]
+//	go run ./cmd/crawl -sitemap https://docs.example.com/sitemap.xml -depth 0
+//
+// The crawler obeys robots.txt and waits -delay between requests to the same host. The ETag
+// and Last-Modified of stored pages are kept in -state, so crawling again only stores pages
+// that changed. In-process, it reads the same environment variables as the API server.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/internal/config"
+	"github.com/chr1sbest/hybrid-search/pkg/crawler"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+)
+
+func main() {
+	seeds := flag.String("seed", "", "comma-separated URLs to start crawling from")
+	sitemaps := flag.String("sitemap", "", "comma-separated sitemap or sitemap index URLs whose pages are crawled")
+	depth := flag.Int("depth", 2, "how many links away from a seed or sitemap page to crawl")
+	maxPages := flag.Int("max-pages", 1000, "the most URLs to visit; 0 means no limit")
+	delay := flag.Duration("delay", time.Second, "the least time between requests to the same host")
+	concurrency := flag.Int("concurrency", 4, "the number of pages fetched in parallel")
+	userAgent := flag.String("user-agent", crawler.DefaultUserAgent, "the User-Agent sent to sites and matched against robots.txt")
+	allowHosts := flag.String("allow-hosts", "", "comma-separated hosts whose links are followed (default: the hosts of the seeds and sitemaps)")
+	statePath := flag.String("state", "data/crawl-state.json", "a file recording the ETags of stored pages, for incremental re-crawls")
+	server := flag.String("server", "", "the base URL of a running server; pages are stored in-process when empty")
+	batchSize := flag.Int("batch-size", 20, "the number of pages stored at once")
+	flag.Parse()
+
+	cfg := crawler.Config{
+		Seeds:        splitList(*seeds),
+		Sitemaps:     splitList(*sitemaps),
+		MaxDepth:     *depth,
+		MaxPages:     *maxPages,
+		Delay:        *delay,
+		Concurrency:  *concurrency,
+		UserAgent:    *userAgent,
+		AllowedHosts: splitList(*allowHosts),
+		BatchSize:    *batchSize,
+	}
+	if len(cfg.Seeds) == 0 && len(cfg.Sitemaps) == 0 {
+		fmt.Fprintln(os.Stderr, "At least one -seed or -sitemap is required.")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Stop cleanly on Ctrl-C, so the state holds every page that was stored.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	state, err := crawler.NewFileState(*statePath)
+	if err != nil {
+		log.Fatalf("Failed to open crawl state: %v", err)
+	}
+
+	var svc ingest.Service
+	if *server != "" {
+		svc = ingest.NewRemoteClient(*server)
+	} else {
+		// The crawler stores pages in batches, so the pipeline processes each one in a single pass.
+		config.Load()
+		pipeline, err := config.NewPipeline(ctx, max(*batchSize, 1), 1)
+		if err != nil {
+			log.Fatalf("Failed to create ingestion pipeline: %v", err)
+		}
+		svc = pipeline
+	}
+
+	c, err := crawler.New(cfg, svc, state, nil)
+	if err != nil {
+		log.Fatalf("Failed to create crawler: %v", err)
+	}
+
+	start := time.Now()
+	stats, err := c.Crawl(ctx)
+	log.Printf("Fetched %d, unchanged %d, stored %d, skipped %d, failed %d in %s",
+		stats.Fetched, stats.Unchanged, stats.Stored, stats.Skipped, stats.Failed, time.Since(start).Round(time.Second))
+	if err != nil {
+		log.Fatalf("Crawl stopped: %v", err)
+	}
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,118 @@
+// Command ingest loads documents from files into the stores, either in-process or through the
+// POST /documents:batch endpoint of a running server.
+//
+// Usage:
+//
+//	go run ./cmd/ingest -jsonl docs.jsonl [-server http://localhost:8080]
+//	go run ./cmd/ingest -csv articles.csv -csv-text body [-csv-id slug] [-csv-title heading] [-csv-metadata tag,team]
+//	go run ./cmd/ingest -dir ./docs
+//
+// With -checkpoint, the IDs of stored documents are recorded in a file, and running the same
+// command again skips them. In-process, it reads the same environment variables as the API
+// server.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/internal/config"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	"github.com/chr1sbest/hybrid-search/pkg/loader"
+)
+
+func main() {
+	jsonlPath := flag.String("jsonl", "", "a JSONL file with one StoreRequest per line")
+	csvPath := flag.String("csv", "", "a CSV file whose first row names its columns")
+	dir := flag.String("dir", "", "a directory of .txt and .md files")
+	csvText := flag.String("csv-text", "text", "the CSV column holding the document text")
+	csvID := flag.String("csv-id", "", "the CSV column holding the document ID (default: file name and row number)")
+	csvTitle := flag.String("csv-title", "", "the CSV column holding the document title")
+	csvMetadata := flag.String("csv-metadata", "", "comma-separated CSV columns to store as metadata")
+	server := flag.String("server", "", "the base URL of a running server; documents are stored in-process when empty")
+	batchSize := flag.Int("batch-size", 20, "the number of documents stored at once")
+	concurrency := flag.Int("concurrency", 4, "the number of batches stored in parallel")
+	checkpointPath := flag.String("checkpoint", "", "a file recording stored documents, so an interrupted load can resume")
+	progressInterval := flag.Duration("progress", 5*time.Second, "how often to report progress")
+	flag.Parse()
+
+	var src loader.Source
+	switch {
+	case *jsonlPath != "" && *csvPath == "" && *dir == "":
+		src = loader.JSONLSource{Path: *jsonlPath}
+	case *csvPath != "" && *jsonlPath == "" && *dir == "":
+		columns := loader.Columns{Text: *csvText, ID: *csvID, Title: *csvTitle}
+		if *csvMetadata != "" {
+			columns.Metadata = strings.Split(*csvMetadata, ",")
+		}
+		src = loader.CSVSource{Path: *csvPath, Columns: columns}
+	case *dir != "" && *jsonlPath == "" && *csvPath == "":
+		src = loader.DirSource{Root: *dir}
+	default:
+		fmt.Fprintln(os.Stderr, "Exactly one of -jsonl, -csv or -dir is required.")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Stop cleanly on Ctrl-C, so the checkpoint holds every batch that finished.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var svc ingest.Service
+	if *server != "" {
+		svc = ingest.NewRemoteClient(*server)
+	} else {
+		// The loader runs batches in parallel, so the pipeline processes each one in a single pass.
+		config.Load()
+		pipeline, err := config.NewPipeline(ctx, max(*batchSize, 1), 1)
+		if err != nil {
+			log.Fatalf("Failed to create ingestion pipeline: %v", err)
+		}
+		svc = pipeline
+	}
+
+	opts := loader.Options{BatchSize: *batchSize, Concurrency: *concurrency}
+	if *checkpointPath != "" {
+		checkpoint, err := loader.OpenCheckpoint(*checkpointPath)
+		if err != nil {
+			log.Fatalf("Failed to open checkpoint: %v", err)
+		}
+		defer checkpoint.Close()
+		if done := checkpoint.Len(); done > 0 {
+			log.Printf("Resuming: %d documents already stored", done)
+		}
+		opts.Checkpoint = checkpoint
+	}
+
+	start := time.Now()
+	lastReport := start
+	opts.Progress = func(stats loader.Stats) {
+		if time.Since(lastReport) >= *progressInterval {
+			lastReport = time.Now()
+			log.Println(report(stats, time.Since(start)))
+		}
+	}
+
+	stats, err := loader.Load(ctx, src, svc, opts)
+	log.Println(report(stats, time.Since(start)))
+	if err != nil {
+		log.Fatalf("Load stopped: %v", err)
+	}
+	if stats.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// report formats the progress of a load.
+func report(stats loader.Stats, elapsed time.Duration) string {
+	stored := stats.Created + stats.Updated
+	rate := float64(stored) / max(elapsed.Seconds(), 1e-9)
+	return fmt.Sprintf("Read %d, stored %d (%d created, %d updated), skipped %d, failed %d in %s (%.1f docs/s)",
+		stats.Read, stored, stats.Created, stats.Updated, stats.Skipped, stats.Failed, elapsed.Round(time.Second), rate)
+}
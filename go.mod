@@ -9,11 +9,13 @@ require (
 	github.com/go-chi/chi/v5 v5.2.3
 	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
+	github.com/ledongthuc/pdf v0.0.0-20220302134840-0c2507a12d80
 	github.com/oapi-codegen/runtime v1.1.1
 	github.com/pinecone-io/go-pinecone/v4 v4.0.0
 	github.com/stretchr/testify v1.11.1
 	github.com/swaggest/swgui v1.8.4
 	github.com/tmc/langchaingo v0.1.13
+	golang.org/x/net v0.35.0
 	golang.org/x/sync v0.17.0
 )
 
@@ -38,7 +40,6 @@ require (
 	go.opentelemetry.io/otel v1.28.0 // indirect
 	go.opentelemetry.io/otel/metric v1.28.0 // indirect
 	go.opentelemetry.io/otel/trace v1.28.0 // indirect
-	golang.org/x/net v0.35.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20240604185151-ef581f913117 // indirect
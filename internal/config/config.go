@@ -0,0 +1,72 @@
+// Package config reads the environment variables shared by the server and the command-line
+// tools, and creates the stores and ingestion pipeline they configure, so every command sets
+// up the stores the same way.
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Load reads a .env file in the working directory into the environment, if there is one.
+func Load() {
+	if err := godotenv.Load(); err != nil {
+		// This is a normal and expected condition when running in a containerized environment
+		// where environment variables are injected directly.
+		log.Println("No .env file found, relying on environment variables.")
+	}
+}
+
+// String reads an environment variable or returns a default value.
+func String(key, fallback string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return fallback
+}
+
+// Int reads an integer environment variable or returns a default value. It exits if the
+// value isn't an integer.
+func Int(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid value for %s: %v", key, err)
+	}
+	return parsed
+}
+
+// Float reads a floating point environment variable or returns a default value. It exits if
+// the value isn't a number.
+func Float(key string, fallback float64) float64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Fatalf("Invalid value for %s: %v", key, err)
+	}
+	return parsed
+}
+
+// Duration reads a duration environment variable (e.g. "2s") or returns a default value. It
+// exits if the value isn't a duration.
+func Duration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid value for %s: %v", key, err)
+	}
+	return parsed
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironment(t *testing.T) {
+	t.Run("ReadsValues", func(t *testing.T) {
+		t.Setenv("CONFIG_TEST_STRING", "")
+		t.Setenv("CONFIG_TEST_INT", "42")
+		t.Setenv("CONFIG_TEST_FLOAT", "0.5")
+		t.Setenv("CONFIG_TEST_DURATION", "2s")
+
+		assert.Equal(t, "", String("CONFIG_TEST_STRING", "fallback"))
+		assert.Equal(t, 42, Int("CONFIG_TEST_INT", 1))
+		assert.Equal(t, 0.5, Float("CONFIG_TEST_FLOAT", 1))
+		assert.Equal(t, 2*time.Second, Duration("CONFIG_TEST_DURATION", time.Minute))
+	})
+
+	t.Run("FallsBackWhenUnset", func(t *testing.T) {
+		assert.Equal(t, "fallback", String("CONFIG_TEST_UNSET", "fallback"))
+		assert.Equal(t, 1, Int("CONFIG_TEST_UNSET", 1))
+		assert.Equal(t, 1.0, Float("CONFIG_TEST_UNSET", 1))
+		assert.Equal(t, time.Minute, Duration("CONFIG_TEST_UNSET", time.Minute))
+	})
+}
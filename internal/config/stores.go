@@ -0,0 +1,107 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/chunker"
+	"github.com/chr1sbest/hybrid-search/pkg/embeddings"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	"github.com/chr1sbest/hybrid-search/pkg/retry"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/chr1sbest/hybrid-search/pkg/synonyms"
+	"github.com/chr1sbest/hybrid-search/pkg/versions"
+)
+
+// NewVectorStore connects to the Pinecone index named by PINECONE_INDEX_NAME.
+func NewVectorStore(ctx context.Context) (*storage.PineconeClient, error) {
+	return storage.NewPineconeClient(ctx, String("PINECONE_API_KEY", ""), String("PINECONE_INDEX_NAME", "semantic-search-api"))
+}
+
+// NewTextStore connects to the Elasticsearch index named by ELASTICSEARCH_INDEX. The index is
+// created with the managed synonyms, fuzziness, refresh policy and bulk limits from the
+// environment if it doesn't exist yet, so it has the same mapping whichever command creates it.
+func NewTextStore() (*storage.ElasticsearchClient, error) {
+	indexName := String("ELASTICSEARCH_INDEX", "go-semantic-search")
+
+	// Load the managed synonyms, if configured, so the synonym set exists before the index.
+	var options []storage.ElasticsearchOption
+	if synonymsFile := String("SYNONYMS_FILE", ""); synonymsFile != "" {
+		rules, err := synonyms.LoadFile(synonymsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load synonyms: %w", err)
+		}
+		options = append(options, storage.WithSynonymSet(String("ELASTICSEARCH_SYNONYM_SET", indexName+"-synonyms"), rules))
+	}
+
+	// Typo tolerance for free-text lexical terms.
+	options = append(options, storage.WithFuzziness(storage.Fuzziness{
+		Distance:      String("LEXICAL_FUZZINESS", ""),
+		PrefixLength:  Int("LEXICAL_FUZZY_PREFIX_LENGTH", 0),
+		MaxExpansions: Int("LEXICAL_FUZZY_MAX_EXPANSIONS", 0),
+	}))
+
+	// Refresh policy and batch limits for indexing.
+	refresh, err := storage.ParseRefreshPolicy(String("ELASTICSEARCH_REFRESH", string(storage.RefreshTrue)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ELASTICSEARCH_REFRESH: %w", err)
+	}
+	options = append(options,
+		storage.WithRefreshPolicy(refresh),
+		storage.WithBulkLimits(Int("ELASTICSEARCH_BULK_MAX_DOCS", storage.DefaultBulkMaxDocs), Int("ELASTICSEARCH_BULK_MAX_BYTES", storage.DefaultBulkMaxBytes)),
+	)
+
+	return storage.NewElasticsearchClient(String("ELASTICSEARCH_ADDRESS", "http://localhost:9200"), indexName, options...)
+}
+
+// NewChunker creates a chunker with the chunk size and overlap every document is stored with.
+func NewChunker() *chunker.Chunker {
+	return chunker.NewChunker(chunker.DefaultChunkSize, chunker.DefaultChunkOverlap)
+}
+
+// NewHistory opens the document version history in VERSIONS_DIR.
+func NewHistory() (*versions.History, error) {
+	store, err := versions.NewFileStore(String("VERSIONS_DIR", "data/versions"))
+	if err != nil {
+		return nil, err
+	}
+	return versions.NewHistory(store), nil
+}
+
+// NewStoreRetry returns how writes to the stores are retried: up to STORE_MAX_ATTEMPTS
+// attempts, backing off from STORE_RETRY_BACKOFF up to STORE_RETRY_MAX_BACKOFF.
+func NewStoreRetry() retry.Policy {
+	return retry.Policy{
+		MaxAttempts:    Int("STORE_MAX_ATTEMPTS", 3),
+		InitialBackoff: Duration("STORE_RETRY_BACKOFF", 200*time.Millisecond),
+		MaxBackoff:     Duration("STORE_RETRY_MAX_BACKOFF", 5*time.Second),
+	}
+}
+
+// NewPipeline creates an in-process ingestion pipeline with the server's stores and retry
+// policy, which records the documents it stores in the version history.
+func NewPipeline(ctx context.Context, batchSize, concurrency int) (*ingest.Pipeline, error) {
+	vectorStore, err := NewVectorStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	textStore, err := NewTextStore()
+	if err != nil {
+		return nil, err
+	}
+	history, err := NewHistory()
+	if err != nil {
+		return nil, err
+	}
+	return ingest.NewPipeline(
+		NewChunker(),
+		embeddings.NewPassthroughEmbeddingService(),
+		vectorStore,
+		textStore,
+		batchSize,
+		concurrency,
+		ingest.WithHistory(history),
+		ingest.WithRetry(NewStoreRetry()),
+	), nil
+}
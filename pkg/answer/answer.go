@@ -0,0 +1,169 @@
+package answer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/chr1sbest/hybrid-search/pkg/llm"
+	"github.com/chr1sbest/hybrid-search/pkg/ranking"
+	"github.com/chr1sbest/hybrid-search/pkg/search"
+)
+
+const systemPrompt = `You are a helpful assistant that answers questions using only the numbered sources provided.
+Cite the sources that support each statement with their numbers in square brackets, for example [1] or [2][3].
+If the sources do not contain the answer, say that you don't know. Do not make up information.`
+
+// NoContextAnswer is returned, without calling the model, when the search finds no sources.
+const NoContextAnswer = "I couldn't find any documents relevant to this question."
+
+var citationRef = regexp.MustCompile(`\[(\d+)\]`)
+
+// Service defines the interface for answering questions from the indexed documents.
+type Service interface {
+	// Answer searches for sources, asks the chat model to answer from them, and returns the
+	// answer with its citations. If onToken is not nil the reply is streamed through it.
+	Answer(ctx context.Context, question string, opts Options, onToken func(token string) error) (*Answer, error)
+}
+
+// Options holds the per-request answer settings.
+type Options struct {
+	// Search is passed to the search service to retrieve the sources.
+	Search search.Options
+	// MaxContextTokens overrides the generator's context budget when greater than zero.
+	MaxContextTokens int
+}
+
+// Answer is a generated answer along with the sources it was given.
+type Answer struct {
+	Text string
+	// Citations lists the sources in the order they were numbered in the prompt.
+	Citations []Citation
+}
+
+// Citation maps a source number used in the answer back to the indexed document.
+type Citation struct {
+	// Number is the 1-based number the source was given in the prompt, as in "[1]".
+	Number           int
+	DocumentID       string
+	ParentDocumentID string
+	// Score is the source's search score: the rerank score if it was reranked, otherwise the fused score.
+	Score float64
+	// Cited reports whether the answer references this source.
+	Cited bool
+}
+
+// Generator answers questions with retrieval-augmented generation.
+// It implements the Service interface.
+type Generator struct {
+	searchService    search.Service
+	chat             llm.ChatClient
+	maxContextTokens int
+}
+
+// NewGenerator creates a Generator. maxContextTokens is the approximate token budget for the
+// sources included in the prompt.
+func NewGenerator(searchService search.Service, chat llm.ChatClient, maxContextTokens int) *Generator {
+	return &Generator{
+		searchService:    searchService,
+		chat:             chat,
+		maxContextTokens: maxContextTokens,
+	}
+}
+
+// Answer implements the Service interface.
+func (g *Generator) Answer(ctx context.Context, question string, opts Options, onToken func(token string) error) (*Answer, error) {
+	resp, err := g.searchService.Search(ctx, question, opts.Search)
+	if err != nil {
+		return nil, fmt.Errorf("error searching for sources: %w", err)
+	}
+
+	budget := g.maxContextTokens
+	if opts.MaxContextTokens > 0 {
+		budget = opts.MaxContextTokens
+	}
+	sources, citations := packContext(resp.Results, budget)
+	if len(citations) == 0 {
+		if onToken != nil {
+			if err := onToken(NoContextAnswer); err != nil {
+				return nil, err
+			}
+		}
+		return &Answer{Text: NoContextAnswer}, nil
+	}
+
+	messages := []llm.Message{
+		{Role: llm.RoleSystem, Content: systemPrompt},
+		{Role: llm.RoleUser, Content: fmt.Sprintf("Sources:\n\n%s\nQuestion: %s", sources, question)},
+	}
+
+	var reply string
+	if onToken != nil {
+		reply, err = g.chat.Stream(ctx, messages, onToken)
+	} else {
+		reply, err = g.chat.Complete(ctx, messages)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error generating answer: %w", err)
+	}
+
+	markCited(reply, citations)
+	return &Answer{Text: reply, Citations: citations}, nil
+}
+
+// packContext numbers the results in ranked order and adds them to the prompt until the token
+// budget is spent. The first source is truncated to fit if it is too long on its own, so
+// there is always some context when the search finds anything.
+func packContext(results []ranking.Result, maxTokens int) (string, []Citation) {
+	var b strings.Builder
+	var citations []Citation
+	used := 0
+	for _, res := range results {
+		text := strings.TrimSpace(res.Document.Text)
+		if text == "" {
+			continue
+		}
+
+		number := len(citations) + 1
+		header := fmt.Sprintf("[%d] ", number)
+		tokens := llm.EstimateTokens(header + text)
+		if used+tokens > maxTokens {
+			if len(citations) > 0 {
+				break
+			}
+			text = llm.TruncateToTokens(text, maxTokens-llm.EstimateTokens(header))
+			if text == "" {
+				break
+			}
+			tokens = llm.EstimateTokens(header + text)
+		}
+
+		fmt.Fprintf(&b, "%s%s\n\n", header, text)
+		used += tokens
+
+		score := res.Score
+		if res.RerankScore != nil {
+			score = *res.RerankScore
+		}
+		citations = append(citations, Citation{
+			Number:           number,
+			DocumentID:       res.Document.DocumentID,
+			ParentDocumentID: res.Document.ParentDocumentID,
+			Score:            score,
+		})
+	}
+	return b.String(), citations
+}
+
+// markCited flags the citations whose numbers appear in the answer.
+func markCited(reply string, citations []Citation) {
+	for _, match := range citationRef.FindAllStringSubmatch(reply, -1) {
+		number, err := strconv.Atoi(match[1])
+		if err != nil || number < 1 || number > len(citations) {
+			continue
+		}
+		citations[number-1].Cited = true
+	}
+}
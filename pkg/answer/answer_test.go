@@ -0,0 +1,141 @@
+package answer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/pkg/llm"
+	llm_mocks "github.com/chr1sbest/hybrid-search/pkg/llm/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/ranking"
+	"github.com/chr1sbest/hybrid-search/pkg/search"
+	search_mocks "github.com/chr1sbest/hybrid-search/pkg/search/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testResults = []ranking.Result{
+	{Document: storage.Document{DocumentID: "p1#0", ParentDocumentID: "p1", Text: "Invoices are emailed monthly."}, Score: 0.9},
+	{Document: storage.Document{DocumentID: "p2#3", ParentDocumentID: "p2", Text: "Passwords can be reset from settings."}, Score: 0.5},
+}
+
+func TestGenerator_Answer(t *testing.T) {
+	opts := Options{Search: search.Options{TopK: 5}}
+
+	t.Run("CitesSources", func(t *testing.T) {
+		// 1. Arrange
+		mockSearch := new(search_mocks.Service)
+		mockChat := new(llm_mocks.ChatClient)
+		mockSearch.On("Search", mock.Anything, "when are invoices sent?", opts.Search).Return(&search.Response{Results: testResults}, nil)
+		mockChat.On("Complete", mock.Anything, mock.MatchedBy(func(messages []llm.Message) bool {
+			prompt := messages[1].Content
+			return strings.Contains(prompt, "[1] Invoices are emailed monthly.") &&
+				strings.Contains(prompt, "[2] Passwords can be reset from settings.") &&
+				strings.HasSuffix(prompt, "Question: when are invoices sent?")
+		})).Return("Invoices are emailed every month [1].", nil)
+
+		generator := NewGenerator(mockSearch, mockChat, 1000)
+
+		// 2. Act
+		answer, err := generator.Answer(context.Background(), "when are invoices sent?", opts, nil)
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "Invoices are emailed every month [1].", answer.Text)
+		assert.Equal(t, []Citation{
+			{Number: 1, DocumentID: "p1#0", ParentDocumentID: "p1", Score: 0.9, Cited: true},
+			{Number: 2, DocumentID: "p2#3", ParentDocumentID: "p2", Score: 0.5},
+		}, answer.Citations)
+		mockChat.AssertExpectations(t)
+	})
+
+	t.Run("StreamsTokens", func(t *testing.T) {
+		mockSearch := new(search_mocks.Service)
+		mockChat := new(llm_mocks.ChatClient)
+		mockSearch.On("Search", mock.Anything, mock.Anything, mock.Anything).Return(&search.Response{Results: testResults}, nil)
+		mockChat.On("Stream", mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				onToken := args.Get(2).(func(string) error)
+				onToken("Reset it ")
+				onToken("in settings [2].")
+			}).
+			Return("Reset it in settings [2].", nil)
+
+		var tokens []string
+		answer, err := NewGenerator(mockSearch, mockChat, 1000).Answer(context.Background(), "q", opts, func(token string) error {
+			tokens = append(tokens, token)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Reset it ", "in settings [2]."}, tokens)
+		assert.False(t, answer.Citations[0].Cited)
+		assert.True(t, answer.Citations[1].Cited)
+	})
+
+	t.Run("NoSources", func(t *testing.T) {
+		mockSearch := new(search_mocks.Service)
+		mockChat := new(llm_mocks.ChatClient)
+		mockSearch.On("Search", mock.Anything, mock.Anything, mock.Anything).Return(&search.Response{}, nil)
+
+		answer, err := NewGenerator(mockSearch, mockChat, 1000).Answer(context.Background(), "q", opts, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, NoContextAnswer, answer.Text)
+		assert.Empty(t, answer.Citations)
+		mockChat.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		mockSearch := new(search_mocks.Service)
+		mockSearch.On("Search", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("search down")).Once()
+		_, err := NewGenerator(mockSearch, new(llm_mocks.ChatClient), 1000).Answer(context.Background(), "q", opts, nil)
+		assert.Error(t, err)
+
+		mockChat := new(llm_mocks.ChatClient)
+		mockSearch.On("Search", mock.Anything, mock.Anything, mock.Anything).Return(&search.Response{Results: testResults}, nil)
+		mockChat.On("Complete", mock.Anything, mock.Anything).Return("", errors.New("model down"))
+		_, err = NewGenerator(mockSearch, mockChat, 1000).Answer(context.Background(), "q", opts, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestPackContext(t *testing.T) {
+	t.Run("StopsAtBudget", func(t *testing.T) {
+		// Each source is about 10 tokens, so only the first two fit in 25.
+		results := []ranking.Result{
+			{Document: storage.Document{DocumentID: "a", Text: strings.Repeat("a", 36)}},
+			{Document: storage.Document{DocumentID: "b", Text: strings.Repeat("b", 36)}},
+			{Document: storage.Document{DocumentID: "c", Text: strings.Repeat("c", 36)}},
+		}
+
+		prompt, citations := packContext(results, 25)
+
+		assert.Len(t, citations, 2)
+		assert.Equal(t, "b", citations[1].DocumentID)
+		assert.NotContains(t, prompt, "ccc")
+	})
+
+	t.Run("TruncatesOversizedFirstSource", func(t *testing.T) {
+		results := []ranking.Result{{Document: storage.Document{DocumentID: "long", Text: strings.Repeat("word ", 500)}}}
+
+		prompt, citations := packContext(results, 50)
+
+		assert.Len(t, citations, 1)
+		assert.LessOrEqual(t, llm.EstimateTokens(prompt), 52)
+	})
+
+	t.Run("PrefersRerankScoreAndSkipsEmptyText", func(t *testing.T) {
+		rerankScore := 0.75
+		results := []ranking.Result{
+			{Document: storage.Document{DocumentID: "empty"}},
+			{Document: storage.Document{DocumentID: "a", Text: "text"}, Score: 0.1, RerankScore: &rerankScore},
+		}
+
+		_, citations := packContext(results, 100)
+
+		assert.Equal(t, []Citation{{Number: 1, DocumentID: "a", Score: 0.75}}, citations)
+	})
+}
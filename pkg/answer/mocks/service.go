@@ -0,0 +1,60 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	answer "github.com/chr1sbest/hybrid-search/pkg/answer"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Service is an autogenerated mock type for the Service type
+type Service struct {
+	mock.Mock
+}
+
+// Answer provides a mock function with given fields: ctx, question, opts, onToken
+func (_m *Service) Answer(ctx context.Context, question string, opts answer.Options, onToken func(string) error) (*answer.Answer, error) {
+	ret := _m.Called(ctx, question, opts, onToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Answer")
+	}
+
+	var r0 *answer.Answer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, answer.Options, func(string) error) (*answer.Answer, error)); ok {
+		return rf(ctx, question, opts, onToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, answer.Options, func(string) error) *answer.Answer); ok {
+		r0 = rf(ctx, question, opts, onToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*answer.Answer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, answer.Options, func(string) error) error); ok {
+		r1 = rf(ctx, question, opts, onToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewService creates a new instance of Service. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Service {
+	mock := &Service{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
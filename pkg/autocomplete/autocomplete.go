@@ -0,0 +1,132 @@
+package autocomplete
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chr1sbest/hybrid-search/pkg/cache"
+	"github.com/chr1sbest/hybrid-search/pkg/queryparser"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// maxTrackedQueries bounds the number of distinct queries counted between flushes, and the
+// number of queries remembered as already recorded.
+const maxTrackedQueries = 10000
+
+// maxPendingFlushes is how many flushes a query is counted for without reaching the minimum
+// count before its count is dropped, so that rare queries don't fill the log and stop it from
+// counting new ones.
+const maxPendingFlushes = 10
+
+// maxQueryLength is the longest query, in characters, that is offered as a completion.
+const maxQueryLength = 100
+
+// QueryLog counts the queries users search for and periodically records the frequent ones
+// with an Autocompleter, so they are offered as completions. Counting is in memory; the store
+// keeps the running totals. It is safe for concurrent use.
+type QueryLog struct {
+	store    storage.Autocompleter
+	minCount int
+
+	mu sync.Mutex
+	// counts holds the searches for each query not yet flushed.
+	counts map[string]*pendingQuery
+	// recorded holds queries that are already completions, whose counts are flushed at once.
+	recorded *cache.LRU[string, struct{}]
+}
+
+// pendingQuery is the count of a query that has not been flushed yet.
+type pendingQuery struct {
+	count int
+	// flushes is the number of flushes the query has been kept through.
+	flushes int
+}
+
+// NewQueryLog creates a query log that records a query once it has been searched minCount
+// times.
+func NewQueryLog(store storage.Autocompleter, minCount int) *QueryLog {
+	return &QueryLog{
+		store:    store,
+		minCount: minCount,
+		counts:   make(map[string]*pendingQuery),
+		recorded: cache.NewLRU[string, struct{}](maxTrackedQueries, 0),
+	}
+}
+
+// Record counts one search for query. Only plain free-text queries of a reasonable length are
+// counted, since query syntax makes for poor completions. Queries are compared ignoring case
+// and spacing.
+func (l *QueryLog) Record(query string) {
+	if _, plain := queryparser.Parse(query).(queryparser.Term); !plain {
+		return
+	}
+	query = normalize(query)
+	if utf8.RuneCountInString(query) > maxQueryLength {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	pending, ok := l.counts[query]
+	if !ok {
+		if len(l.counts) >= maxTrackedQueries {
+			return
+		}
+		pending = &pendingQuery{}
+		l.counts[query] = pending
+	}
+	pending.count++
+}
+
+// Flush records every query that has reached the minimum count, or was recorded before, with
+// the store. Counts below the minimum are kept for the next flush, for up to maxPendingFlushes
+// flushes. Counts are dropped if the store fails, since popularity only needs to be
+// approximate.
+func (l *QueryLog) Flush(ctx context.Context) error {
+	l.mu.Lock()
+	ready := make(map[string]int)
+	for query, pending := range l.counts {
+		if _, ok := l.recorded.Get(query); ok || pending.count >= l.minCount {
+			ready[query] = pending.count
+			delete(l.counts, query)
+		} else if pending.flushes++; pending.flushes >= maxPendingFlushes {
+			delete(l.counts, query)
+		}
+	}
+	l.mu.Unlock()
+
+	if len(ready) == 0 {
+		return nil
+	}
+	if err := l.store.RecordQueries(ctx, ready); err != nil {
+		return err
+	}
+	for query := range ready {
+		l.recorded.Add(query, struct{}{})
+	}
+	return nil
+}
+
+// Run flushes the log every interval until ctx is cancelled. Errors are logged.
+func (l *QueryLog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.Flush(ctx); err != nil {
+				log.Printf("Failed to record frequent queries: %v", err)
+			}
+		}
+	}
+}
+
+func normalize(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
@@ -0,0 +1,86 @@
+package autocomplete
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	storage_mocks "github.com/chr1sbest/hybrid-search/pkg/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestQueryLog_Flush(t *testing.T) {
+	t.Run("RecordsFrequentQueries", func(t *testing.T) {
+		// 1. Arrange
+		mockStore := new(storage_mocks.Autocompleter)
+		mockStore.On("RecordQueries", mock.Anything, map[string]int{"reset password": 2}).Return(nil).Once()
+		queryLog := NewQueryLog(mockStore, 2)
+
+		queryLog.Record("Reset  password")
+		queryLog.Record("reset password")
+		queryLog.Record("billing")
+
+		// 2. Act
+		err := queryLog.Flush(context.Background())
+
+		// 3. Assert
+		assert.NoError(t, err)
+		mockStore.AssertExpectations(t)
+
+		// Infrequent queries keep counting towards the next flush, and recorded queries are
+		// flushed from their first new search.
+		mockStore.On("RecordQueries", mock.Anything, map[string]int{"billing": 2, "reset password": 1}).Return(nil).Once()
+		queryLog.Record("billing")
+		queryLog.Record("reset password")
+		assert.NoError(t, queryLog.Flush(context.Background()))
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("SkipsQuerySyntaxAndLongQueries", func(t *testing.T) {
+		mockStore := new(storage_mocks.Autocompleter)
+		queryLog := NewQueryLog(mockStore, 1)
+
+		queryLog.Record(`"reset password"`)
+		queryLog.Record("invoice tag:billing")
+		queryLog.Record(strings.Repeat("a", maxQueryLength+1))
+
+		assert.NoError(t, queryLog.Flush(context.Background()))
+		mockStore.AssertNotCalled(t, "RecordQueries", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DropsRareQueries", func(t *testing.T) {
+		// 1. Arrange: the log is full of queries searched once.
+		mockStore := new(storage_mocks.Autocompleter)
+		mockStore.On("RecordQueries", mock.Anything, map[string]int{"billing": 2}).Return(nil).Once()
+		queryLog := NewQueryLog(mockStore, 2)
+		for i := range maxTrackedQueries {
+			queryLog.Record(fmt.Sprintf("query %d", i))
+		}
+		queryLog.Record("billing")
+		assert.NotContains(t, queryLog.counts, "billing")
+
+		// 2. Act
+		for range maxPendingFlushes {
+			assert.NoError(t, queryLog.Flush(context.Background()))
+		}
+		queryLog.Record("billing")
+		queryLog.Record("billing")
+
+		// 3. Assert: the rare queries aged out, making room for new ones.
+		assert.NoError(t, queryLog.Flush(context.Background()))
+		assert.Empty(t, queryLog.counts)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("StoreError", func(t *testing.T) {
+		mockStore := new(storage_mocks.Autocompleter)
+		mockStore.On("RecordQueries", mock.Anything, mock.Anything).Return(errors.New("store down"))
+		queryLog := NewQueryLog(mockStore, 1)
+		queryLog.Record("billing")
+
+		assert.Error(t, queryLog.Flush(context.Background()))
+	})
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	t.Run("EvictsLeastRecentlyUsed", func(t *testing.T) {
+		c := NewLRU[string, int](2, 0)
+		c.Add("a", 1)
+		c.Add("b", 2)
+		c.Get("a") // "b" is now the least recently used entry.
+		c.Add("c", 3)
+
+		_, ok := c.Get("b")
+		assert.False(t, ok)
+		value, ok := c.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("AddReplacesValue", func(t *testing.T) {
+		c := NewLRU[string, int](2, 0)
+		c.Add("a", 1)
+		c.Add("a", 2)
+
+		value, _ := c.Get("a")
+		assert.Equal(t, 2, value)
+		assert.Equal(t, 1, c.Len())
+	})
+
+	t.Run("EntriesExpire", func(t *testing.T) {
+		now := time.Now()
+		c := NewLRU[string, int](2, time.Minute)
+		c.now = func() time.Time { return now }
+		c.Add("a", 1)
+
+		now = now.Add(30 * time.Second)
+		_, ok := c.Get("a")
+		assert.True(t, ok)
+
+		now = now.Add(time.Minute)
+		_, ok = c.Get("a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("ZeroCapacityDisablesCaching", func(t *testing.T) {
+		c := NewLRU[string, int](0, 0)
+		c.Add("a", 1)
+		_, ok := c.Get("a")
+		assert.False(t, ok)
+	})
+}
@@ -1,13 +1,29 @@
 package chunker
 
 import (
+	"fmt"
 	"log"
+	"maps"
+	"strconv"
+	"strings"
 
 	"github.com/chr1sbest/hybrid-search/pkg/storage"
-	"github.com/google/uuid"
 	"github.com/tmc/langchaingo/textsplitter"
 )
 
+// The chunk size and overlap, in characters, used for stored documents. Tools that re-chunk
+// stored documents must use the same values to produce the same chunks.
+const (
+	DefaultChunkSize    = 512
+	DefaultChunkOverlap = 50
+)
+
+// PageSeparator separates the pages of a paged document's text, such as an uploaded PDF.
+const PageSeparator = "\n\n"
+
+// PageKey is the chunk metadata key holding the page a chunk comes from.
+const PageKey = "page"
+
 // Chunker is a wrapper around the langchaingo text splitter.
 type Chunker struct {
 	splitter textsplitter.RecursiveCharacter
@@ -23,25 +39,122 @@ func NewChunker(chunkSize, chunkOverlap int) *Chunker {
 	}
 }
 
+// ChunkID returns the ID of the chunk at index i of a parent document.
+func ChunkID(parentDocID string, i int) string {
+	return fmt.Sprintf("%s#%d", parentDocID, i)
+}
+
+// ParseChunkID splits a chunk ID made by ChunkID into the parent document ID and the chunk's
+// index. ok is false if id isn't in that form.
+func ParseChunkID(id string) (parentDocID string, i int, ok bool) {
+	sep := strings.LastIndexByte(id, '#')
+	if sep <= 0 {
+		return "", 0, false
+	}
+	i, err := strconv.Atoi(id[sep+1:])
+	if err != nil || i < 0 || strconv.Itoa(i) != id[sep+1:] {
+		return "", 0, false
+	}
+	return id[:sep], i, true
+}
+
+// JoinPages joins the text of a document's pages with PageSeparator. It returns the text and
+// the offset at which each page starts, for the document's PageStarts.
+func JoinPages(pages []string) (text string, starts []int) {
+	var b strings.Builder
+	starts = make([]int, len(pages))
+	for i, page := range pages {
+		if i > 0 {
+			b.WriteString(PageSeparator)
+		}
+		starts[i] = b.Len()
+		b.WriteString(page)
+	}
+	return b.String(), starts
+}
+
+// SplitPages returns the pages of text that JoinPages joined, given the offsets they start at.
+// It returns nil if the offsets don't fit the text.
+func SplitPages(text string, starts []int) []string {
+	if len(starts) == 0 || starts[0] != 0 {
+		return nil
+	}
+	pages := make([]string, len(starts))
+	for i, start := range starts {
+		end := len(text)
+		if i+1 < len(starts) {
+			end = starts[i+1] - len(PageSeparator)
+		}
+		if end < start || end > len(text) {
+			return nil
+		}
+		pages[i] = text[start:end]
+	}
+	return pages
+}
+
 // Chunk splits the input text into a slice of Document chunks.
 func (c *Chunker) Chunk(text, parentDocID string) []storage.Document {
+	return c.appendChunks(nil, text, parentDocID, nil)
+}
+
+// ChunkPages splits the pages of a document into chunks. No chunk spans two pages, and each
+// records its 1-based page number under the PageKey metadata key. Blank pages are skipped;
+// chunk IDs are numbered across the whole document.
+func (c *Chunker) ChunkPages(pages []string, parentDocID string) []storage.Document {
+	var chunks []storage.Document
+	for i, page := range pages {
+		if strings.TrimSpace(page) == "" {
+			continue
+		}
+		chunks = c.appendChunks(chunks, page, parentDocID, map[string]string{PageKey: strconv.Itoa(i + 1)})
+	}
+	return chunks
+}
+
+// ChunkDocument splits doc into chunks page by page if it records where its pages start, and
+// like Chunk otherwise.
+func (c *Chunker) ChunkDocument(doc storage.Document) []storage.Document {
+	if pages := SplitPages(doc.Text, doc.PageStarts); pages != nil {
+		return c.ChunkPages(pages, doc.DocumentID)
+	}
+	return c.Chunk(doc.Text, doc.DocumentID)
+}
+
+// appendChunks splits text and appends its chunks to chunks, numbering them after the ones
+// already there and giving each the metadata.
+func (c *Chunker) appendChunks(chunks []storage.Document, text, parentDocID string, metadata map[string]string) []storage.Document {
 	// Use the library to split the text into strings.
 	chunksText, err := c.splitter.SplitText(text)
 	if err != nil {
 		// In a real application, you might want to handle this error more gracefully.
 		log.Printf("Error chunking text: %v", err)
-		return nil
+		return chunks
 	}
 
-	// Convert the string chunks into our Document model.
-	var chunks []storage.Document
+	// Convert the string chunks into our Document model. Chunk IDs are derived from the
+	// parent, so re-ingesting a document overwrites its chunks instead of duplicating them.
 	for _, chunkText := range chunksText {
 		chunks = append(chunks, storage.Document{
-			DocumentID:       uuid.New().String(),
+			DocumentID:       ChunkID(parentDocID, len(chunks)),
 			ParentDocumentID: parentDocID,
 			Text:             chunkText,
+			Metadata:         maps.Clone(metadata),
 		})
 	}
 
 	return chunks
 }
+
+// MergeMetadata returns the metadata of a chunk: the parent document's metadata together with
+// the chunk's own, such as its page, which takes precedence. parent is returned unchanged when
+// the chunk has no metadata of its own.
+func MergeMetadata(parent, chunk map[string]string) map[string]string {
+	if len(chunk) == 0 {
+		return parent
+	}
+	merged := make(map[string]string, len(parent)+len(chunk))
+	maps.Copy(merged, parent)
+	maps.Copy(merged, chunk)
+	return merged
+}
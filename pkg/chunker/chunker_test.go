@@ -3,6 +3,7 @@ package chunker
 import (
 	"testing"
 
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,9 +20,11 @@ func TestChunker(t *testing.T) {
 		assert.Equal(t, "This is the second sentence. This", chunks[1].Text)
 		assert.Equal(t, "sentence. This is the third", chunks[2].Text)
 		assert.Equal(t, "is the third sentence.", chunks[3].Text)
-		for _, chunk := range chunks {
+		for i, chunk := range chunks {
 			assert.Equal(t, parentDocID, chunk.ParentDocumentID)
+			assert.Equal(t, ChunkID(parentDocID, i), chunk.DocumentID)
 		}
+		assert.Equal(t, "parent-123#0", chunks[0].DocumentID)
 	})
 
 	t.Run("ShortTextReturnsOneChunk", func(t *testing.T) {
@@ -31,4 +34,70 @@ func TestChunker(t *testing.T) {
 		assert.Len(t, chunks, 1)
 		assert.Equal(t, text, chunks[0].Text)
 	})
+
+	t.Run("PagesRecordTheirNumber", func(t *testing.T) {
+		chunker := NewChunker(100, 10)
+		chunks := chunker.ChunkPages([]string{"First page.", " \n", "Third page."}, parentDocID)
+
+		assert.Len(t, chunks, 2)
+		assert.Equal(t, "parent-123#0", chunks[0].DocumentID)
+		assert.Equal(t, "First page.", chunks[0].Text)
+		assert.Equal(t, map[string]string{PageKey: "1"}, chunks[0].Metadata)
+		assert.Equal(t, "parent-123#1", chunks[1].DocumentID)
+		assert.Equal(t, "Third page.", chunks[1].Text)
+		assert.Equal(t, map[string]string{PageKey: "3"}, chunks[1].Metadata)
+	})
+}
+
+func TestJoinPages(t *testing.T) {
+	chunker := NewChunker(100, 10)
+
+	t.Run("RoundTrips", func(t *testing.T) {
+		pages := []string{"First page.", "", "Third page."}
+		text, starts := JoinPages(pages)
+
+		assert.Equal(t, "First page.\n\n\n\nThird page.", text)
+		assert.Equal(t, []int{0, 13, 15}, starts)
+		assert.Equal(t, pages, SplitPages(text, starts))
+	})
+
+	t.Run("SinglePageRecordsPage", func(t *testing.T) {
+		text, starts := JoinPages([]string{"Only page."})
+		chunks := chunker.ChunkDocument(storage.Document{DocumentID: "parent-123", Text: text, PageStarts: starts})
+
+		assert.Equal(t, "Only page.", text)
+		assert.Len(t, chunks, 1)
+		assert.Equal(t, "Only page.", chunks[0].Text)
+		assert.Equal(t, map[string]string{PageKey: "1"}, chunks[0].Metadata)
+	})
+
+	t.Run("InvalidStartsAreIgnored", func(t *testing.T) {
+		for _, starts := range [][]int{{1}, {0, 50}, {0, 5, 3}} {
+			assert.Nil(t, SplitPages("Some text.", starts), starts)
+		}
+		chunks := chunker.ChunkDocument(storage.Document{DocumentID: "parent-123", Text: "Some text.", PageStarts: []int{0, 50}})
+
+		assert.Len(t, chunks, 1)
+		assert.Nil(t, chunks[0].Metadata)
+	})
+}
+
+func TestMergeMetadata(t *testing.T) {
+	parent := map[string]string{"tag": "billing", PageKey: "parent"}
+
+	assert.Equal(t, parent, MergeMetadata(parent, nil))
+	assert.Equal(t, map[string]string{"tag": "billing", PageKey: "2"}, MergeMetadata(parent, map[string]string{PageKey: "2"}))
+	assert.Equal(t, "parent", parent[PageKey])
+}
+
+func TestParseChunkID(t *testing.T) {
+	parent, i, ok := ParseChunkID(ChunkID("docs/a#b", 12))
+	assert.True(t, ok)
+	assert.Equal(t, "docs/a#b", parent)
+	assert.Equal(t, 12, i)
+
+	for _, id := range []string{"3f2b9c1e-uuid", "#0", "p1#", "p1#x", "p1#-1", "p1#01"} {
+		_, _, ok := ParseChunkID(id)
+		assert.False(t, ok, id)
+	}
 }
@@ -0,0 +1,329 @@
+package consistency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/chr1sbest/hybrid-search/pkg/chunker"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// Report lists the differences between the documents in the text store and the chunks in the
+// vector store.
+type Report struct {
+	// Parents and Chunks count the documents and chunks seen.
+	Parents int
+	Chunks  int
+	// OrphanParents are documents in the text store that have no chunks at all.
+	OrphanParents []string
+	// MissingChunks maps documents that have some of their chunks to the IDs of the ones they
+	// lack.
+	MissingChunks map[string][]string
+	// OrphanChunks are chunks whose parent is not in the text store, or whose index is past
+	// the end of the parent as it is chunked now.
+	OrphanChunks []string
+	// UnrecognizedChunks are vector store IDs that aren't in the "<parent>#<index>" form, such
+	// as chunks stored before chunk IDs were derived from their parent. They are only reported.
+	UnrecognizedChunks []string
+	// LegacyParents are documents that some of the unrecognized chunks belong to, going by the
+	// parent document ID stored with them. Until those chunks are removed, the documents are
+	// not checked, so they are never reported as orphans or re-chunked into duplicates.
+	LegacyParents []string
+}
+
+// Consistent reports whether the stores agree.
+func (r *Report) Consistent() bool {
+	return len(r.OrphanParents) == 0 && len(r.MissingChunks) == 0 && len(r.OrphanChunks) == 0
+}
+
+// RepairOptions selects what Repair fixes.
+type RepairOptions struct {
+	// Rechunk re-chunks orphan parents and parents with missing chunks from their text in the
+	// text store, and writes all of their chunks.
+	Rechunk bool
+	// DeleteOrphans deletes orphan chunks and, unless Rechunk is set, orphan parents.
+	DeleteOrphans bool
+	// DryRun counts what would be repaired without changing either store.
+	DryRun bool
+	// InFlight holds documents with writes that haven't finished, such as pending outbox
+	// entries or unfinished jobs. They are neither re-chunked nor deleted, and neither are
+	// their chunks.
+	InFlight map[string]bool
+}
+
+// RepairResult counts what Repair fixed, or would fix in a dry run.
+type RepairResult struct {
+	RechunkedParents int
+	WrittenChunks    int
+	DeletedChunks    int
+	DeletedParents   int
+}
+
+// ChunkWriter writes the chunks of a document to the vector store and returns how many there
+// are. ingest.Pipeline implements it.
+type ChunkWriter interface {
+	WriteChunks(ctx context.Context, doc storage.Document) (int, error)
+}
+
+// Checker compares the text and vector stores. The text store must implement
+// storage.DocumentScanner and the vector store storage.IDLister and storage.ParentFetcher.
+type Checker struct {
+	textStore   storage.TextStore
+	scanner     storage.DocumentScanner
+	vectorStore storage.VectorStore
+	lister      storage.IDLister
+	parents     storage.ParentFetcher
+	writer      ChunkWriter
+	chunker     *chunker.Chunker
+}
+
+// NewChecker creates a Checker that re-chunks documents with writer. chunkr must split
+// documents the same way they were split when stored, or every document will look
+// inconsistent.
+func NewChecker(
+	textStore storage.TextStore,
+	vectorStore storage.VectorStore,
+	writer ChunkWriter,
+	chunkr *chunker.Chunker,
+) (*Checker, error) {
+	scanner, ok := textStore.(storage.DocumentScanner)
+	if !ok {
+		return nil, errors.New("the text store can't enumerate its documents")
+	}
+	lister, ok := vectorStore.(storage.IDLister)
+	if !ok {
+		return nil, errors.New("the vector store can't enumerate its IDs")
+	}
+	parents, ok := vectorStore.(storage.ParentFetcher)
+	if !ok {
+		return nil, errors.New("the vector store can't look up the parents of its chunks")
+	}
+	return &Checker{
+		textStore:   textStore,
+		scanner:     scanner,
+		vectorStore: vectorStore,
+		lister:      lister,
+		parents:     parents,
+		writer:      writer,
+		chunker:     chunkr,
+	}, nil
+}
+
+// Check enumerates both stores and reports where they disagree. Lists in the report are
+// sorted.
+func (c *Checker) Check(ctx context.Context) (*Report, error) {
+	report := &Report{MissingChunks: make(map[string][]string)}
+
+	// expected holds the number of chunks each document is split into.
+	expected := make(map[string]int)
+	err := c.scanner.ScanDocuments(ctx, func(docs []storage.Document) error {
+		for _, doc := range docs {
+			expected[doc.DocumentID] = len(c.chunker.ChunkDocument(doc))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error scanning the text store: %w", err)
+	}
+	report.Parents = len(expected)
+
+	// present holds the indexes of the chunks found for each parent.
+	present := make(map[string][]int)
+	err = c.lister.ListIDs(ctx, "", func(ids []string) error {
+		report.Chunks += len(ids)
+		for _, id := range ids {
+			parent, i, ok := chunker.ParseChunkID(id)
+			if !ok {
+				report.UnrecognizedChunks = append(report.UnrecognizedChunks, id)
+				continue
+			}
+			present[parent] = append(present[parent], i)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the vector store: %w", err)
+	}
+
+	// legacy holds the documents that unrecognized chunks belong to.
+	legacy := make(map[string]bool)
+	if len(report.UnrecognizedChunks) > 0 {
+		parents, err := c.parents.FetchParentIDs(ctx, report.UnrecognizedChunks)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up the parents of unrecognized chunks: %w", err)
+		}
+		for _, parent := range parents {
+			if _, ok := expected[parent]; ok && !legacy[parent] {
+				legacy[parent] = true
+				report.LegacyParents = append(report.LegacyParents, parent)
+			}
+		}
+	}
+
+	for parent, count := range expected {
+		if legacy[parent] {
+			continue
+		}
+		indexes := present[parent]
+		if len(indexes) == 0 {
+			if count > 0 {
+				report.OrphanParents = append(report.OrphanParents, parent)
+			}
+			continue
+		}
+		found := make([]bool, count)
+		for _, i := range indexes {
+			if i < count {
+				found[i] = true
+			} else {
+				report.OrphanChunks = append(report.OrphanChunks, chunker.ChunkID(parent, i))
+			}
+		}
+		for i, ok := range found {
+			if !ok {
+				report.MissingChunks[parent] = append(report.MissingChunks[parent], chunker.ChunkID(parent, i))
+			}
+		}
+	}
+	for parent, indexes := range present {
+		if _, ok := expected[parent]; !ok {
+			for _, i := range indexes {
+				report.OrphanChunks = append(report.OrphanChunks, chunker.ChunkID(parent, i))
+			}
+		}
+	}
+
+	slices.Sort(report.OrphanParents)
+	slices.Sort(report.OrphanChunks)
+	slices.Sort(report.UnrecognizedChunks)
+	slices.Sort(report.LegacyParents)
+	return report, nil
+}
+
+// Repair fixes the problems in report. A document or chunk that can't be repaired doesn't
+// stop the others; their errors are returned together.
+//
+// The stores may have changed since the report was made, since writers aren't stopped while
+// the stores are enumerated. So before anything is deleted, each candidate is checked again:
+// orphan chunks against the documents in the text store now, and orphan parents against the
+// chunks in the vector store now. Documents in opts.InFlight are left alone. A write that
+// starts after the second check can still be undone, so stop the writers for a guarantee.
+func (c *Checker) Repair(ctx context.Context, report *Report, opts RepairOptions) (RepairResult, error) {
+	var result RepairResult
+	var errs []error
+
+	rechunk := make(map[string]bool)
+	if opts.Rechunk {
+		for _, parent := range report.OrphanParents {
+			rechunk[parent] = true
+		}
+		for parent := range report.MissingChunks {
+			rechunk[parent] = true
+		}
+		for parent, ok := range opts.InFlight {
+			if ok {
+				delete(rechunk, parent)
+			}
+		}
+	}
+	checkChunks := opts.DeleteOrphans && len(report.OrphanChunks) > 0
+	checkParents := opts.DeleteOrphans && !opts.Rechunk && len(report.OrphanParents) > 0
+	if len(rechunk) == 0 && !checkChunks && !checkParents {
+		return result, nil
+	}
+
+	// expected holds the number of chunks each document is split into now.
+	expected := make(map[string]int)
+	err := c.scanner.ScanDocuments(ctx, func(docs []storage.Document) error {
+		for _, doc := range docs {
+			expected[doc.DocumentID] = len(c.chunker.ChunkDocument(doc))
+			if !rechunk[doc.DocumentID] {
+				continue
+			}
+			written, err := c.rechunk(ctx, doc, opts.DryRun)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("document %s: %w", doc.DocumentID, err))
+				continue
+			}
+			result.RechunkedParents++
+			result.WrittenChunks += written
+		}
+		return nil
+	})
+	if err != nil {
+		// Without the documents as they are now, orphans can't be told from new writes.
+		errs = append(errs, fmt.Errorf("error scanning the text store: %w", err))
+		return result, errors.Join(errs...)
+	}
+
+	if checkChunks {
+		var orphans []string
+		for _, id := range report.OrphanChunks {
+			parent, i, _ := chunker.ParseChunkID(id)
+			if count, ok := expected[parent]; (!ok || i >= count) && !opts.InFlight[parent] {
+				orphans = append(orphans, id)
+			}
+		}
+		if len(orphans) > 0 {
+			if opts.DryRun {
+				result.DeletedChunks = len(orphans)
+			} else if err := c.vectorStore.Delete(ctx, orphans); err != nil {
+				errs = append(errs, fmt.Errorf("error deleting orphan chunks: %w", err))
+			} else {
+				result.DeletedChunks = len(orphans)
+			}
+		}
+	}
+	if checkParents {
+		for _, parent := range report.OrphanParents {
+			if _, ok := expected[parent]; !ok || opts.InFlight[parent] {
+				continue
+			}
+			orphan, err := c.hasNoChunks(ctx, parent)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error listing the chunks of document %s: %w", parent, err))
+				continue
+			}
+			if !orphan {
+				continue
+			}
+			if !opts.DryRun {
+				if err := c.textStore.Delete(ctx, parent); err != nil {
+					errs = append(errs, fmt.Errorf("error deleting document %s: %w", parent, err))
+					continue
+				}
+			}
+			result.DeletedParents++
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// errHasChunks stops listing the chunks of a document once one is found.
+var errHasChunks = errors.New("the document has chunks")
+
+// hasNoChunks reports whether the vector store has no chunks of parent.
+func (c *Checker) hasNoChunks(ctx context.Context, parent string) (bool, error) {
+	found := false
+	err := c.lister.ListIDs(ctx, parent+"#", func(ids []string) error {
+		if len(ids) > 0 {
+			found = true
+			return errHasChunks
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errHasChunks) {
+		return false, err
+	}
+	return !found, nil
+}
+
+// rechunk splits doc again and writes all of its chunks, returning how many there are.
+func (c *Checker) rechunk(ctx context.Context, doc storage.Document, dryRun bool) (int, error) {
+	if dryRun {
+		return len(c.chunker.ChunkDocument(doc)), nil
+	}
+	return c.writer.WriteChunks(ctx, doc)
+}
@@ -0,0 +1,256 @@
+package consistency
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/pkg/chunker"
+	embedding_mocks "github.com/chr1sbest/hybrid-search/pkg/embeddings/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	storage_mocks "github.com/chr1sbest/hybrid-search/pkg/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// scanningTextStore is a text store that can enumerate its documents.
+type scanningTextStore struct {
+	*storage_mocks.TextStore
+	*storage_mocks.DocumentScanner
+}
+
+// listingVectorStore is a vector store that can enumerate its IDs and look up parents.
+type listingVectorStore struct {
+	*storage_mocks.VectorStore
+	*storage_mocks.IDLister
+	*storage_mocks.ParentFetcher
+}
+
+// Every document is short enough to be a single chunk.
+var testDocs = []storage.Document{
+	{DocumentID: "complete", Text: "Invoices are emailed monthly."},
+	{DocumentID: "missing", Text: "Refunds take five days."},
+	{DocumentID: "orphan", Text: "Support is open on weekdays.", Metadata: map[string]string{"tag": "support"}},
+}
+
+// newTestChecker creates a Checker over testDocs and the chunk IDs ids. parents maps the
+// unrecognized IDs to the parent document IDs stored with them.
+func newTestChecker(t *testing.T, ids []string, parents map[string]string) (*Checker, scanningTextStore, listingVectorStore, *embedding_mocks.EmbeddingClient) {
+	t.Helper()
+	text := scanningTextStore{new(storage_mocks.TextStore), new(storage_mocks.DocumentScanner)}
+	vector := listingVectorStore{new(storage_mocks.VectorStore), new(storage_mocks.IDLister), new(storage_mocks.ParentFetcher)}
+	mockEmbed := new(embedding_mocks.EmbeddingClient)
+
+	text.DocumentScanner.On("ScanDocuments", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		_ = args.Get(1).(func([]storage.Document) error)(testDocs)
+	})
+	listIDs(vector, ids)
+	vector.ParentFetcher.On("FetchParentIDs", mock.Anything, mock.Anything).Return(parents, nil)
+
+	chunkr := chunker.NewChunker(chunker.DefaultChunkSize, chunker.DefaultChunkOverlap)
+	pipeline := ingest.NewPipeline(chunkr, mockEmbed, vector, text, 10, 1)
+	checker, err := NewChecker(text, vector, pipeline, chunkr)
+	assert.NoError(t, err)
+	return checker, text, vector, mockEmbed
+}
+
+// listIDs makes vector list ids, or those of them that start with the prefix it is given.
+func listIDs(vector listingVectorStore, ids []string) {
+	vector.IDLister.ExpectedCalls = nil
+	vector.IDLister.On("ListIDs", mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		var page []string
+		for _, id := range ids {
+			if strings.HasPrefix(id, args.String(1)) {
+				page = append(page, id)
+			}
+		}
+		_ = args.Get(2).(func([]string) error)(page)
+	})
+}
+
+func TestChecker_Check(t *testing.T) {
+	t.Run("ReportsInconsistencies", func(t *testing.T) {
+		// 1. Arrange
+		// "missing" has a chunk past its end but not its first one, "deleted" is gone from
+		// the text store, and "orphan" has a chunk stored under a legacy ID.
+		checker, _, vector, _ := newTestChecker(t,
+			[]string{"complete#0", "missing#1", "deleted#0", "legacy-uuid", "stray-uuid", "gone-uuid"},
+			map[string]string{"legacy-uuid": "orphan", "gone-uuid": "deleted"})
+
+		// 2. Act
+		report, err := checker.Check(context.Background())
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 3, report.Parents)
+		assert.Equal(t, 6, report.Chunks)
+		assert.Empty(t, report.OrphanParents)
+		assert.Equal(t, map[string][]string{"missing": {"missing#0"}}, report.MissingChunks)
+		assert.Equal(t, []string{"deleted#0", "missing#1"}, report.OrphanChunks)
+		assert.Equal(t, []string{"gone-uuid", "legacy-uuid", "stray-uuid"}, report.UnrecognizedChunks)
+		assert.Equal(t, []string{"orphan"}, report.LegacyParents)
+		assert.False(t, report.Consistent())
+		vector.ParentFetcher.AssertCalled(t, "FetchParentIDs", mock.Anything, report.UnrecognizedChunks)
+	})
+
+	t.Run("Consistent", func(t *testing.T) {
+		checker, _, vector, _ := newTestChecker(t, []string{"complete#0", "missing#0", "orphan#0"}, nil)
+
+		report, err := checker.Check(context.Background())
+
+		assert.NoError(t, err)
+		assert.True(t, report.Consistent())
+		vector.ParentFetcher.AssertNotCalled(t, "FetchParentIDs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ListError", func(t *testing.T) {
+		text := scanningTextStore{new(storage_mocks.TextStore), new(storage_mocks.DocumentScanner)}
+		vector := listingVectorStore{new(storage_mocks.VectorStore), new(storage_mocks.IDLister), new(storage_mocks.ParentFetcher)}
+		text.DocumentScanner.On("ScanDocuments", mock.Anything, mock.Anything).Return(nil)
+		vector.IDLister.On("ListIDs", mock.Anything, "", mock.Anything).Return(errors.New("unavailable"))
+		checker, err := NewChecker(text, vector, nil, chunker.NewChunker(chunker.DefaultChunkSize, chunker.DefaultChunkOverlap))
+		assert.NoError(t, err)
+
+		_, err = checker.Check(context.Background())
+
+		assert.EqualError(t, err, "error listing the vector store: unavailable")
+	})
+}
+
+func TestNewChecker_Unsupported(t *testing.T) {
+	_, err := NewChecker(new(storage_mocks.TextStore), new(storage_mocks.VectorStore), nil, nil)
+
+	assert.EqualError(t, err, "the text store can't enumerate its documents")
+}
+
+func TestChecker_Repair(t *testing.T) {
+	ids := []string{"complete#0", "missing#1", "deleted#0"}
+
+	t.Run("RechunkAndDeleteOrphans", func(t *testing.T) {
+		// 1. Arrange
+		checker, text, vector, mockEmbed := newTestChecker(t, ids, nil)
+		report, err := checker.Check(context.Background())
+		assert.NoError(t, err)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		vector.VectorStore.On("UpsertMany", mock.Anything, []storage.Document{
+			{DocumentID: "missing#0", ParentDocumentID: "missing", Text: "Refunds take five days."},
+		}, [][]float32{{0.1}}).Return(nil)
+		vector.VectorStore.On("UpsertMany", mock.Anything, []storage.Document{
+			{DocumentID: "orphan#0", ParentDocumentID: "orphan", Text: "Support is open on weekdays.", Metadata: map[string]string{"tag": "support"}},
+		}, [][]float32{{0.1}}).Return(nil)
+		vector.VectorStore.On("Delete", mock.Anything, []string{"deleted#0", "missing#1"}).Return(nil)
+
+		// 2. Act
+		result, err := checker.Repair(context.Background(), report, RepairOptions{Rechunk: true, DeleteOrphans: true})
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Equal(t, RepairResult{RechunkedParents: 2, WrittenChunks: 2, DeletedChunks: 2}, result)
+		vector.VectorStore.AssertExpectations(t)
+		text.TextStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DeleteOrphanParents", func(t *testing.T) {
+		checker, text, vector, _ := newTestChecker(t, ids, nil)
+		report, err := checker.Check(context.Background())
+		assert.NoError(t, err)
+		vector.VectorStore.On("Delete", mock.Anything, mock.Anything).Return(nil)
+		text.TextStore.On("Delete", mock.Anything, "orphan").Return(errors.New("unavailable"))
+
+		result, err := checker.Repair(context.Background(), report, RepairOptions{DeleteOrphans: true})
+
+		assert.EqualError(t, err, "error deleting document orphan: unavailable")
+		assert.Equal(t, RepairResult{DeletedChunks: 2}, result)
+	})
+
+	t.Run("KeepsLegacyParents", func(t *testing.T) {
+		// 1. Arrange
+		// "orphan" has no chunk with a derived ID, but one stored under a legacy ID, so it
+		// must be neither deleted nor re-chunked.
+		checker, text, vector, mockEmbed := newTestChecker(t, append(ids, "legacy-uuid"), map[string]string{"legacy-uuid": "orphan"})
+		report, err := checker.Check(context.Background())
+		assert.NoError(t, err)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		vector.VectorStore.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		vector.VectorStore.On("Delete", mock.Anything, []string{"deleted#0", "missing#1"}).Return(nil)
+
+		// 2. Act
+		rechunked, err := checker.Repair(context.Background(), report, RepairOptions{Rechunk: true, DeleteOrphans: true})
+		assert.NoError(t, err)
+		deleted, err := checker.Repair(context.Background(), report, RepairOptions{DeleteOrphans: true})
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Equal(t, RepairResult{RechunkedParents: 1, WrittenChunks: 1, DeletedChunks: 2}, rechunked)
+		assert.Equal(t, RepairResult{DeletedChunks: 2}, deleted)
+		vector.VectorStore.AssertNumberOfCalls(t, "UpsertMany", 1)
+		vector.VectorStore.AssertCalled(t, "UpsertMany", mock.Anything, []storage.Document{
+			{DocumentID: "missing#0", ParentDocumentID: "missing", Text: "Refunds take five days."},
+		}, [][]float32{{0.1}})
+		text.TextStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		checker, text, vector, mockEmbed := newTestChecker(t, ids, nil)
+		report, err := checker.Check(context.Background())
+		assert.NoError(t, err)
+
+		result, err := checker.Repair(context.Background(), report, RepairOptions{Rechunk: true, DeleteOrphans: true, DryRun: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, RepairResult{RechunkedParents: 2, WrittenChunks: 2, DeletedChunks: 2}, result)
+		mockEmbed.AssertNotCalled(t, "CreateEmbedding", mock.Anything, mock.Anything)
+		vector.VectorStore.AssertNotCalled(t, "UpsertMany", mock.Anything, mock.Anything, mock.Anything)
+		vector.VectorStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		text.TextStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+	t.Run("RechecksBeforeDeleting", func(t *testing.T) {
+		// 1. Arrange
+		// "new" is stored after the text store is scanned but before the vector store is
+		// listed, so its chunk looks like an orphan. "orphan" gets its chunk after the listing.
+		checker, text, vector, _ := newTestChecker(t, append(ids, "new#0"), nil)
+		report, err := checker.Check(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"deleted#0", "missing#1", "new#0"}, report.OrphanChunks)
+		assert.Equal(t, []string{"orphan"}, report.OrphanParents)
+
+		docs := append(slices.Clone(testDocs), storage.Document{DocumentID: "new", Text: "Passwords expire yearly."})
+		text.DocumentScanner.ExpectedCalls = nil
+		text.DocumentScanner.On("ScanDocuments", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			_ = args.Get(1).(func([]storage.Document) error)(docs)
+		})
+		listIDs(vector, append(ids, "new#0", "orphan#0"))
+		vector.VectorStore.On("Delete", mock.Anything, []string{"deleted#0", "missing#1"}).Return(nil)
+
+		// 2. Act
+		result, err := checker.Repair(context.Background(), report, RepairOptions{DeleteOrphans: true})
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Equal(t, RepairResult{DeletedChunks: 2}, result)
+		vector.VectorStore.AssertExpectations(t)
+		text.TextStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("SkipsInFlight", func(t *testing.T) {
+		checker, text, vector, mockEmbed := newTestChecker(t, ids, nil)
+		report, err := checker.Check(context.Background())
+		assert.NoError(t, err)
+		vector.VectorStore.On("Delete", mock.Anything, []string{"missing#1"}).Return(nil)
+
+		inFlight := map[string]bool{"deleted": true, "orphan": true, "missing": false}
+		result, err := checker.Repair(context.Background(), report, RepairOptions{DeleteOrphans: true, InFlight: inFlight})
+		assert.NoError(t, err)
+		rechunked, err := checker.Repair(context.Background(), report, RepairOptions{Rechunk: true, DryRun: true, InFlight: inFlight})
+
+		assert.NoError(t, err)
+		assert.Equal(t, RepairResult{DeletedChunks: 1}, result)
+		assert.Equal(t, RepairResult{RechunkedParents: 1, WrittenChunks: 1}, rechunked)
+		vector.VectorStore.AssertExpectations(t)
+		text.TextStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		mockEmbed.AssertNotCalled(t, "CreateEmbedding", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,616 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/chunker"
+	"github.com/chr1sbest/hybrid-search/pkg/extract"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultUserAgent identifies the crawler to the sites it visits, and selects their
+// robots.txt rules, when Config.UserAgent is not set.
+const DefaultUserAgent = "hybrid-search-crawler/1.0"
+
+// URLKey is the metadata key holding a crawled document's URL, which is also its ID.
+const URLKey = "url"
+
+const (
+	// maxBodyBytes is the most read from a page; the rest is ignored.
+	maxBodyBytes = 32 << 20
+	// maxRobotsBytes is the most read from a robots.txt file, the minimum RFC 9309 requires
+	// crawlers to parse.
+	maxRobotsBytes = 500 << 10
+	// maxSitemapDepth is how many levels of sitemap indexes are followed.
+	maxSitemapDepth = 3
+	// maxRedirects is how many redirects are followed for a page or sitemap, as
+	// http.Client does by default, and maxRobotsRedirects for a robots.txt file, as RFC
+	// 9309 suggests.
+	maxRedirects       = 10
+	maxRobotsRedirects = 5
+)
+
+// errRedirectSkipped is returned, wrapped, for a redirect the crawler won't follow.
+var errRedirectSkipped = errors.New("redirect not followed")
+
+// Config configures a Crawler.
+type Config struct {
+	// Seeds are the URLs the crawl starts from.
+	Seeds []string
+	// Sitemaps are sitemap or sitemap index URLs whose pages are crawled along with the seeds.
+	Sitemaps []string
+	// MaxDepth is how many links away from a seed or sitemap page the crawler goes. Zero
+	// crawls only those pages.
+	MaxDepth int
+	// MaxPages stops the crawl once this many URLs have been visited. Zero means no limit.
+	MaxPages int
+	// Delay is the least time between two requests to the same host. A longer Crawl-delay in
+	// the host's robots.txt takes precedence.
+	Delay time.Duration
+	// Concurrency is the number of pages fetched at once, across all hosts.
+	Concurrency int
+	// UserAgent is sent with every request. It defaults to DefaultUserAgent.
+	UserAgent string
+	// AllowedHosts are the hosts whose links are followed. It defaults to the hosts of the
+	// seeds and sitemaps.
+	AllowedHosts []string
+	// BatchSize is the number of documents sent to the ingestion service at once.
+	BatchSize int
+}
+
+// Stats counts the URLs seen by a crawl.
+type Stats struct {
+	// Fetched counts the pages downloaded.
+	Fetched int
+	// Unchanged counts the pages the server reported unchanged since the last crawl. They
+	// aren't downloaded or stored again.
+	Unchanged int
+	// Stored counts the pages stored by the ingestion service.
+	Stored int
+	// Skipped counts the URLs robots.txt disallows and the pages that are marked noindex or
+	// hold no text the crawler can extract.
+	Skipped int
+	// Failed counts the URLs that couldn't be fetched or stored. They are tried again by the
+	// next crawl.
+	Failed int
+}
+
+// Crawler fetches pages breadth-first from seed URLs and sitemaps and stores their main
+// content with an ingestion service, using each page's URL as its document ID. It obeys
+// robots.txt, waits between requests to the same host, and sends the ETag and Last-Modified
+// of pages it has already stored, so unchanged pages aren't stored again.
+type Crawler struct {
+	cfg          Config
+	svc          ingest.Service
+	state        *FileState
+	client       *http.Client
+	robotsClient *http.Client
+	allowedHosts map[string]bool
+
+	mu    sync.Mutex
+	hosts map[string]*host
+}
+
+// host is what the crawler knows about one host.
+type host struct {
+	robotsOnce sync.Once
+	robots     *robots
+	// next is when the next request to the host may start. It is guarded by Crawler.mu.
+	next time.Time
+}
+
+// New creates a Crawler. Pages are stored with svc, and state records what was stored for the
+// next crawl. If client is nil, http.DefaultClient is used. The crawler uses copies of
+// client with its own redirect checks.
+func New(cfg Config, svc ingest.Service, state *FileState, client *http.Client) (*Crawler, error) {
+	if len(cfg.Seeds) == 0 && len(cfg.Sitemaps) == 0 {
+		return nil, errors.New("at least one seed URL or sitemap is required")
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+	cfg.Concurrency = max(cfg.Concurrency, 1)
+	cfg.BatchSize = max(cfg.BatchSize, 1)
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	allowedHosts := make(map[string]bool)
+	for _, name := range cfg.AllowedHosts {
+		allowedHosts[strings.ToLower(name)] = true
+	}
+	if len(allowedHosts) == 0 {
+		for _, raw := range slices.Concat(cfg.Seeds, cfg.Sitemaps) {
+			u, err := url.Parse(raw)
+			if err != nil || u.Host == "" {
+				return nil, fmt.Errorf("invalid URL %q", raw)
+			}
+			allowedHosts[strings.ToLower(u.Host)] = true
+		}
+	}
+
+	c := &Crawler{
+		cfg:          cfg,
+		svc:          svc,
+		state:        state,
+		allowedHosts: allowedHosts,
+		hosts:        make(map[string]*host),
+	}
+	// Every hop of a redirect is held to the same rules as a link. robots.txt files are
+	// fetched with their own client, since checking their redirects would need the rules
+	// being fetched.
+	pages := *client
+	pages.CheckRedirect = c.checkRedirect
+	c.client = &pages
+	robotsFiles := *client
+	robotsFiles.CheckRedirect = func(_ *http.Request, via []*http.Request) error {
+		if len(via) >= maxRobotsRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRobotsRedirects)
+		}
+		return nil
+	}
+	c.robotsClient = &robotsFiles
+	return c, nil
+}
+
+// checkRedirect is the CheckRedirect of the crawler's client. It only follows a redirect to
+// a host the crawler follows, and to a path that host's robots.txt allows.
+func (c *Crawler) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if !c.allowed(req.URL.String()) {
+		return fmt.Errorf("%w: %s is on a host the crawler doesn't follow", errRedirectSkipped, req.URL)
+	}
+	if !c.robots(req.Context(), req.URL, c.host(req.URL.Host)).allowed(req.URL.RequestURI()) {
+		return fmt.Errorf("%w: %s is disallowed by robots.txt", errRedirectSkipped, req.URL)
+	}
+	return nil
+}
+
+// kind is the outcome of visiting a URL.
+type kind int
+
+const (
+	kindFailed kind = iota
+	kindSkipped
+	kindUnchanged
+	kindFetched
+)
+
+// visit is the outcome of visiting a URL.
+type visit struct {
+	kind kind
+	// fetched is set when the page was downloaded, even if it was then skipped.
+	fetched bool
+	// doc is the document to store. It is set only when kind is kindFetched.
+	doc storage.Document
+	// page is the state to record once doc is stored, or, for unchanged pages, right away.
+	page Page
+	// links are the page's links, to crawl at the next depth.
+	links []string
+}
+
+// Crawl visits the seeds and sitemap pages, then the pages they link to, one depth at a time.
+// Pages are stored and the state saved after each depth, so an interrupted crawl keeps what
+// it has stored. Pages that fail are logged and counted, and don't stop the crawl. It returns
+// early if the state can't be saved or ctx is cancelled.
+func (c *Crawler) Crawl(ctx context.Context) (Stats, error) {
+	var stats Stats
+
+	// seen holds every URL queued so far, so each is visited once.
+	seen := make(map[string]bool)
+	var frontier []string
+	enqueue := func(link string) {
+		if !seen[link] && c.allowed(link) {
+			seen[link] = true
+			frontier = append(frontier, link)
+		}
+	}
+	for _, seed := range c.cfg.Seeds {
+		link, ok := parseURL(seed)
+		if !ok {
+			log.Printf("Skipping invalid seed URL %q", seed)
+			stats.Failed++
+			continue
+		}
+		enqueue(link)
+	}
+	for _, sitemapURL := range c.cfg.Sitemaps {
+		pages, err := c.sitemapPages(ctx, sitemapURL, 0)
+		if err != nil {
+			log.Printf("Failed to read sitemap %s: %v", sitemapURL, err)
+			stats.Failed++
+		}
+		for _, link := range pages {
+			enqueue(link)
+		}
+	}
+
+	visited := 0
+	for depth := 0; len(frontier) > 0; depth++ {
+		if c.cfg.MaxPages > 0 {
+			remaining := c.cfg.MaxPages - visited
+			if remaining <= 0 {
+				break
+			}
+			frontier = frontier[:min(len(frontier), remaining)]
+		}
+		visited += len(frontier)
+
+		visits := c.visitAll(ctx, frontier)
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		var docs []storage.Document
+		pending := make(map[string]Page)
+		current := frontier
+		frontier = nil
+		for i, v := range visits {
+			if v.fetched {
+				stats.Fetched++
+			}
+			switch v.kind {
+			case kindFailed:
+				stats.Failed++
+			case kindSkipped:
+				stats.Skipped++
+			case kindUnchanged:
+				stats.Unchanged++
+				c.state.Put(current[i], v.page)
+			case kindFetched:
+				docs = append(docs, v.doc)
+				pending[v.doc.DocumentID] = v.page
+			}
+			if depth < c.cfg.MaxDepth {
+				for _, link := range v.links {
+					enqueue(link)
+				}
+			}
+		}
+
+		c.store(ctx, docs, pending, &stats)
+		if err := c.state.Save(); err != nil {
+			return stats, err
+		}
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// visitAll visits links concurrently and returns their outcomes in the same order.
+func (c *Crawler) visitAll(ctx context.Context, links []string) []visit {
+	visits := make([]visit, len(links))
+	var g errgroup.Group
+	g.SetLimit(c.cfg.Concurrency)
+	for i, link := range links {
+		g.Go(func() error {
+			visits[i] = c.visit(ctx, link)
+			return nil
+		})
+	}
+	g.Wait()
+	return visits
+}
+
+// visit fetches link and extracts its document and links.
+func (c *Crawler) visit(ctx context.Context, link string) visit {
+	u, err := url.Parse(link)
+	if err != nil {
+		return visit{kind: kindFailed}
+	}
+	h := c.host(u.Host)
+	rules := c.robots(ctx, u, h)
+	if !rules.allowed(u.RequestURI()) {
+		log.Printf("Skipping %s: disallowed by robots.txt", link)
+		return visit{kind: kindSkipped}
+	}
+	if err := c.wait(ctx, h, rules.delay); err != nil {
+		return visit{kind: kindFailed}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		log.Printf("Failed to fetch %s: %v", link, err)
+		return visit{kind: kindFailed}
+	}
+	req.Header.Set("User-Agent", c.cfg.UserAgent)
+	previous, known := c.state.Get(link)
+	if known {
+		if previous.ETag != "" {
+			req.Header.Set("If-None-Match", previous.ETag)
+		}
+		if previous.LastModified != "" {
+			req.Header.Set("If-Modified-Since", previous.LastModified)
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if errors.Is(err, errRedirectSkipped) {
+		log.Printf("Skipping %s: %v", link, err)
+		return visit{kind: kindSkipped}
+	}
+	if err != nil {
+		log.Printf("Failed to fetch %s: %v", link, err)
+		return visit{kind: kindFailed}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && known {
+		previous.CrawledAt = time.Now()
+		return visit{kind: kindUnchanged, page: previous, links: previous.Links}
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Failed to fetch %s: unexpected status %s", link, resp.Status)
+		return visit{kind: kindFailed}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		log.Printf("Failed to fetch %s: %v", link, err)
+		return visit{kind: kindFailed}
+	}
+
+	v := visit{
+		fetched: true,
+		page: Page{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			CrawledAt:    time.Now(),
+		},
+	}
+	noindex := hasDirective(resp.Header.Get("X-Robots-Tag"), "noindex")
+	nofollow := hasDirective(resp.Header.Get("X-Robots-Tag"), "nofollow")
+
+	mimeType := extract.Detect(path.Base(resp.Request.URL.Path), resp.Header.Get("Content-Type"), body)
+	var doc *extract.Document
+	if mimeType == extract.MIMEHTML {
+		// Links resolve against the URL the page was served from, after any redirects.
+		page, err := parseLinks(body, resp.Request.URL)
+		if err != nil {
+			log.Printf("Failed to parse %s: %v", link, err)
+			return visit{kind: kindFailed, fetched: true}
+		}
+		noindex = noindex || page.noindex
+		nofollow = nofollow || page.nofollow
+		if !nofollow {
+			v.links = page.links
+		}
+		doc, err = extract.ExtractHTML(body)
+		if err != nil {
+			log.Printf("Failed to extract text from %s: %v", link, err)
+			v.kind = kindFailed
+			return v
+		}
+	} else {
+		doc, err = extract.Extract(mimeType, body)
+		if errors.Is(err, extract.ErrUnsupported) {
+			log.Printf("Skipping %s: unsupported content type %q", link, mimeType)
+			v.kind = kindSkipped
+			return v
+		}
+		if err != nil {
+			log.Printf("Failed to extract text from %s: %v", link, err)
+			v.kind = kindFailed
+			return v
+		}
+	}
+	v.page.Links = v.links
+
+	text := doc.Text
+	var pageStarts []int
+	if doc.Pages != nil {
+		text, pageStarts = chunker.JoinPages(doc.Pages)
+	}
+	if noindex || strings.TrimSpace(text) == "" {
+		v.kind = kindSkipped
+		return v
+	}
+	title := doc.Title
+	if title == "" {
+		title = link
+	}
+	v.kind = kindFetched
+	v.doc = storage.Document{
+		DocumentID: link,
+		Title:      title,
+		Text:       text,
+		Metadata:   map[string]string{URLKey: link},
+		PageStarts: pageStarts,
+	}
+	return v
+}
+
+// store ingests docs in batches and records the state of each one stored.
+func (c *Crawler) store(ctx context.Context, docs []storage.Document, pages map[string]Page, stats *Stats) {
+	for start := 0; start < len(docs); start += c.cfg.BatchSize {
+		batch := docs[start:min(start+c.cfg.BatchSize, len(docs))]
+		for _, result := range c.svc.Ingest(ctx, batch) {
+			switch result.Status {
+			case ingest.StatusCreated, ingest.StatusUpdated:
+				stats.Stored++
+				c.state.Put(result.DocumentID, pages[result.DocumentID])
+			default:
+				stats.Failed++
+				log.Printf("Failed to store %s: %s", result.DocumentID, result.Error)
+			}
+		}
+	}
+}
+
+// allowed reports whether link is on one of the hosts the crawler follows.
+func (c *Crawler) allowed(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && c.allowedHosts[strings.ToLower(u.Host)]
+}
+
+// host returns the crawler's record of the named host, creating it on first use.
+func (c *Crawler) host(name string) *host {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.hosts[name]
+	if !ok {
+		h = &host{}
+		c.hosts[name] = h
+	}
+	return h
+}
+
+// robots returns the robots.txt rules of u's host, fetching them on first use.
+func (c *Crawler) robots(ctx context.Context, u *url.URL, h *host) *robots {
+	h.robotsOnce.Do(func() {
+		robotsURL := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+		h.robots = c.fetchRobots(ctx, robotsURL.String())
+	})
+	return h.robots
+}
+
+// fetchRobots fetches and parses a robots.txt file. As RFC 9309 asks, a missing file allows
+// everything, and a file that can't be fetched because of a server or network error
+// disallows everything.
+func (c *Crawler) fetchRobots(ctx context.Context, robotsURL string) *robots {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return disallowAll
+	}
+	req.Header.Set("User-Agent", c.cfg.UserAgent)
+	resp, err := c.robotsClient.Do(req)
+	if err != nil {
+		log.Printf("Failed to fetch %s, so the host won't be crawled: %v", robotsURL, err)
+		return disallowAll
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		log.Printf("Failed to fetch %s, so the host won't be crawled: unexpected status %s", robotsURL, resp.Status)
+		return disallowAll
+	case resp.StatusCode != http.StatusOK:
+		return allowAll
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsBytes))
+	if err != nil {
+		log.Printf("Failed to read %s, so the host won't be crawled: %v", robotsURL, err)
+		return disallowAll
+	}
+	return parseRobots(string(body), c.cfg.UserAgent)
+}
+
+// wait blocks until a request to h may start, and reserves the slot that follows it. The
+// gap between requests is Config.Delay, or crawlDelay if that is longer.
+func (c *Crawler) wait(ctx context.Context, h *host, crawlDelay time.Duration) error {
+	c.mu.Lock()
+	now := time.Now()
+	start := now
+	if h.next.After(now) {
+		start = h.next
+	}
+	h.next = start.Add(max(c.cfg.Delay, crawlDelay))
+	c.mu.Unlock()
+
+	if start == now {
+		return nil
+	}
+	timer := time.NewTimer(start.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sitemapPages returns the pages listed in a sitemap, following sitemap indexes up to
+// maxSitemapDepth levels deep. Pages on hosts the crawler doesn't follow are left out by
+// Crawl.
+func (c *Crawler) sitemapPages(ctx context.Context, sitemapURL string, depth int) ([]string, error) {
+	u, err := url.Parse(sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	h := c.host(u.Host)
+	if err := c.wait(ctx, h, c.robots(ctx, u, h).delay); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.cfg.UserAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	// Sitemaps are often served gzipped as .xml.gz files.
+	if bytes.HasPrefix(data, []byte("\x1f\x8b")) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = io.ReadAll(io.LimitReader(zr, maxBodyBytes)); err != nil {
+			return nil, err
+		}
+	}
+
+	locs, sitemaps, err := parseSitemap(data)
+	if err != nil {
+		return nil, err
+	}
+	var pages []string
+	for _, loc := range locs {
+		if link, ok := resolve(resp.Request.URL, loc); ok {
+			pages = append(pages, link)
+		}
+	}
+	if depth < maxSitemapDepth {
+		for _, child := range sitemaps {
+			link, ok := resolve(resp.Request.URL, child)
+			if !ok {
+				continue
+			}
+			childPages, err := c.sitemapPages(ctx, link, depth+1)
+			if err != nil {
+				log.Printf("Failed to read sitemap %s: %v", link, err)
+				continue
+			}
+			pages = append(pages, childPages...)
+		}
+	}
+	return pages, nil
+}
+
+// hasDirective reports whether an X-Robots-Tag header holds directive.
+func hasDirective(header, directive string) bool {
+	for field := range strings.SplitSeq(strings.ToLower(header), ",") {
+		field = strings.TrimSpace(field)
+		if field == directive || field == "none" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,336 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	ingest_mocks "github.com/chr1sbest/hybrid-search/pkg/ingest/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseRobots(t *testing.T) {
+	body := `# Example robots.txt
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Crawl-delay: 2
+
+User-agent: hybrid-search-crawler
+User-agent: other
+Disallow: /drafts/
+Disallow: /*.pdf$
+Allow: /drafts/published
+`
+
+	t.Run("NamedGroup", func(t *testing.T) {
+		rules := parseRobots(body, DefaultUserAgent)
+
+		assert.False(t, rules.allowed("/drafts/plan"))
+		assert.True(t, rules.allowed("/drafts/published/plan"))
+		assert.False(t, rules.allowed("/files/report.pdf"))
+		assert.True(t, rules.allowed("/files/report.pdf?download=1"))
+		assert.True(t, rules.allowed("/private/plan"))
+		assert.Zero(t, rules.delay)
+	})
+
+	t.Run("WildcardGroup", func(t *testing.T) {
+		rules := parseRobots(body, "somebot/2.0")
+
+		assert.False(t, rules.allowed("/private/plan"))
+		assert.True(t, rules.allowed("/private/public/plan"))
+		assert.True(t, rules.allowed("/robots.txt"))
+		assert.Equal(t, 2*time.Second, rules.delay)
+	})
+
+	t.Run("NamedGroupWithoutRules", func(t *testing.T) {
+		rules := parseRobots("User-agent: hybrid-search-crawler\nDisallow:\n\nUser-agent: *\nDisallow: /\n", DefaultUserAgent)
+
+		assert.True(t, rules.allowed("/plan"))
+	})
+}
+
+// site is an httptest server with a few linked pages. Every page has an ETag and answers a
+// matching If-None-Match with 304 Not Modified.
+type site struct {
+	*httptest.Server
+
+	mu sync.Mutex
+	// requests holds the path of every request after robots.txt, in order.
+	requests []string
+	times    []time.Time
+	// versions holds each page's ETag.
+	versions map[string]string
+}
+
+func newSite(t *testing.T) *site {
+	t.Helper()
+	s := &site{versions: map[string]string{
+		"/":             `"home-v1"`,
+		"/a":            `"a-v1"`,
+		"/deep":         `"deep-v1"`,
+		"/noindex":      `"noindex-v1"`,
+		"/from-sitemap": `"sitemap-page-v1"`,
+	}}
+	pages := map[string]struct{ contentType, body string }{
+		"/": {"text/html; charset=utf-8", `<html><head><title>Home</title></head><body>
+<nav>Menu</nav>
+<main><h1>Welcome</h1><p>Home page text.</p>
+<a href="/a#top">A</a> <a href="/private/secret">Secret</a> <a href="/noindex">Hidden</a>
+<a href="https://example.com/elsewhere">External</a> <a href="/ads" rel="nofollow">Ads</a></main>
+</body></html>`},
+		"/a":            {"text/html", `<html><body><p>Page A text.</p><a href="deep">Deep</a> <a href="/">Home</a></body></html>`},
+		"/deep":         {"text/html", `<html><body><p>Deep page text.</p></body></html>`},
+		"/noindex":      {"text/html", `<html><head><meta name="robots" content="noindex"></head><body><p>Hidden.</p><a href="/a">A</a></body></html>`},
+		"/from-sitemap": {"text/plain", "Sitemap page text."},
+	}
+
+	redirects := map[string]string{
+		"/to-a":    "/a",
+		"/moved":   "/private/secret",
+		"/outside": "https://example.com/elsewhere",
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+			return
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>%s/from-sitemap</loc></url>
+  <url><loc>https://example.com/outside</loc></url>
+</urlset>`, s.URL)
+			return
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, r.URL.Path)
+		s.times = append(s.times, time.Now())
+		etag := s.versions[r.URL.Path]
+		s.mu.Unlock()
+
+		if target, ok := redirects[r.URL.Path]; ok {
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", page.contentType)
+		fmt.Fprint(w, page.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// setVersion changes the ETag of the page at path.
+func (s *site) setVersion(path, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[path] = etag
+}
+
+// log returns the paths requested so far and the times they were requested.
+func (s *site) log() ([]string, []time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests), slices.Clone(s.times)
+}
+
+// recordingService returns a mock ingestion service that stores every document and appends
+// the batches it receives to batches.
+func recordingService(batches *[][]storage.Document) *ingest_mocks.Service {
+	svc := new(ingest_mocks.Service)
+	svc.On("Ingest", mock.Anything, mock.Anything).Return(func(_ context.Context, docs []storage.Document) []ingest.Result {
+		*batches = append(*batches, docs)
+		results := make([]ingest.Result, len(docs))
+		for i, doc := range docs {
+			results[i] = ingest.Result{DocumentID: doc.DocumentID, Status: ingest.StatusCreated}
+		}
+		return results
+	})
+	return svc
+}
+
+// ids returns the IDs of the documents in batches.
+func ids(batches [][]storage.Document) []string {
+	var ids []string
+	for _, batch := range batches {
+		for _, doc := range batch {
+			ids = append(ids, doc.DocumentID)
+		}
+	}
+	return ids
+}
+
+func TestCrawl(t *testing.T) {
+	t.Run("FollowsLinksWithinLimits", func(t *testing.T) {
+		// 1. Arrange
+		s := newSite(t)
+		state, err := NewFileState(filepath.Join(t.TempDir(), "state.json"))
+		assert.NoError(t, err)
+		var batches [][]storage.Document
+		c, err := New(Config{Seeds: []string{s.URL}, MaxDepth: 1, Concurrency: 2, BatchSize: 10}, recordingService(&batches), state, s.Client())
+		assert.NoError(t, err)
+
+		// 2. Act
+		stats, err := c.Crawl(context.Background())
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Equal(t, Stats{Fetched: 3, Stored: 2, Skipped: 2}, stats)
+		assert.Equal(t, []string{s.URL + "/", s.URL + "/a"}, ids(batches))
+		home := batches[0][0]
+		assert.Equal(t, "Home", home.Title)
+		assert.Equal(t, map[string]string{URLKey: s.URL + "/"}, home.Metadata)
+		assert.Contains(t, home.Text, "Home page text.")
+		assert.NotContains(t, home.Text, "Menu")
+
+		requests, _ := s.log()
+		assert.ElementsMatch(t, []string{"/", "/a", "/noindex"}, requests)
+		page, ok := state.Get(s.URL + "/a")
+		assert.True(t, ok)
+		assert.Equal(t, `"a-v1"`, page.ETag)
+		assert.Equal(t, []string{s.URL + "/deep", s.URL + "/"}, page.Links)
+	})
+
+	t.Run("Sitemap", func(t *testing.T) {
+		s := newSite(t)
+		state, err := NewFileState(filepath.Join(t.TempDir(), "state.json"))
+		assert.NoError(t, err)
+		var batches [][]storage.Document
+		c, err := New(Config{Sitemaps: []string{s.URL + "/sitemap.xml"}}, recordingService(&batches), state, s.Client())
+		assert.NoError(t, err)
+
+		stats, err := c.Crawl(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, Stats{Fetched: 1, Stored: 1}, stats)
+		assert.Equal(t, []string{s.URL + "/from-sitemap"}, ids(batches))
+		assert.Equal(t, "Sitemap page text.", batches[0][0].Text)
+	})
+
+	t.Run("RecrawlsChangedPages", func(t *testing.T) {
+		// 1. Arrange
+		s := newSite(t)
+		statePath := filepath.Join(t.TempDir(), "state.json")
+		crawl := func() (Stats, [][]storage.Document) {
+			state, err := NewFileState(statePath)
+			assert.NoError(t, err)
+			var batches [][]storage.Document
+			c, err := New(Config{Seeds: []string{s.URL}, MaxDepth: 2}, recordingService(&batches), state, s.Client())
+			assert.NoError(t, err)
+			stats, err := c.Crawl(context.Background())
+			assert.NoError(t, err)
+			return stats, batches
+		}
+		first, _ := crawl()
+		s.setVersion("/a", `"a-v2"`)
+
+		// 2. Act
+		second, batches := crawl()
+
+		// 3. Assert
+		assert.Equal(t, Stats{Fetched: 4, Stored: 3, Skipped: 2}, first)
+		assert.Equal(t, Stats{Fetched: 2, Unchanged: 2, Stored: 1, Skipped: 2}, second)
+		assert.Equal(t, []string{s.URL + "/a"}, ids(batches))
+	})
+
+	t.Run("WaitsBetweenRequests", func(t *testing.T) {
+		s := newSite(t)
+		state, err := NewFileState(filepath.Join(t.TempDir(), "state.json"))
+		assert.NoError(t, err)
+		var batches [][]storage.Document
+		delay := 50 * time.Millisecond
+		c, err := New(Config{Seeds: []string{s.URL}, MaxDepth: 1, Delay: delay, Concurrency: 4}, recordingService(&batches), state, s.Client())
+		assert.NoError(t, err)
+
+		_, err = c.Crawl(context.Background())
+
+		assert.NoError(t, err)
+		_, times := s.log()
+		assert.Len(t, times, 3)
+		for i := 1; i < len(times); i++ {
+			// Allow for requests arriving slightly out of step with when they were sent.
+			assert.GreaterOrEqual(t, times[i].Sub(times[i-1]), delay-10*time.Millisecond)
+		}
+	})
+
+	t.Run("ChecksRedirects", func(t *testing.T) {
+		// 1. Arrange
+		// "/moved" redirects to a path robots.txt disallows, and "/outside" to another host.
+		s := newSite(t)
+		state, err := NewFileState(filepath.Join(t.TempDir(), "state.json"))
+		assert.NoError(t, err)
+		var batches [][]storage.Document
+		seeds := []string{s.URL + "/to-a", s.URL + "/moved", s.URL + "/outside"}
+		c, err := New(Config{Seeds: seeds, AllowedHosts: []string{s.Listener.Addr().String()}}, recordingService(&batches), state, s.Client())
+		assert.NoError(t, err)
+
+		// 2. Act
+		stats, err := c.Crawl(context.Background())
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Equal(t, Stats{Fetched: 1, Stored: 1, Skipped: 2}, stats)
+		assert.Equal(t, []string{s.URL + "/to-a"}, ids(batches))
+		assert.Contains(t, batches[0][0].Text, "Page A text.")
+		requests, _ := s.log()
+		assert.ElementsMatch(t, []string{"/to-a", "/a", "/moved", "/outside"}, requests)
+	})
+
+	t.Run("AllowedHostsIgnoreCase", func(t *testing.T) {
+		c, err := New(Config{Seeds: []string{"https://Docs.Example.com/"}}, new(ingest_mocks.Service), nil, nil)
+		assert.NoError(t, err)
+
+		assert.True(t, c.allowed("https://DOCS.example.COM/guide"))
+		assert.False(t, c.allowed("https://example.com/guide"))
+	})
+
+	t.Run("RequiresSeeds", func(t *testing.T) {
+		_, err := New(Config{}, new(ingest_mocks.Service), nil, nil)
+
+		assert.ErrorContains(t, err, "seed")
+	})
+}
+
+func TestFileState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawl", "state.json")
+	state, err := NewFileState(path)
+	assert.NoError(t, err)
+	page := Page{ETag: `"v1"`, Links: []string{"https://example.com/a"}, CrawledAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	state.Put("https://example.com/", page)
+
+	assert.NoError(t, state.Save())
+	reloaded, err := NewFileState(path)
+
+	assert.NoError(t, err)
+	got, ok := reloaded.Get("https://example.com/")
+	assert.True(t, ok)
+	assert.Equal(t, page, got)
+	_, ok = reloaded.Get("https://example.com/a")
+	assert.False(t, ok)
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "the temporary file should be renamed into place")
+}
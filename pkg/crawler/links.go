@@ -0,0 +1,129 @@
+package crawler
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// pageLinks holds what the crawler needs from an HTML page besides its content.
+type pageLinks struct {
+	links []string
+	// noindex and nofollow are set by a <meta name="robots"> tag.
+	noindex  bool
+	nofollow bool
+}
+
+// parseLinks returns the http and https links of an HTML page, resolved against base, without
+// fragments and in the order they appear. Links marked rel="nofollow" are left out.
+func parseLinks(data []byte, base *url.URL) (pageLinks, error) {
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return pageLinks{}, err
+	}
+
+	var page pageLinks
+	seen := make(map[string]bool)
+	for n := range root.Descendants() {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		switch n.DataAtom {
+		case atom.Base:
+			// A <base> element changes what relative links resolve against.
+			if href, ok := attr(n, "href"); ok {
+				if u, err := base.Parse(href); err == nil {
+					base = u
+				}
+			}
+		case atom.Meta:
+			if name, _ := attr(n, "name"); strings.EqualFold(name, "robots") {
+				content, _ := attr(n, "content")
+				for directive := range strings.SplitSeq(strings.ToLower(content), ",") {
+					switch strings.TrimSpace(directive) {
+					case "noindex":
+						page.noindex = true
+					case "nofollow":
+						page.nofollow = true
+					case "none":
+						page.noindex, page.nofollow = true, true
+					}
+				}
+			}
+		case atom.A:
+			href, ok := attr(n, "href")
+			if !ok {
+				continue
+			}
+			if rel, _ := attr(n, "rel"); hasToken(rel, "nofollow") {
+				continue
+			}
+			link, ok := resolve(base, href)
+			if ok && !seen[link] {
+				seen[link] = true
+				page.links = append(page.links, link)
+			}
+		}
+	}
+	return page, nil
+}
+
+// resolve returns href resolved against base, in the form used as a document ID. It reports
+// false for links that aren't http or https.
+func resolve(base *url.URL, href string) (string, bool) {
+	u, err := base.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", false
+	}
+	return normalize(u)
+}
+
+// parseURL returns raw in the form used as a document ID. It reports false for URLs that
+// aren't absolute http or https URLs.
+func parseURL(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", false
+	}
+	return normalize(u)
+}
+
+// normalize returns u without its fragment, with a lowercase scheme and host and a path of at
+// least "/". It reports false for URLs that aren't http or https.
+func normalize(u *url.URL) (string, bool) {
+	normalized := *u
+	normalized.Scheme = strings.ToLower(u.Scheme)
+	if (normalized.Scheme != "http" && normalized.Scheme != "https") || u.Host == "" {
+		return "", false
+	}
+	normalized.Host = strings.ToLower(u.Host)
+	normalized.Fragment = ""
+	normalized.RawFragment = ""
+	if normalized.Path == "" {
+		normalized.Path = "/"
+	}
+	return normalized.String(), true
+}
+
+// attr returns the value of n's attribute key.
+func attr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// hasToken reports whether the space-separated list holds token, ignoring case.
+func hasToken(list, token string) bool {
+	for field := range strings.FieldsSeq(list) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package crawler
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robots holds the rules of a robots.txt file that apply to the crawler, as specified by
+// RFC 9309.
+type robots struct {
+	rules []robotsRule
+	// delay is the Crawl-delay requested for the crawler, which isn't part of the standard but
+	// is widely used. It is zero when none is set.
+	delay time.Duration
+}
+
+// robotsRule is an Allow or Disallow line.
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// allowAll is the robots policy of a site without a robots.txt.
+var allowAll = &robots{}
+
+// disallowAll is the robots policy of a site whose robots.txt can't be fetched because of a
+// server error. RFC 9309 asks crawlers to assume everything is disallowed.
+var disallowAll = &robots{rules: []robotsRule{{pattern: "/", re: regexp.MustCompile(`^/`)}}}
+
+// parseRobots returns the rules of a robots.txt file for the crawler identified by userAgent.
+// Groups naming the crawler's product token apply if there are any; otherwise the "*" group
+// applies.
+func parseRobots(body, userAgent string) *robots {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	var specific, general robots
+	// named is true if a group names the crawler, even one without rules.
+	named := false
+	var agents []string
+	// inRules is true once a group's first rule has been read, so the next User-agent line
+	// starts a new group.
+	inRules := false
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			if inRules {
+				agents = nil
+				inRules = false
+			}
+			agents = append(agents, strings.ToLower(value))
+			named = named || strings.ToLower(value) == token
+			continue
+		}
+		if key != "allow" && key != "disallow" && key != "crawl-delay" {
+			continue
+		}
+		inRules = true
+
+		for _, agent := range agents {
+			var group *robots
+			switch agent {
+			case token:
+				group = &specific
+			case "*":
+				group = &general
+			default:
+				continue
+			}
+			switch key {
+			case "crawl-delay":
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					group.delay = time.Duration(seconds * float64(time.Second))
+				}
+			default:
+				// An empty Disallow allows everything, so it adds no rule.
+				if value != "" {
+					group.rules = append(group.rules, robotsRule{allow: key == "allow", pattern: value, re: robotsPattern(value)})
+				}
+			}
+		}
+	}
+
+	if named {
+		return &specific
+	}
+	return &general
+}
+
+// robotsPattern compiles a rule's path pattern, in which "*" matches any characters and a
+// trailing "$" anchors the end of the path.
+func robotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// allowed reports whether the crawler may fetch path, which includes any query string. The
+// longest matching rule decides, and Allow wins a tie.
+func (r *robots) allowed(path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+	allow, longest := true, -1
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || (n == longest && rule.allow) {
+			allow, longest = rule.allow, n
+		}
+	}
+	return allow
+}
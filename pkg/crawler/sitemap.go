@@ -0,0 +1,41 @@
+package crawler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// sitemap is a sitemap or sitemap index, as defined at sitemaps.org.
+type sitemap struct {
+	XMLName xml.Name
+	URLs    []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// parseSitemap returns the page URLs listed in a sitemap, and the sitemaps listed in a sitemap
+// index.
+func parseSitemap(data []byte) (pages, sitemaps []string, err error) {
+	var s sitemap
+	if err := xml.Unmarshal(data, &s); err != nil {
+		return nil, nil, fmt.Errorf("error parsing sitemap: %w", err)
+	}
+	if s.XMLName.Local != "urlset" && s.XMLName.Local != "sitemapindex" {
+		return nil, nil, fmt.Errorf("error parsing sitemap: unexpected root element <%s>", s.XMLName.Local)
+	}
+	for _, u := range s.URLs {
+		if loc := strings.TrimSpace(u.Loc); loc != "" {
+			pages = append(pages, loc)
+		}
+	}
+	for _, u := range s.Sitemaps {
+		if loc := strings.TrimSpace(u.Loc); loc != "" {
+			sitemaps = append(sitemaps, loc)
+		}
+	}
+	return pages, sitemaps, nil
+}
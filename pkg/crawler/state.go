@@ -0,0 +1,92 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Page is what the crawler remembers about a stored page, so that a later crawl can ask the
+// server whether it changed.
+type Page struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// Links are the page's links, which are followed again when the page hasn't changed.
+	Links     []string  `json:"links,omitempty"`
+	CrawledAt time.Time `json:"crawled_at"`
+}
+
+// FileState keeps the crawler's pages in a JSON file. It is safe for concurrent use.
+type FileState struct {
+	path string
+
+	mu    sync.Mutex
+	pages map[string]Page
+}
+
+// NewFileState loads the pages saved at path. A missing file is an empty state.
+func NewFileState(path string) (*FileState, error) {
+	s := &FileState{path: path, pages: make(map[string]Page)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading crawl state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.pages); err != nil {
+		return nil, fmt.Errorf("error parsing crawl state: %w", err)
+	}
+	return s, nil
+}
+
+// Get returns the saved page for url.
+func (s *FileState) Get(url string) (Page, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	page, ok := s.pages[url]
+	return page, ok
+}
+
+// Put records page for url. It is written to the file by the next Save.
+func (s *FileState) Put(url string, page Page) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pages[url] = page
+}
+
+// Save writes the pages to the file. The file is written to a temporary name and renamed
+// into place, so a crash never leaves a partially written state.
+func (s *FileState) Save() error {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.pages, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("error marshalling crawl state: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error saving crawl state: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error saving crawl state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error saving crawl state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error saving crawl state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("error saving crawl state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxDOCXPart limits the size of a decompressed part of a Word document, to guard against
+// zip bombs.
+const maxDOCXPart = 64 << 20
+
+// extractDOCX returns the text of a Word document, one paragraph per line. The title is the
+// one set in the document's properties.
+func extractDOCX(data []byte) (*Document, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading DOCX: %w", err)
+	}
+
+	body, err := readZipFile(archive, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	text, err := docxText(body)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{Text: text}
+	if core, err := readZipFile(archive, "docProps/core.xml"); err == nil {
+		var props struct {
+			Title string `xml:"title"`
+		}
+		if xml.Unmarshal(core, &props) == nil {
+			doc.Title = strings.TrimSpace(props.Title)
+		}
+	}
+	return doc, nil
+}
+
+// readZipFile returns the contents of the named file in archive.
+func readZipFile(archive *zip.Reader, name string) ([]byte, error) {
+	f, err := archive.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading DOCX: %w", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxDOCXPart+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading DOCX: %w", err)
+	}
+	if len(data) > maxDOCXPart {
+		return nil, fmt.Errorf("error reading DOCX: %s is too large", name)
+	}
+	return data, nil
+}
+
+// docxText returns the text of the runs in a document.xml part. Paragraphs end in a new
+// line, and tabs and breaks are kept.
+func docxText(body []byte) (string, error) {
+	const ns = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+	var b strings.Builder
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	inText := false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("error parsing DOCX: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != ns {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteString("\t")
+			case "br", "cr":
+				b.WriteString("\n")
+			}
+		case xml.EndElement:
+			if t.Name.Space != ns {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return collapseBlankLines(b.String()), nil
+}
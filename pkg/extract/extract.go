@@ -0,0 +1,129 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// The content types that can be extracted.
+const (
+	MIMEPDF      = "application/pdf"
+	MIMEDOCX     = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MIMEHTML     = "text/html"
+	MIMEMarkdown = "text/markdown"
+	MIMEText     = "text/plain"
+)
+
+// ErrUnsupported is returned by Extract for content types it can't extract text from.
+var ErrUnsupported = errors.New("unsupported content type")
+
+// Document is the text extracted from a file.
+type Document struct {
+	// Title is the title recorded in the file, such as an HTML <title> or the first heading
+	// of a Markdown file. It is empty if the file has none.
+	Title string
+	// Text is the file's text. For paged formats it is the text of every page, separated by
+	// blank lines.
+	Text string
+	// Pages holds the text of each page, for formats that have pages, such as PDF. It is nil
+	// otherwise.
+	Pages []string
+}
+
+// extensions maps file extensions to the content types they hold.
+var extensions = map[string]string{
+	".pdf":      MIMEPDF,
+	".docx":     MIMEDOCX,
+	".html":     MIMEHTML,
+	".htm":      MIMEHTML,
+	".md":       MIMEMarkdown,
+	".markdown": MIMEMarkdown,
+	".txt":      MIMEText,
+	".text":     MIMEText,
+}
+
+// Detect returns the content type of a file, without parameters. It trusts the file's
+// signature first, then its extension, then the declared content type, and finally sniffs
+// the data, since clients often send uploads as application/octet-stream.
+func Detect(filename, declared string, data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return MIMEPDF
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")) && isDOCX(data):
+		return MIMEDOCX
+	}
+	if mimeType, ok := extensions[strings.ToLower(filepath.Ext(filename))]; ok {
+		return mimeType
+	}
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return mediaType
+}
+
+// Extract returns the text of data, which holds content of the given type. It returns
+// ErrUnsupported if the type isn't one of the MIME constants.
+func Extract(mimeType string, data []byte) (*Document, error) {
+	switch mimeType {
+	case MIMEPDF:
+		return extractPDF(data)
+	case MIMEDOCX:
+		return extractDOCX(data)
+	case MIMEHTML:
+		return ExtractHTML(data)
+	case MIMEMarkdown:
+		if !utf8.Valid(data) {
+			return nil, errors.New("the file is not valid UTF-8")
+		}
+		return extractMarkdown(string(data)), nil
+	case MIMEText:
+		if !utf8.Valid(data) {
+			return nil, errors.New("the file is not valid UTF-8")
+		}
+		return &Document{Text: string(data)}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupported, mimeType)
+}
+
+// isDOCX reports whether data is a zip archive holding a Word document.
+func isDOCX(data []byte) bool {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return false
+	}
+	for _, file := range archive.File {
+		if file.Name == "word/document.xml" {
+			return true
+		}
+	}
+	return false
+}
+
+// collapseBlankLines trims each line of text and removes runs of blank lines, leaving at most
+// one blank line between paragraphs.
+func collapseBlankLines(text string) string {
+	var b strings.Builder
+	blank := true
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if !blank {
+				b.WriteString("\n")
+			}
+			blank = true
+			continue
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+		blank = false
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,174 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// buildPDF returns a PDF with one page per entry of pages. Each line of a page is drawn
+// below the last.
+func buildPDF(title string, pages ...[]string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // The page tree, filled in below.
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		fmt.Sprintf("<< /Title (%s) >>", title),
+	}
+	var kids []string
+	for _, lines := range pages {
+		content := "BT /F1 12 Tf 72 720 Td"
+		for i, line := range lines {
+			if i > 0 {
+				content += " 0 -14 Td"
+			}
+			content += fmt.Sprintf(" (%s) Tj", line)
+		}
+		content += " ET"
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", len(objects)))
+		kids = append(kids, fmt.Sprintf("%d 0 R", len(objects)))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// buildDOCX returns a Word document with the given title and document.xml body.
+func buildDOCX(t *testing.T, title, body string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	archive := zip.NewWriter(&b)
+	files := map[string]string{
+		"word/document.xml": `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` + body + `</w:body></w:document>`,
+		"docProps/core.xml": `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>` + title + `</dc:title></cp:coreProperties>`,
+	}
+	for name, content := range files {
+		w, err := archive.Create(name)
+		assert.NoError(t, err)
+		_, _ = w.Write([]byte(content))
+	}
+	assert.NoError(t, archive.Close())
+	return b.Bytes()
+}
+
+func TestDetect(t *testing.T) {
+	docx := buildDOCX(t, "", "")
+	testCases := []struct {
+		name     string
+		filename string
+		declared string
+		data     []byte
+		expected string
+	}{
+		{"PDFSignature", "upload.bin", "application/octet-stream", []byte("%PDF-1.7\n"), MIMEPDF},
+		{"DOCXSignature", "upload", "", docx, MIMEDOCX},
+		{"OtherZip", "archive.zip", "", []byte("PK\x03\x04"), "application/zip"},
+		{"MarkdownExtension", "README.MD", "text/plain", []byte("# Title"), MIMEMarkdown},
+		{"DeclaredType", "page", "text/html; charset=utf-8", []byte("<p>Hi</p>"), MIMEHTML},
+		{"SniffedHTML", "page", "application/octet-stream", []byte("<!DOCTYPE html><p>Hi</p>"), MIMEHTML},
+		{"SniffedText", "notes", "", []byte("Plain notes."), MIMEText},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Detect(tc.filename, tc.declared, tc.data))
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	t.Run("PDF", func(t *testing.T) {
+		data := buildPDF("Billing Guide", []string{"Invoices are emailed monthly.", "Pay by card."}, nil, []string{"Refunds take five days."})
+
+		doc, err := Extract(MIMEPDF, data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Billing Guide", doc.Title)
+		assert.Equal(t, []string{"Invoices are emailed monthly.\nPay by card.", "", "Refunds take five days."}, doc.Pages)
+		assert.Equal(t, "Invoices are emailed monthly.\nPay by card.\n\nRefunds take five days.", doc.Text)
+	})
+
+	t.Run("InvalidPDF", func(t *testing.T) {
+		_, err := Extract(MIMEPDF, []byte("%PDF-1.4\ngarbage"))
+
+		assert.ErrorContains(t, err, "error reading PDF")
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		data := []byte(`<html><head><title> Billing
+			FAQ </title><style>p { color: red; }</style></head>
+			<body><nav><a href="/">Home</a></nav>
+			<main><h1>Billing</h1><p>Invoices are   emailed <b>monthly</b>.</p><script>track()</script>
+			<ul><li>Card</li><li>Transfer</li></ul></main>
+			<footer>Copyright</footer></body></html>`)
+
+		doc, err := Extract(MIMEHTML, data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Billing FAQ", doc.Title)
+		assert.Equal(t, "Billing\n\nInvoices are emailed monthly.\n\nCard\n\nTransfer", doc.Text)
+		assert.Nil(t, doc.Pages)
+	})
+
+	t.Run("HTMLWithoutMain", func(t *testing.T) {
+		doc, err := Extract(MIMEHTML, []byte(`<body><header>Site</header><h1>Refunds</h1><div>Refunds take five days.</div></body>`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Refunds", doc.Title)
+		assert.Equal(t, "Refunds\n\nRefunds take five days.", doc.Text)
+	})
+
+	t.Run("DOCX", func(t *testing.T) {
+		data := buildDOCX(t, "Billing Guide", `<w:p><w:r><w:t>Invoices are </w:t></w:r><w:r><w:t>emailed monthly.</w:t></w:r></w:p>`+
+			`<w:p/><w:p><w:r><w:t>Pay by</w:t><w:tab/><w:t>card.</w:t><w:br/><w:t>Or transfer.</w:t></w:r></w:p>`)
+
+		doc, err := Extract(MIMEDOCX, data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Billing Guide", doc.Title)
+		assert.Equal(t, "Invoices are emailed monthly.\n\nPay by\tcard.\nOr transfer.", doc.Text)
+	})
+
+	t.Run("Markdown", func(t *testing.T) {
+		data := []byte("---\nlayout: page\n---\n# Billing\n\nSee [the FAQ](/faq) and ![a chart](chart.png).\n\n```go\nfmt.Println()\n```\n## Refunds\n")
+
+		doc, err := Extract(MIMEMarkdown, data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Billing", doc.Title)
+		assert.Equal(t, "Billing\n\nSee the FAQ and a chart.\n\nfmt.Println()\nRefunds", doc.Text)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := Extract("image/png", []byte{0x89, 'P', 'N', 'G'})
+
+		assert.True(t, errors.Is(err, ErrUnsupported))
+	})
+
+	t.Run("InvalidUTF8", func(t *testing.T) {
+		_, err := Extract(MIMEText, []byte{0xff, 0xfe})
+
+		assert.EqualError(t, err, "the file is not valid UTF-8")
+	})
+}
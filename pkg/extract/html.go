@@ -0,0 +1,146 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedElements hold no main content: scripts and styles, and the navigation and
+// boilerplate around a page's content.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Nav:      true,
+	atom.Header:   true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Iframe:   true,
+}
+
+// blockElements start on a new line.
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Blockquote: true, atom.Br: true,
+	atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Figcaption: true,
+	atom.Figure: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true,
+	atom.H5: true, atom.H6: true, atom.Hr: true, atom.Li: true, atom.Main: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true,
+	atom.Td: true, atom.Th: true, atom.Tr: true, atom.Ul: true,
+}
+
+// ExtractHTML returns the main content of an HTML page: the first <main> element, or else
+// the first <article>, or else the <body>, leaving out scripts, styles, navigation, headers,
+// footers and sidebars. The title is the page's <title>, or else its first <h1>.
+func ExtractHTML(data []byte) (*Document, error) {
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing HTML: %w", err)
+	}
+
+	content := findElement(root, atom.Main)
+	if content == nil {
+		content = findElement(root, atom.Article)
+	}
+	if content == nil {
+		content = findElement(root, atom.Body)
+	}
+
+	doc := &Document{}
+	if title := findElement(root, atom.Title); title != nil {
+		doc.Title = strings.Join(strings.Fields(nodeText(title)), " ")
+	}
+	if doc.Title == "" {
+		if h1 := findElement(root, atom.H1); h1 != nil {
+			doc.Title = strings.Join(strings.Fields(nodeText(h1)), " ")
+		}
+	}
+	if content != nil {
+		var b strings.Builder
+		writeText(&b, content)
+		doc.Text = collapseBlankLines(b.String())
+	}
+	return doc, nil
+}
+
+// findElement returns the first element of type a under n, in document order.
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// nodeText returns all the text under n.
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	for node := range n.Descendants() {
+		if node.Type == html.TextNode {
+			b.WriteString(node.Data)
+		}
+	}
+	return b.String()
+}
+
+// writeText writes the readable text under n, one block element per paragraph. Whitespace is
+// collapsed except inside <pre>.
+func writeText(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(collapseSpaces(n.Data))
+		return
+	case html.ElementNode:
+		if skippedElements[n.DataAtom] {
+			return
+		}
+		if n.DataAtom == atom.Pre {
+			b.WriteString("\n\n")
+			b.WriteString(nodeText(n))
+			b.WriteString("\n\n")
+			return
+		}
+	}
+
+	block := n.Type == html.ElementNode && blockElements[n.DataAtom]
+	if block {
+		b.WriteString("\n\n")
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		writeText(b, child)
+	}
+	if block {
+		b.WriteString("\n\n")
+	}
+}
+
+// collapseSpaces replaces each run of whitespace in s with a single space.
+func collapseSpaces(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
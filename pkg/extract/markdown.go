@@ -0,0 +1,47 @@
+package extract
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// markdownImage matches an image, ![alt](src), keeping the alt text.
+	markdownImage = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	// markdownLink matches an inline link, [text](url), keeping the text.
+	markdownLink = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	// markdownHeading matches the marker of an ATX heading.
+	markdownHeading = regexp.MustCompile(`^#{1,6}\s+`)
+)
+
+// extractMarkdown returns the text of a Markdown document without its markup: front matter,
+// code fence lines and heading markers are removed, and links and images are replaced by
+// their text. The title is the first top-level heading.
+func extractMarkdown(text string) *Document {
+	doc := &Document{}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	// YAML front matter sits between "---" lines at the very start.
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		if _, after, found := strings.Cut(rest, "\n---\n"); found {
+			text = after
+		}
+	}
+
+	var b strings.Builder
+	for line := range strings.Lines(text) {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			continue
+		}
+		if title, ok := strings.CutPrefix(trimmed, "# "); ok && doc.Title == "" {
+			doc.Title = strings.TrimSpace(title)
+		}
+		line = markdownHeading.ReplaceAllString(line, "")
+		line = markdownImage.ReplaceAllString(line, "$1")
+		line = markdownLink.ReplaceAllString(line, "$1")
+		b.WriteString(line)
+	}
+	doc.Text = strings.TrimSpace(b.String())
+	return doc
+}
@@ -0,0 +1,65 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// extractPDF returns the text of each page of a PDF.
+func extractPDF(data []byte) (doc *Document, err error) {
+	// The PDF reader panics on some malformed files.
+	defer func() {
+		if r := recover(); r != nil {
+			doc, err = nil, fmt.Errorf("error reading PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading PDF: %w", err)
+	}
+
+	doc = &Document{Title: strings.TrimSpace(reader.Trailer().Key("Info").Key("Title").Text())}
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			doc.Pages = append(doc.Pages, "")
+			continue
+		}
+		doc.Pages = append(doc.Pages, pageText(page.Content().Text))
+	}
+
+	var nonEmpty []string
+	for _, page := range doc.Pages {
+		if page != "" {
+			nonEmpty = append(nonEmpty, page)
+		}
+	}
+	doc.Text = strings.Join(nonEmpty, "\n\n")
+	return doc, nil
+}
+
+// pageText joins the text runs of a page in the order they are drawn, which is the reading
+// order for most documents. A run that starts lower on the page begins a new line, and one
+// that starts well to the right of where the last ended is separated by a space.
+func pageText(runs []pdf.Text) string {
+	var b strings.Builder
+	for i, run := range runs {
+		if i > 0 {
+			prev := runs[i-1]
+			size := math.Max(run.FontSize, 1)
+			switch {
+			case math.Abs(run.Y-prev.Y) > size/2:
+				b.WriteString("\n")
+			case run.X-(prev.X+prev.W) > size/5:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(run.S)
+	}
+	return collapseBlankLines(b.String())
+}
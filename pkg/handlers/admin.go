@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/chr1sbest/hybrid-search/api"
+)
+
+// ReloadSynonyms handles the POST /admin/synonyms/reload endpoint. When env.AdminToken is set,
+// the request must carry it as a bearer token.
+func (env *Env) ReloadSynonyms(w http.ResponseWriter, r *http.Request) {
+	if !env.authorizeAdmin(w, r) {
+		return
+	}
+	if env.Synonyms == nil {
+		msg := "Synonyms are not configured on this server"
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	count, err := env.Synonyms.Reload(r.Context())
+	if err != nil {
+		msg := "Failed to reload synonyms"
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		log.Printf("Failed to reload synonyms: %v", err)
+		return
+	}
+
+	msg := fmt.Sprintf("Reloaded %d synonym rules", count)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.SuccessMessage{Message: &msg})
+}
+
+// authorizeAdmin responds with 401 and returns false unless the request carries env.AdminToken
+// as a bearer token. Without an AdminToken, admin endpoints are open to anyone who can reach
+// the server.
+func (env *Env) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if env.AdminToken == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if ok && subtle.ConstantTimeCompare([]byte(token), []byte(env.AdminToken)) == 1 {
+		return true
+	}
+	msg := "A valid admin token is required"
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(api.Error{Message: &msg})
+	return false
+}
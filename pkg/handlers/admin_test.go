@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	storage_mocks "github.com/chr1sbest/hybrid-search/pkg/storage/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/synonyms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEnv_ReloadSynonyms(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "synonyms.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("k8s, kubernetes\nsla => service level agreement\n"), 0o644))
+
+	t.Run("Reloads", func(t *testing.T) {
+		// 1. Arrange
+		mockSynonymStore := new(storage_mocks.SynonymStore)
+		mockSynonymStore.On("UpdateSynonyms", mock.Anything, []string{"k8s, kubernetes", "sla => service level agreement"}).Return(nil)
+		env := &Env{Synonyms: synonyms.NewManager(path, mockSynonymStore)}
+
+		// 2. Act
+		w := httptest.NewRecorder()
+		env.ReloadSynonyms(w, httptest.NewRequest(http.MethodPost, "/admin/synonyms/reload", nil))
+
+		// 3. Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp api.SuccessMessage
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		assert.Equal(t, "Reloaded 2 synonym rules", *resp.Message)
+		mockSynonymStore.AssertExpectations(t)
+	})
+
+	t.Run("StoreError", func(t *testing.T) {
+		mockSynonymStore := new(storage_mocks.SynonymStore)
+		mockSynonymStore.On("UpdateSynonyms", mock.Anything, mock.Anything).Return(errors.New("cluster unavailable"))
+		env := &Env{Synonyms: synonyms.NewManager(path, mockSynonymStore)}
+
+		w := httptest.NewRecorder()
+		env.ReloadSynonyms(w, httptest.NewRequest(http.MethodPost, "/admin/synonyms/reload", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("StoreErrorIsNotExposed", func(t *testing.T) {
+		mockSynonymStore := new(storage_mocks.SynonymStore)
+		mockSynonymStore.On("UpdateSynonyms", mock.Anything, mock.Anything).Return(errors.New("cluster es-internal-7 unavailable"))
+		env := &Env{Synonyms: synonyms.NewManager(path, mockSynonymStore)}
+
+		w := httptest.NewRecorder()
+		env.ReloadSynonyms(w, httptest.NewRequest(http.MethodPost, "/admin/synonyms/reload", nil))
+
+		assert.NotContains(t, w.Body.String(), "es-internal-7")
+	})
+
+	t.Run("RequiresAdminToken", func(t *testing.T) {
+		mockSynonymStore := new(storage_mocks.SynonymStore)
+		mockSynonymStore.On("UpdateSynonyms", mock.Anything, mock.Anything).Return(nil).Once()
+		env := &Env{Synonyms: synonyms.NewManager(path, mockSynonymStore), AdminToken: "s3cret"}
+		reload := func(authorization string) int {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/admin/synonyms/reload", nil)
+			if authorization != "" {
+				r.Header.Set("Authorization", authorization)
+			}
+			env.ReloadSynonyms(w, r)
+			return w.Code
+		}
+		assert.Equal(t, http.StatusUnauthorized, reload(""))
+		assert.Equal(t, http.StatusUnauthorized, reload("Bearer wrong"))
+		assert.Equal(t, http.StatusUnauthorized, reload("s3cret"))
+		assert.Equal(t, http.StatusOK, reload("Bearer s3cret"))
+		mockSynonymStore.AssertExpectations(t)
+	})
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		(&Env{}).ReloadSynonyms(w, httptest.NewRequest(http.MethodPost, "/admin/synonyms/reload", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/pkg/answer"
+	"github.com/chr1sbest/hybrid-search/pkg/search"
+)
+
+// defaultAnswerTopK is the number of results requested from each retriever for /answer.
+const defaultAnswerTopK = 8
+
+// tokenEvent is the data of a streamed "token" event.
+type tokenEvent struct {
+	Text string `json:"text"`
+}
+
+// AnswerQuestion handles the POST /answer endpoint.
+func (env *Env) AnswerQuestion(w http.ResponseWriter, r *http.Request) {
+	if env.AnswerService == nil {
+		msg := "Answering is not configured on this server"
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	var req api.AnswerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := "Invalid request body"
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	opts, err := answerOptions(req)
+	if err != nil {
+		msg := err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	// When streaming, the event stream is only opened once the first token arrives, so
+	// errors from the search can still be reported with a normal status code.
+	stream := req.Stream != nil && *req.Stream
+	streaming := false
+	var onToken func(token string) error
+	if stream {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			msg := "Streaming is not supported by this connection"
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(api.Error{Message: &msg})
+			return
+		}
+		onToken = func(token string) error {
+			if !streaming {
+				startEventStream(w)
+				streaming = true
+			}
+			if err := writeEvent(w, "token", tokenEvent{Text: token}); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		}
+	}
+
+	result, err := env.AnswerService.Answer(r.Context(), req.Question, opts, onToken)
+	if err != nil && streaming {
+		msg := "Failed to generate an answer"
+		writeEvent(w, "error", api.Error{Message: &msg})
+		log.Printf("Failed to generate an answer: %v", err)
+		return
+	}
+	if errors.Is(err, search.ErrUnknownReranker) || errors.Is(err, search.ErrUnknownRewriter) {
+		msg := err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+	if err != nil {
+		msg := "Failed to generate an answer"
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		log.Printf("Failed to generate an answer: %v", err)
+		return
+	}
+
+	resp := toAPIAnswer(result)
+	if stream {
+		if !streaming {
+			startEventStream(w)
+		}
+		writeEvent(w, "answer", resp)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// answerOptions validates an /answer request and converts it to answer options.
+func answerOptions(req api.AnswerRequest) (answer.Options, error) {
+	opts := answer.Options{Search: search.Options{TopK: defaultAnswerTopK}}
+	if req.Question == "" {
+		return opts, fmt.Errorf("'question' field cannot be empty")
+	}
+	if req.TopK != nil {
+		if *req.TopK < 1 {
+			return opts, fmt.Errorf("'top_k' must be at least 1")
+		}
+		opts.Search.TopK = *req.TopK
+	}
+	if req.MaxContextTokens != nil {
+		if *req.MaxContextTokens < 1 {
+			return opts, fmt.Errorf("'max_context_tokens' must be at least 1")
+		}
+		opts.MaxContextTokens = *req.MaxContextTokens
+	}
+	if req.Reranker != nil {
+		opts.Search.Reranker = *req.Reranker
+	}
+	if req.Rewrite != nil {
+		opts.Search.Rewriter = *req.Rewrite
+	}
+	return opts, nil
+}
+
+// toAPIAnswer converts a generated answer to its API form.
+func toAPIAnswer(result *answer.Answer) api.AnswerResponse {
+	citations := make([]api.Citation, len(result.Citations))
+	for i, c := range result.Citations {
+		number, docID, parentDocID, score, cited := c.Number, c.DocumentID, c.ParentDocumentID, c.Score, c.Cited
+		citations[i] = api.Citation{
+			Number:           &number,
+			DocumentId:       &docID,
+			ParentDocumentId: &parentDocID,
+			Score:            &score,
+			Cited:            &cited,
+		}
+	}
+	text := result.Text
+	return api.AnswerResponse{Answer: &text, Citations: &citations}
+}
+
+// startEventStream writes the headers for a server-sent event stream.
+func startEventStream(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeEvent writes a single server-sent event with a JSON-encoded data payload.
+func writeEvent(w http.ResponseWriter, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
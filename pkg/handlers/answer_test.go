@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/pkg/answer"
+	answer_mocks "github.com/chr1sbest/hybrid-search/pkg/answer/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testAnswer = &answer.Answer{
+	Text: "Invoices are emailed monthly [1].",
+	Citations: []answer.Citation{
+		{Number: 1, DocumentID: "p1#0", ParentDocumentID: "p1", Score: 0.9, Cited: true},
+		{Number: 2, DocumentID: "p2#3", ParentDocumentID: "p2", Score: 0.4},
+	},
+}
+
+func TestEnv_AnswerQuestion(t *testing.T) {
+	t.Run("ReturnsAnswerWithCitations", func(t *testing.T) {
+		// 1. Arrange
+		mockAnswerService := new(answer_mocks.Service)
+		env := &Env{AnswerService: mockAnswerService}
+
+		req := httptest.NewRequest(http.MethodPost, "/answer", strings.NewReader(`{"question": "when are invoices sent?", "top_k": 3, "reranker": "http"}`))
+		w := httptest.NewRecorder()
+
+		expectedOpts := answer.Options{Search: search.Options{TopK: 3, Reranker: "http"}}
+		mockAnswerService.On("Answer", mock.Anything, "when are invoices sent?", expectedOpts, mock.Anything).Return(testAnswer, nil)
+
+		// 2. Act
+		env.AnswerQuestion(w, req)
+
+		// 3. Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var resp api.AnswerResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, testAnswer.Text, *resp.Answer)
+		assert.Len(t, *resp.Citations, 2)
+		first := (*resp.Citations)[0]
+		assert.Equal(t, 1, *first.Number)
+		assert.Equal(t, "p1#0", *first.DocumentId)
+		assert.Equal(t, "p1", *first.ParentDocumentId)
+		assert.True(t, *first.Cited)
+		assert.False(t, *(*resp.Citations)[1].Cited)
+
+		// A non-streaming request doesn't pass a token callback.
+		onToken := mockAnswerService.Calls[0].Arguments.Get(3).(func(string) error)
+		assert.Nil(t, onToken)
+	})
+
+	t.Run("Streams", func(t *testing.T) {
+		mockAnswerService := new(answer_mocks.Service)
+		env := &Env{AnswerService: mockAnswerService}
+
+		mockAnswerService.On("Answer", mock.Anything, "q", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				onToken := args.Get(3).(func(string) error)
+				onToken("Invoices are ")
+				onToken("emailed monthly [1].")
+			}).
+			Return(testAnswer, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/answer", strings.NewReader(`{"question": "q", "stream": true}`))
+		w := httptest.NewRecorder()
+		env.AnswerQuestion(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+		events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+		assert.Len(t, events, 3)
+		assert.Equal(t, "event: token\ndata: {\"text\":\"Invoices are \"}", events[0])
+		assert.Equal(t, "event: token\ndata: {\"text\":\"emailed monthly [1].\"}", events[1])
+		assert.True(t, strings.HasPrefix(events[2], "event: answer\ndata: "))
+
+		var resp api.AnswerResponse
+		assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(events[2], "event: answer\ndata: ")), &resp))
+		assert.Equal(t, testAnswer.Text, *resp.Answer)
+	})
+
+	t.Run("StreamErrorAfterTokens", func(t *testing.T) {
+		mockAnswerService := new(answer_mocks.Service)
+		env := &Env{AnswerService: mockAnswerService}
+
+		mockAnswerService.On("Answer", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				args.Get(3).(func(string) error)("Invoices")
+			}).
+			Return(nil, errors.New("model went away"))
+
+		req := httptest.NewRequest(http.MethodPost, "/answer", strings.NewReader(`{"question": "q", "stream": true}`))
+		w := httptest.NewRecorder()
+		env.AnswerQuestion(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "event: error\ndata: {\"message\":\"Failed to generate an answer\"}")
+	})
+
+	t.Run("StreamErrorBeforeTokens", func(t *testing.T) {
+		mockAnswerService := new(answer_mocks.Service)
+		env := &Env{AnswerService: mockAnswerService}
+
+		mockAnswerService.On("Answer", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, fmt.Errorf("error searching for sources: %w", search.ErrUnknownReranker))
+
+		req := httptest.NewRequest(http.MethodPost, "/answer", strings.NewReader(`{"question": "q", "stream": true, "reranker": "nope"}`))
+		w := httptest.NewRecorder()
+		env.AnswerQuestion(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NotEqual(t, "text/event-stream", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("InvalidRequests", func(t *testing.T) {
+		tests := []struct {
+			name string
+			body string
+		}{
+			{name: "MalformedBody", body: `{`},
+			{name: "MissingQuestion", body: `{}`},
+			{name: "InvalidTopK", body: `{"question": "q", "top_k": 0}`},
+			{name: "InvalidMaxContextTokens", body: `{"question": "q", "max_context_tokens": -1}`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockAnswerService := new(answer_mocks.Service)
+				env := &Env{AnswerService: mockAnswerService}
+
+				req := httptest.NewRequest(http.MethodPost, "/answer", strings.NewReader(tt.body))
+				w := httptest.NewRecorder()
+				env.AnswerQuestion(w, req)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				mockAnswerService.AssertNotCalled(t, "Answer", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("ServiceError", func(t *testing.T) {
+		mockAnswerService := new(answer_mocks.Service)
+		env := &Env{AnswerService: mockAnswerService}
+		mockAnswerService.On("Answer", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("model down"))
+
+		req := httptest.NewRequest(http.MethodPost, "/answer", strings.NewReader(`{"question": "q"}`))
+		w := httptest.NewRecorder()
+		env.AnswerQuestion(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/answer", strings.NewReader(`{"question": "q"}`))
+		w := httptest.NewRecorder()
+		(&Env{}).AnswerQuestion(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// DefaultMaxBatchDocuments is the number of documents /documents:batch accepts when
+// Env.MaxBatchDocuments is not set.
+const DefaultMaxBatchDocuments = 100
+
+// BatchStoreDocuments handles the POST /documents:batch endpoint. Documents that fail
+// validation are reported as failed without being sent to the ingest service.
+func (env *Env) BatchStoreDocuments(w http.ResponseWriter, r *http.Request) {
+	if env.IngestService == nil {
+		msg := "Batch ingestion is not configured"
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	var req api.BatchStoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := "Invalid request body"
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	maxDocs := env.MaxBatchDocuments
+	if maxDocs <= 0 {
+		maxDocs = DefaultMaxBatchDocuments
+	}
+	if len(req.Documents) == 0 || len(req.Documents) > maxDocs {
+		msg := fmt.Sprintf("'documents' must contain between 1 and %d documents", maxDocs)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	results := make([]ingest.Result, len(req.Documents))
+	// valid holds the documents to ingest, and positions their indexes in the request.
+	var valid []storage.Document
+	var positions []int
+	seen := make(map[string]bool, len(req.Documents))
+	for i, doc := range req.Documents {
+		results[i].DocumentID = doc.Id
+		if reason := validateBatchDocument(doc, seen); reason != "" {
+			results[i].Status = ingest.StatusFailed
+			results[i].Error = reason
+			continue
+		}
+		seen[doc.Id] = true
+
+		parentDoc := storage.Document{DocumentID: doc.Id, Text: doc.Text}
+		if doc.Title != nil {
+			parentDoc.Title = strings.TrimSpace(*doc.Title)
+		}
+		if doc.Metadata != nil {
+			parentDoc.Metadata = *doc.Metadata
+		}
+		valid = append(valid, parentDoc)
+		positions = append(positions, i)
+	}
+
+	if len(valid) > 0 {
+		for i, result := range env.IngestService.Ingest(r.Context(), valid) {
+			results[positions[i]] = result
+		}
+	}
+
+	apiResults := make([]api.BatchResult, len(results))
+	for i, result := range results {
+		id, status := result.DocumentID, api.BatchResultStatus(result.Status)
+		apiResults[i] = api.BatchResult{Id: &id, Status: &status}
+		if result.Error != "" {
+			reason := result.Error
+			apiResults[i].Error = &reason
+		}
+		if result.Version > 0 {
+			version := result.Version
+			apiResults[i].Version = &version
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.BatchStoreResponse{Results: &apiResults})
+}
+
+// validateBatchDocument returns why doc cannot be ingested, or "" if it can. seen holds the
+// IDs of the valid documents before it in the batch.
+func validateBatchDocument(doc api.BatchDocument, seen map[string]bool) string {
+	switch {
+	case strings.TrimSpace(doc.Id) == "":
+		return "'id' cannot be empty"
+	case strings.Contains(doc.Id, "#"):
+		// Chunk IDs are the parent ID followed by "#" and the chunk number.
+		return "'id' cannot contain '#'"
+	case seen[doc.Id]:
+		return "duplicate 'id' in batch"
+	case doc.Text == "":
+		return "'text' cannot be empty"
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	ingest_mocks "github.com/chr1sbest/hybrid-search/pkg/ingest/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEnv_BatchStoreDocuments(t *testing.T) {
+	t.Run("ReportsEachDocument", func(t *testing.T) {
+		// 1. Arrange
+		mockIngest := new(ingest_mocks.Service)
+		mockIngest.On("Ingest", mock.Anything, []storage.Document{
+			{DocumentID: "a", Title: "Billing", Text: "Invoices are emailed monthly.", Metadata: map[string]string{"tag": "billing"}},
+			{DocumentID: "c", Text: "Exports are available as CSV."},
+		}).Return([]ingest.Result{
+			{DocumentID: "a", Status: ingest.StatusCreated, Version: 1},
+			{DocumentID: "c", Status: ingest.StatusFailed, Error: "failed to store chunks: quota exceeded"},
+		})
+		env := &Env{IngestService: mockIngest}
+
+		body := `{"documents": [
+			{"id": "a", "title": " Billing ", "text": "Invoices are emailed monthly.", "metadata": {"tag": "billing"}},
+			{"id": "b#1", "text": "Chunk-like IDs are rejected."},
+			{"id": "c", "text": "Exports are available as CSV."},
+			{"id": "a", "text": "Duplicates are rejected."},
+			{"id": "d", "text": ""}
+		]}`
+
+		// 2. Act
+		w := httptest.NewRecorder()
+		env.BatchStoreDocuments(w, httptest.NewRequest(http.MethodPost, "/documents:batch", bytes.NewBufferString(body)))
+
+		// 3. Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp api.BatchStoreResponse
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		results := *resp.Results
+		assert.Len(t, results, 5)
+		for i, want := range []struct{ id, status, err string }{
+			{"a", "created", ""},
+			{"b#1", "failed", "'id' cannot contain '#'"},
+			{"c", "failed", "failed to store chunks: quota exceeded"},
+			{"a", "failed", "duplicate 'id' in batch"},
+			{"d", "failed", "'text' cannot be empty"},
+		} {
+			assert.Equal(t, want.id, *results[i].Id)
+			assert.Equal(t, want.status, string(*results[i].Status))
+			if want.err == "" {
+				assert.Nil(t, results[i].Error)
+			} else {
+				assert.Equal(t, want.err, *results[i].Error)
+			}
+		}
+		assert.Equal(t, 1, *results[0].Version)
+		assert.Nil(t, results[2].Version)
+		mockIngest.AssertExpectations(t)
+	})
+
+	t.Run("InvalidBatchSize", func(t *testing.T) {
+		for _, body := range []string{
+			`{"documents": []}`,
+			`{"documents": [{"id": "a", "text": "one"}, {"id": "b", "text": "two"}]}`,
+			`not json`,
+		} {
+			mockIngest := new(ingest_mocks.Service)
+			env := &Env{IngestService: mockIngest, MaxBatchDocuments: 1}
+
+			w := httptest.NewRecorder()
+			env.BatchStoreDocuments(w, httptest.NewRequest(http.MethodPost, "/documents:batch", bytes.NewBufferString(body)))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockIngest.AssertNotCalled(t, "Ingest", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		(&Env{}).BatchStoreDocuments(w, httptest.NewRequest(http.MethodPost, "/documents:batch", bytes.NewBufferString(`{}`)))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -2,28 +2,67 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/chr1sbest/hybrid-search/api"
-	"github.com/chr1sbest/hybrid-search/pkg/chunker"
-	"github.com/chr1sbest/hybrid-search/pkg/embeddings"
+	"github.com/chr1sbest/hybrid-search/pkg/answer"
+	"github.com/chr1sbest/hybrid-search/pkg/autocomplete"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	"github.com/chr1sbest/hybrid-search/pkg/jobs"
+	"github.com/chr1sbest/hybrid-search/pkg/outbox"
+	"github.com/chr1sbest/hybrid-search/pkg/ranking"
 	"github.com/chr1sbest/hybrid-search/pkg/search"
 	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/chr1sbest/hybrid-search/pkg/synonyms"
+	"github.com/chr1sbest/hybrid-search/pkg/versions"
 	"github.com/google/uuid"
-	"golang.org/x/sync/errgroup"
 )
 
 // Env holds application-wide dependencies and implements the api.ServerInterface.
 type Env struct {
-	EmbeddingClient embeddings.EmbeddingClient
-	VectorStore     storage.VectorStore
-	TextStore       storage.TextStore
-	SearchService   search.Service
+	SearchService search.Service
+	// AnswerService is optional; /answer responds with 503 when it is not set.
+	AnswerService answer.Service
+	// Synonyms is optional; /admin/synonyms/reload responds with 503 when it is not set.
+	Synonyms *synonyms.Manager
+	// AdminToken, when set, must be sent as a bearer token to the /admin endpoints. When it is
+	// empty, those endpoints are unauthenticated.
+	AdminToken string
+	// Autocompleter is optional; /suggest responds with 503 when it is not set.
+	Autocompleter storage.Autocompleter
+	// QueryLog is optional. When set, searches that find results are counted so that
+	// frequent queries are offered as completions.
+	QueryLog *autocomplete.QueryLog
+	// Pipeline stores the documents of /store and /documents/upload. Its retry policy applies
+	// to every step, and its failure policy to requests that don't set failure_policy. Unless
+	// an outbox is set, those endpoints respond with 503 when it is not set.
+	Pipeline *ingest.Pipeline
+	// IngestService is optional; /documents:batch responds with 503 when it is not set.
+	IngestService ingest.Service
+	// MaxBatchDocuments limits the documents accepted by /documents:batch. When it is zero,
+	// DefaultMaxBatchDocuments applies.
+	MaxBatchDocuments int
+	// Jobs is optional; /store?async=true and /jobs/{id} respond with 503 when it is not set.
+	Jobs *jobs.Manager
+	// Outbox is optional. When set, /store records each document in it before writing to the
+	// stores, and failed writes are retried in the background instead of being undone.
+	Outbox *outbox.Outbox
+	// MaxUploadBytes limits the size of /documents/upload requests. When it is zero,
+	// DefaultMaxUploadBytes applies.
+	MaxUploadBytes int64
+	// History is optional; PUT /documents/{id}, its versions and rollbacks respond with 503
+	// when it is not set. Versioned writes also need IngestService, which should record the
+	// other documents it stores in the same history, as should Pipeline. /store records the
+	// documents it writes through the outbox in it.
+	History *versions.History
 }
 
 // StoreDocument handles the POST /store endpoint.
-func (env *Env) StoreDocument(w http.ResponseWriter, r *http.Request) {
+func (env *Env) StoreDocument(w http.ResponseWriter, r *http.Request, params api.StoreDocumentParams) {
 	var req api.StoreRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		msg := "Invalid request body"
@@ -39,54 +78,102 @@ func (env *Env) StoreDocument(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := r.Context()
-	parentDocID := uuid.New().String()
-
-	chunkr := chunker.NewChunker(512, 50)
-	chunks := chunkr.Chunk(req.Text, parentDocID)
-
-	var g errgroup.Group
-
-	g.Go(func() error {
-		parentDoc := storage.Document{
-			DocumentID: parentDocID,
-			Text:       req.Text,
-		}
-		return env.TextStore.Index(ctx, parentDoc)
-	})
+	var metadata map[string]string
+	if req.Metadata != nil {
+		metadata = *req.Metadata
+	}
+	var title string
+	if req.Title != nil {
+		title = strings.TrimSpace(*req.Title)
+	}
 
-	g.Go(func() error {
-		for _, chunk := range chunks {
-			vector, err := env.EmbeddingClient.CreateEmbedding(ctx, chunk.Text)
-			if err != nil {
-				log.Printf("Failed to create embedding for chunk %s: %v", chunk.DocumentID, err)
-				continue
-			}
+	parentDoc := storage.Document{
+		DocumentID: uuid.New().String(),
+		Title:      title,
+		Text:       req.Text,
+		Metadata:   metadata,
+	}
+	env.storeDocument(w, r, parentDoc, params.Async != nil && *params.Async, params.FailurePolicy)
+}
 
-			if err := env.VectorStore.Upsert(ctx, chunk, vector); err != nil {
-				log.Printf("Failed to upsert chunk %s: %v", chunk.DocumentID, err)
-			}
+// storeDocument stores a parent document and its chunks and writes the response, for /store
+// and /documents/upload. With async, the document is queued as a job instead, which uses the
+// server's failure policy; with an outbox, it is written through the outbox. Otherwise failed
+// chunks are handled by failurePolicy, or the server's policy when it is nil.
+func (env *Env) storeDocument(w http.ResponseWriter, r *http.Request, parentDoc storage.Document, async bool, failurePolicy *api.FailurePolicy) {
+	if async {
+		if failurePolicy != nil {
+			msg := "'failure_policy' can't be used with async=true; jobs use the server's failure policy"
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(api.Error{Message: &msg})
+			return
 		}
-		return nil
-	})
+		env.submitJob(w, parentDoc)
+		return
+	}
+	if env.Outbox != nil {
+		env.storeThroughOutbox(w, r, parentDoc)
+		return
+	}
+	if env.Pipeline == nil {
+		msg := "Document storage is not configured"
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
 
-	if err := g.Wait(); err != nil {
+	var policy ingest.FailurePolicy
+	if failurePolicy != nil {
+		policy = ingest.FailurePolicy(*failurePolicy)
+	}
+	parentDocID := parentDoc.DocumentID
+	result, err := env.Pipeline.Store(r.Context(), parentDoc, policy)
+	if err != nil {
 		msg := "Failed to store document and chunks"
+		if len(result.Failed) > 0 {
+			msg = fmt.Sprintf("Failed to store %d of %d chunks; the document was not stored", len(result.Failed), result.Chunks)
+		}
+		log.Printf("Failed to store document %s: %v", parentDocID, err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(api.Error{Message: &msg})
-		log.Printf("Error during storage: %v", err)
 		return
 	}
 
 	msg := "Document chunked and stored successfully"
+	status := http.StatusCreated
+	resp := api.StoreResponse{Message: &msg, DocumentId: &parentDocID}
+	if len(result.Failed) > 0 {
+		msg = fmt.Sprintf("Document stored, but %d of %d chunks failed", len(result.Failed), result.Chunks)
+		status = http.StatusMultiStatus
+		failed := make([]api.ChunkFailure, len(result.Failed))
+		for i, f := range result.Failed {
+			chunkID, reason := f.ChunkID, f.Err.Error()
+			failed[i] = api.ChunkFailure{ChunkId: &chunkID, Error: &reason}
+		}
+		resp.FailedChunks = &failed
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(api.SuccessMessage{Message: &msg})
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
 }
 
 // QueryDocuments handles the GET /query endpoint.
 func (env *Env) QueryDocuments(w http.ResponseWriter, r *http.Request, params api.QueryDocumentsParams) {
-	results, err := env.SearchService.Search(r.Context(), params.Q, 5)
+	opts, err := searchOptions(params)
+	if err != nil {
+		msg := err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	resp, err := env.SearchService.Search(r.Context(), params.Q, opts)
+	if errors.Is(err, search.ErrUnknownReranker) || errors.Is(err, search.ErrUnknownRewriter) || errors.Is(err, search.ErrNoVectors) {
+		msg := err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
 	if err != nil {
 		msg := "Failed to search records"
 		w.WriteHeader(http.StatusInternalServerError)
@@ -95,21 +182,156 @@ func (env *Env) QueryDocuments(w http.ResponseWriter, r *http.Request, params ap
 		return
 	}
 
-	// Convert storage.Document to api.Document
-	apiResults := make([]api.Document, len(results))
-	for i, res := range results {
-		// Create copies of the strings to take their address
-		docID := res.DocumentID
-		parentDocID := res.ParentDocumentID
-		text := res.Text
+	if env.QueryLog != nil && len(resp.Results) > 0 {
+		query := params.Q
+		if resp.CorrectedQuery != "" {
+			query = resp.CorrectedQuery
+		}
+		env.QueryLog.Record(query)
+	}
 
-		apiResults[i] = api.Document{
+	explain := params.Explain != nil && *params.Explain
+
+	// Convert ranking.Result to api.SearchResult
+	apiResults := make([]api.SearchResult, len(resp.Results))
+	for i, res := range resp.Results {
+		// Create copies of the values to take their address
+		docID := res.Document.DocumentID
+		parentDocID := res.Document.ParentDocumentID
+		text := res.Document.Text
+		score := res.Score
+
+		apiResults[i] = api.SearchResult{
 			DocumentId:       &docID,
 			ParentDocumentId: &parentDocID,
 			Text:             &text,
+			Score:            &score,
+			RerankScore:      res.RerankScore,
+		}
+		if len(res.Document.Metadata) > 0 {
+			metadata := res.Document.Metadata
+			apiResults[i].Metadata = &metadata
+		}
+		if opts.Highlight != nil {
+			highlights := res.Highlights
+			if highlights == nil {
+				highlights = []string{}
+			}
+			apiResults[i].Highlights = &highlights
+		}
+		if explain {
+			apiResults[i].Explanation = toAPIExplanation(res.Explanation)
 		}
 	}
 
+	body := api.SearchResponse{Results: &apiResults}
+	for _, suggestion := range resp.Suggestions {
+		w.Header().Add("X-Suggested-Query", suggestion)
+	}
+	if len(resp.Suggestions) > 0 {
+		body.Suggestions = &resp.Suggestions
+	}
+	if resp.CorrectedQuery != "" {
+		w.Header().Set("X-Corrected-Query", resp.CorrectedQuery)
+		body.CorrectedQuery = &resp.CorrectedQuery
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(apiResults)
+	json.NewEncoder(w).Encode(body)
+}
+
+// toAPIExplanation converts a fused result's per-retriever contributions to their API form.
+func toAPIExplanation(contributions []ranking.Contribution) *[]api.RetrieverContribution {
+	explanation := make([]api.RetrieverContribution, len(contributions))
+	for i, c := range contributions {
+		retriever, rank, score, contribution := c.Retriever, c.Rank, c.Score, c.Contribution
+		explanation[i] = api.RetrieverContribution{
+			Retriever:    &retriever,
+			Rank:         &rank,
+			Score:        &score,
+			Contribution: &contribution,
+		}
+	}
+	return &explanation
+}
+
+// searchOptions validates the /query parameters and converts them to search options.
+func searchOptions(params api.QueryDocumentsParams) (search.Options, error) {
+	fusion, err := fusionOverrides(params)
+	if err != nil {
+		return search.Options{}, err
+	}
+
+	opts := search.Options{TopK: 5, Fusion: fusion}
+	if params.Reranker != nil {
+		opts.Reranker = *params.Reranker
+	}
+	if params.Rewrite != nil {
+		opts.Rewriter = *params.Rewrite
+	}
+	opts.Autocorrect = params.Autocorrect != nil && *params.Autocorrect
+	if params.MmrLambda != nil {
+		if *params.MmrLambda < 0 || *params.MmrLambda > 1 {
+			return opts, fmt.Errorf("'mmr_lambda' must be between 0 and 1")
+		}
+		opts.MMRLambda = params.MmrLambda
+	}
+	if params.MaxPerParent != nil {
+		if *params.MaxPerParent < 1 {
+			return opts, fmt.Errorf("'max_per_parent' must be at least 1")
+		}
+		opts.MaxPerParent = *params.MaxPerParent
+	}
+	if params.Highlight != nil && *params.Highlight {
+		opts.Highlight = &storage.Highlight{}
+		if params.HighlightPreTag != nil {
+			opts.Highlight.PreTag = *params.HighlightPreTag
+		}
+		if params.HighlightPostTag != nil {
+			opts.Highlight.PostTag = *params.HighlightPostTag
+		}
+		if params.FragmentSize != nil {
+			if *params.FragmentSize < 1 {
+				return opts, fmt.Errorf("'fragment_size' must be at least 1")
+			}
+			opts.Highlight.FragmentSize = *params.FragmentSize
+		}
+	}
+	return opts, nil
+}
+
+// fusionOverrides builds the per-request fusion settings from the query parameters.
+// Parameters that are not set leave the server defaults in place.
+func fusionOverrides(params api.QueryDocumentsParams) (ranking.FusionConfig, error) {
+	var cfg ranking.FusionConfig
+	if params.Fusion != nil {
+		strategy := ranking.Strategy(*params.Fusion)
+		if _, err := ranking.NewFuser(strategy); err != nil {
+			return cfg, fmt.Errorf("'fusion' is invalid: %w", err)
+		}
+		cfg.Strategy = strategy
+	}
+	if params.RrfK != nil {
+		if *params.RrfK <= 0 {
+			return cfg, fmt.Errorf("'rrf_k' must be greater than 0")
+		}
+		cfg.K = *params.RrfK
+	}
+
+	weights := map[string]*float64{
+		ranking.RetrieverLexical:  params.LexicalWeight,
+		ranking.RetrieverSemantic: params.SemanticWeight,
+	}
+	for retriever, weight := range weights {
+		if weight == nil {
+			continue
+		}
+		if *weight < 0 {
+			return cfg, fmt.Errorf("'%s_weight' cannot be negative", retriever)
+		}
+		if cfg.Weights == nil {
+			cfg.Weights = make(map[string]float64)
+		}
+		cfg.Weights[retriever] = *weight
+	}
+	return cfg, nil
 }
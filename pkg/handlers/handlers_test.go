@@ -2,13 +2,23 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/pkg/autocomplete"
+	"github.com/chr1sbest/hybrid-search/pkg/chunker"
+	"github.com/chr1sbest/hybrid-search/pkg/embeddings"
 	embedding_mocks "github.com/chr1sbest/hybrid-search/pkg/embeddings/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	"github.com/chr1sbest/hybrid-search/pkg/ranking"
+	"github.com/chr1sbest/hybrid-search/pkg/search"
 	search_mocks "github.com/chr1sbest/hybrid-search/pkg/search/mocks"
 	"github.com/chr1sbest/hybrid-search/pkg/storage"
 	storage_mocks "github.com/chr1sbest/hybrid-search/pkg/storage/mocks"
@@ -16,17 +26,22 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// newStorePipeline creates a pipeline over the mocks that splits documents the way /store does
+// in production.
+func newStorePipeline(embed embeddings.EmbeddingClient, vector storage.VectorStore, text storage.TextStore, opts ...ingest.Option) *ingest.Pipeline {
+	return ingest.NewPipeline(chunker.NewChunker(chunker.DefaultChunkSize, chunker.DefaultChunkOverlap), embed, vector, text, 10, 1, opts...)
+}
+
+// created is the text store's result for a new document.
+var created = []storage.IndexResult{{Created: true}}
+
 func TestEnv_StoreDocument(t *testing.T) {
 	// 1. Arrange
 	mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
 	mockVectorStore := new(storage_mocks.VectorStore)
 	mockTextStore := new(storage_mocks.TextStore)
 
-	env := &Env{
-		EmbeddingClient: mockEmbeddingClient,
-		VectorStore:     mockVectorStore,
-		TextStore:       mockTextStore,
-	}
+	env := &Env{Pipeline: newStorePipeline(mockEmbeddingClient, mockVectorStore, mockTextStore)}
 
 	// Create a sample request body
 	storeReq := api.StoreRequest{Text: "This is a test document."}
@@ -35,18 +50,18 @@ func TestEnv_StoreDocument(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// 2. Act: Define mock expectations
-	// We expect the TextStore's Index method to be called once for the parent document.
-	mockTextStore.On("Index", mock.Anything, mock.AnythingOfType("storage.Document")).Return(nil).Once()
+	// We expect the TextStore to index the parent document once.
+	mockTextStore.On("IndexMany", mock.Anything, mock.AnythingOfType("[]storage.Document")).Return(created, nil).Once()
 
 	// We expect the EmbeddingClient to be called for each chunk.
 	// Since chunking is internal, we'll just say it can be called any number of times.
 	mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.AnythingOfType("string")).Return([]float32{0.4, 0.5, 0.6}, nil)
 
-	// We expect the VectorStore's Upsert method to be called for each chunk.
-	mockVectorStore.On("Upsert", mock.Anything, mock.AnythingOfType("storage.Document"), mock.AnythingOfType("[]float32")).Return(nil)
+	// We expect the VectorStore to write the chunks.
+	mockVectorStore.On("UpsertMany", mock.Anything, mock.AnythingOfType("[]storage.Document"), mock.AnythingOfType("[][]float32")).Return(nil)
 
 	// Execute the handler
-	env.StoreDocument(w, req)
+	env.StoreDocument(w, req, api.StoreDocumentParams{})
 
 	// 3. Assert
 	assert.Equal(t, http.StatusCreated, w.Code, "Expected HTTP status 201 Created")
@@ -60,6 +75,125 @@ func TestEnv_StoreDocument(t *testing.T) {
 	mockTextStore.AssertExpectations(t)
 }
 
+func TestEnv_StoreDocument_Metadata(t *testing.T) {
+	// 1. Arrange
+	mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+	mockVectorStore := new(storage_mocks.VectorStore)
+	mockTextStore := new(storage_mocks.TextStore)
+
+	env := &Env{Pipeline: newStorePipeline(mockEmbeddingClient, mockVectorStore, mockTextStore)}
+
+	body := `{"title": " Billing FAQ ", "text": "Invoices are emailed monthly.", "metadata": {"tag": "billing"}}`
+	req := httptest.NewRequest(http.MethodPost, "/store", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	hasTag := mock.MatchedBy(func(chunks []storage.Document) bool { return chunks[0].Metadata["tag"] == "billing" })
+	// Only the parent document carries the title.
+	hasTagAndTitle := mock.MatchedBy(func(docs []storage.Document) bool {
+		return docs[0].Metadata["tag"] == "billing" && docs[0].Title == "Billing FAQ"
+	})
+	mockTextStore.On("IndexMany", mock.Anything, hasTagAndTitle).Return(created, nil).Once()
+	mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.AnythingOfType("string")).Return([]float32{0.1}, nil)
+	mockVectorStore.On("UpsertMany", mock.Anything, hasTag, mock.Anything).Return(nil)
+
+	// 2. Act
+	env.StoreDocument(w, req, api.StoreDocumentParams{})
+
+	// 3. Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockTextStore.AssertExpectations(t)
+	mockVectorStore.AssertExpectations(t)
+}
+
+func TestEnv_StoreDocument_Failures(t *testing.T) {
+	// Long enough to be split into several chunks.
+	body := fmt.Sprintf(`{"text": %q}`, strings.Repeat("Invoices are emailed monthly. ", 40))
+	unavailable := errors.New("unavailable")
+
+	t.Run("AllOrNothingRemovesWrittenData", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockVectorStore := new(storage_mocks.VectorStore)
+		mockTextStore := new(storage_mocks.TextStore)
+		env := &Env{Pipeline: newStorePipeline(mockEmbeddingClient, mockVectorStore, mockTextStore)}
+		mockTextStore.On("IndexMany", mock.Anything, mock.Anything).Return(created, nil)
+		// The first chunk fails; the rest are written and must be removed again.
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.Anything).Return(nil, unavailable).Once()
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVectorStore.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		var parentDocID string
+		mockVectorStore.On("Delete", mock.Anything, mock.MatchedBy(func(ids []string) bool {
+			return len(ids) > 0 && strings.HasSuffix(ids[0], "#1")
+		})).Return(nil).Once()
+		mockTextStore.On("Delete", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { parentDocID = args.String(1) }).
+			Return(nil).Once()
+
+		// 2. Act
+		w := httptest.NewRecorder()
+		env.StoreDocument(w, httptest.NewRequest(http.MethodPost, "/store", strings.NewReader(body)), api.StoreDocumentParams{})
+
+		// 3. Assert
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "the document was not stored")
+		mockVectorStore.AssertExpectations(t)
+		mockTextStore.AssertExpectations(t)
+		deleted := mockVectorStore.Calls[len(mockVectorStore.Calls)-1].Arguments.Get(1).([]string)
+		assert.Equal(t, parentDocID+"#1", deleted[0])
+	})
+
+	t.Run("PartialListsFailedChunks", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockVectorStore := new(storage_mocks.VectorStore)
+		mockTextStore := new(storage_mocks.TextStore)
+		env := &Env{Pipeline: newStorePipeline(mockEmbeddingClient, mockVectorStore, mockTextStore, ingest.WithFailurePolicy(ingest.Partial))}
+		mockTextStore.On("IndexMany", mock.Anything, mock.Anything).Return(created, nil)
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.Anything).Return(nil, unavailable).Once()
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVectorStore.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		// 2. Act
+		w := httptest.NewRecorder()
+		env.StoreDocument(w, httptest.NewRequest(http.MethodPost, "/store", strings.NewReader(body)), api.StoreDocumentParams{})
+
+		// 3. Assert
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+		var resp api.StoreResponse
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		assert.Len(t, *resp.FailedChunks, 1)
+		assert.Equal(t, *resp.DocumentId+"#0", *(*resp.FailedChunks)[0].ChunkId)
+		assert.Equal(t, "failed to create embedding: unavailable", *(*resp.FailedChunks)[0].Error)
+		mockTextStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("IndexFailure", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockVectorStore := new(storage_mocks.VectorStore)
+		mockTextStore := new(storage_mocks.TextStore)
+		env := &Env{Pipeline: newStorePipeline(mockEmbeddingClient, mockVectorStore, mockTextStore)}
+		mockTextStore.On("IndexMany", mock.Anything, mock.Anything).Return(nil, unavailable)
+
+		// 2. Act
+		w := httptest.NewRecorder()
+		partial := api.Partial
+		env.StoreDocument(w, httptest.NewRequest(http.MethodPost, "/store", strings.NewReader(body)), api.StoreDocumentParams{FailurePolicy: &partial})
+
+		// 3. Assert
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockEmbeddingClient.AssertNotCalled(t, "CreateEmbedding", mock.Anything, mock.Anything)
+		mockVectorStore.AssertNotCalled(t, "UpsertMany", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		(&Env{}).StoreDocument(w, httptest.NewRequest(http.MethodPost, "/store", strings.NewReader(body)), api.StoreDocumentParams{})
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
 func TestEnv_QueryDocuments(t *testing.T) {
 	// 1. Arrange
 	mockSearchService := new(search_mocks.Service)
@@ -73,15 +207,21 @@ func TestEnv_QueryDocuments(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// Define the mock response from the search service
-	mockResults := []storage.Document{
-		{DocumentID: "doc-1", Text: "This is the first test document."},
+	mockResults := []ranking.Result{
+		{
+			Document: storage.Document{DocumentID: "doc-1", Text: "This is the first test document."},
+			Score:    0.5,
+			Explanation: []ranking.Contribution{
+				{Retriever: ranking.RetrieverLexical, Rank: 1, Score: 3.2, Contribution: 0.5},
+			},
+		},
 	}
 
 	// Define the API parameters
 	params := api.QueryDocumentsParams{Q: "test"}
 
 	// 2. Act: Set up the mock expectation
-	mockSearchService.On("Search", mock.Anything, "test", 5).Return(mockResults, nil)
+	mockSearchService.On("Search", mock.Anything, "test", search.Options{TopK: 5}).Return(&search.Response{Results: mockResults}, nil)
 
 	// Execute the handler
 	env.QueryDocuments(w, req, params)
@@ -89,13 +229,246 @@ func TestEnv_QueryDocuments(t *testing.T) {
 	// 3. Assert
 	assert.Equal(t, http.StatusOK, w.Code, "Expected HTTP status 200 OK")
 
-	var resp []api.Document
-	_ = json.NewDecoder(w.Body).Decode(&resp)
+	var body api.SearchResponse
+	_ = json.NewDecoder(w.Body).Decode(&body)
+	resp := *body.Results
 	assert.Len(t, resp, 1, "Expected one document in the response")
 	assert.Equal(t, "doc-1", *resp[0].DocumentId)
 	assert.Equal(t, "This is the first test document.", *resp[0].Text)
+	assert.Equal(t, 0.5, *resp[0].Score)
+	assert.Nil(t, resp[0].Explanation, "Explanations should only be returned when requested")
 
 	// Verify that the mock expectations were met
 	mockSearchService.AssertExpectations(t)
 }
 
+func TestEnv_QueryDocuments_Explain(t *testing.T) {
+	mockSearchService := new(search_mocks.Service)
+	env := &Env{SearchService: mockSearchService}
+
+	mockResults := []ranking.Result{
+		{
+			Document: storage.Document{DocumentID: "doc-1"},
+			Score:    0.03,
+			Explanation: []ranking.Contribution{
+				{Retriever: ranking.RetrieverLexical, Rank: 2, Score: 3.2, Contribution: 0.01},
+				{Retriever: ranking.RetrieverSemantic, Rank: 1, Score: 0.9, Contribution: 0.02},
+			},
+		},
+	}
+	mockSearchService.On("Search", mock.Anything, "test", search.Options{TopK: 5}).Return(&search.Response{Results: mockResults}, nil)
+
+	explain := true
+	w := httptest.NewRecorder()
+	env.QueryDocuments(w, httptest.NewRequest(http.MethodGet, "/query?q=test&explain=true", nil), api.QueryDocumentsParams{Q: "test", Explain: &explain})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body api.SearchResponse
+	_ = json.NewDecoder(w.Body).Decode(&body)
+	resp := *body.Results
+	assert.Len(t, resp, 1)
+	if assert.NotNil(t, resp[0].Explanation) {
+		explanation := *resp[0].Explanation
+		assert.Len(t, explanation, 2)
+		assert.Equal(t, ranking.RetrieverSemantic, *explanation[1].Retriever)
+		assert.Equal(t, 1, *explanation[1].Rank)
+		assert.Equal(t, 0.9, *explanation[1].Score)
+		assert.Equal(t, 0.02, *explanation[1].Contribution)
+	}
+}
+
+func TestEnv_QueryDocuments_FusionOverrides(t *testing.T) {
+	t.Run("PassesOverridesToService", func(t *testing.T) {
+		mockSearchService := new(search_mocks.Service)
+		env := &Env{SearchService: mockSearchService}
+
+		k, lexical := 10.0, 2.0
+		strategy := api.Zscore
+		params := api.QueryDocumentsParams{Q: "test", Fusion: &strategy, RrfK: &k, LexicalWeight: &lexical}
+		expected := search.Options{
+			TopK: 5,
+			Fusion: ranking.FusionConfig{
+				Strategy: ranking.StrategyZScore,
+				K:        10,
+				Weights:  map[string]float64{ranking.RetrieverLexical: 2},
+			},
+		}
+		mockSearchService.On("Search", mock.Anything, "test", expected).Return(&search.Response{}, nil)
+
+		w := httptest.NewRecorder()
+		env.QueryDocuments(w, httptest.NewRequest(http.MethodGet, "/query?q=test&fusion=zscore&rrf_k=10&lexical_weight=2", nil), params)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockSearchService.AssertExpectations(t)
+	})
+
+	t.Run("RejectsInvalidValues", func(t *testing.T) {
+		mockSearchService := new(search_mocks.Service)
+		env := &Env{SearchService: mockSearchService}
+
+		zero, negative, tooLarge, noResults := 0.0, -1.0, 1.5, 0
+		unknown := api.QueryDocumentsParamsFusion("unknown")
+		for _, params := range []api.QueryDocumentsParams{
+			{Q: "test", RrfK: &zero},
+			{Q: "test", SemanticWeight: &negative},
+			{Q: "test", Fusion: &unknown},
+			{Q: "test", MmrLambda: &tooLarge},
+			{Q: "test", MaxPerParent: &noResults},
+		} {
+			w := httptest.NewRecorder()
+			env.QueryDocuments(w, httptest.NewRequest(http.MethodGet, "/query?q=test", nil), params)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		}
+		mockSearchService.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestEnv_QueryDocuments_Diversification(t *testing.T) {
+	mockSearchService := new(search_mocks.Service)
+	env := &Env{SearchService: mockSearchService}
+
+	lambda, maxPerParent := 0.7, 2
+	params := api.QueryDocumentsParams{Q: "test", MmrLambda: &lambda, MaxPerParent: &maxPerParent}
+	expected := search.Options{TopK: 5, MMRLambda: &lambda, MaxPerParent: 2}
+	mockSearchService.On("Search", mock.Anything, "test", expected).Return(&search.Response{}, nil)
+
+	w := httptest.NewRecorder()
+	env.QueryDocuments(w, httptest.NewRequest(http.MethodGet, "/query?q=test&mmr_lambda=0.7&max_per_parent=2", nil), params)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSearchService.AssertExpectations(t)
+}
+
+func TestEnv_QueryDocuments_Reranker(t *testing.T) {
+	t.Run("ReturnsRerankScore", func(t *testing.T) {
+		mockSearchService := new(search_mocks.Service)
+		env := &Env{SearchService: mockSearchService}
+
+		rerankScore := 0.97
+		mockResults := []ranking.Result{
+			{Document: storage.Document{DocumentID: "doc-1"}, Score: 0.03, RerankScore: &rerankScore},
+		}
+		reranker := "http"
+		mockSearchService.On("Search", mock.Anything, "test", search.Options{TopK: 5, Reranker: "http"}).Return(&search.Response{Results: mockResults}, nil)
+
+		w := httptest.NewRecorder()
+		env.QueryDocuments(w, httptest.NewRequest(http.MethodGet, "/query?q=test&reranker=http", nil), api.QueryDocumentsParams{Q: "test", Reranker: &reranker})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var body api.SearchResponse
+		_ = json.NewDecoder(w.Body).Decode(&body)
+		resp := *body.Results
+		assert.Equal(t, 0.97, *resp[0].RerankScore)
+	})
+
+	t.Run("UnknownRerankerIsBadRequest", func(t *testing.T) {
+		mockSearchService := new(search_mocks.Service)
+		env := &Env{SearchService: mockSearchService}
+
+		reranker := "missing"
+		mockSearchService.On("Search", mock.Anything, "test", search.Options{TopK: 5, Reranker: "missing"}).
+			Return(nil, fmt.Errorf("%w: %q", search.ErrUnknownReranker, "missing"))
+
+		w := httptest.NewRecorder()
+		env.QueryDocuments(w, httptest.NewRequest(http.MethodGet, "/query?q=test&reranker=missing", nil), api.QueryDocumentsParams{Q: "test", Reranker: &reranker})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("MMRWithoutVectorsIsBadRequest", func(t *testing.T) {
+		mockSearchService := new(search_mocks.Service)
+		env := &Env{SearchService: mockSearchService}
+
+		lambda := 0.5
+		mockSearchService.On("Search", mock.Anything, "test", search.Options{TopK: 5, MMRLambda: &lambda}).
+			Return(nil, search.ErrNoVectors)
+
+		w := httptest.NewRecorder()
+		env.QueryDocuments(w, httptest.NewRequest(http.MethodGet, "/query?q=test&mmr_lambda=0.5", nil), api.QueryDocumentsParams{Q: "test", MmrLambda: &lambda})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestEnv_QueryDocuments_Rewrite(t *testing.T) {
+	t.Run("PassesRewriter", func(t *testing.T) {
+		mockSearchService := new(search_mocks.Service)
+		env := &Env{SearchService: mockSearchService}
+
+		rewrite := "hyde"
+		mockSearchService.On("Search", mock.Anything, "test", search.Options{TopK: 5, Rewriter: "hyde"}).Return(&search.Response{}, nil)
+
+		w := httptest.NewRecorder()
+		env.QueryDocuments(w, httptest.NewRequest(http.MethodGet, "/query?q=test&rewrite=hyde", nil), api.QueryDocumentsParams{Q: "test", Rewrite: &rewrite})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockSearchService.AssertExpectations(t)
+	})
+
+	t.Run("UnknownRewriterIsBadRequest", func(t *testing.T) {
+		mockSearchService := new(search_mocks.Service)
+		env := &Env{SearchService: mockSearchService}
+
+		rewrite := "missing"
+		mockSearchService.On("Search", mock.Anything, "test", search.Options{TopK: 5, Rewriter: "missing"}).
+			Return(nil, fmt.Errorf("%w: %q", search.ErrUnknownRewriter, "missing"))
+
+		w := httptest.NewRecorder()
+		env.QueryDocuments(w, httptest.NewRequest(http.MethodGet, "/query?q=test&rewrite=missing", nil), api.QueryDocumentsParams{Q: "test", Rewrite: &rewrite})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestEnv_QueryDocuments_Suggestions(t *testing.T) {
+	mockSearchService := new(search_mocks.Service)
+	mockAutocompleter := new(storage_mocks.Autocompleter)
+	env := &Env{SearchService: mockSearchService, QueryLog: autocomplete.NewQueryLog(mockAutocompleter, 1)}
+
+	autocorrect := true
+	mockResults := []ranking.Result{{Document: storage.Document{DocumentID: "doc-1"}, Score: 0.5}}
+	mockSearchService.On("Search", mock.Anything, "pasword", search.Options{TopK: 5, Autocorrect: true}).Return(&search.Response{
+		Results:        mockResults,
+		Suggestions:    []string{"password", "passport"},
+		CorrectedQuery: "password",
+	}, nil)
+
+	w := httptest.NewRecorder()
+	env.QueryDocuments(w, httptest.NewRequest(http.MethodGet, "/query?q=pasword&autocorrect=true", nil), api.QueryDocumentsParams{Q: "pasword", Autocorrect: &autocorrect})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"password", "passport"}, w.Header().Values("X-Suggested-Query"))
+	assert.Equal(t, "password", w.Header().Get("X-Corrected-Query"))
+	var resp api.SearchResponse
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	assert.Len(t, *resp.Results, 1)
+	assert.Equal(t, []string{"password", "passport"}, *resp.Suggestions)
+	assert.Equal(t, "password", *resp.CorrectedQuery)
+
+	// The corrected query is what gets counted for autocomplete.
+	mockAutocompleter.On("RecordQueries", mock.Anything, map[string]int{"password": 1}).Return(nil)
+	assert.NoError(t, env.QueryLog.Flush(context.Background()))
+	mockAutocompleter.AssertExpectations(t)
+}
+
+func TestEnv_QueryDocuments_Highlight(t *testing.T) {
+	mockSearchService := new(search_mocks.Service)
+	env := &Env{SearchService: mockSearchService}
+
+	highlight, preTag, postTag, fragmentSize := true, "<b>", "</b>", 80
+	params := api.QueryDocumentsParams{Q: "test", Highlight: &highlight, HighlightPreTag: &preTag, HighlightPostTag: &postTag, FragmentSize: &fragmentSize}
+	expected := search.Options{TopK: 5, Highlight: &storage.Highlight{PreTag: "<b>", PostTag: "</b>", FragmentSize: 80}}
+	mockResults := []ranking.Result{{Document: storage.Document{DocumentID: "doc-1"}, Highlights: []string{"a <b>test</b>"}}}
+	mockSearchService.On("Search", mock.Anything, "test", expected).Return(&search.Response{Results: mockResults}, nil)
+
+	w := httptest.NewRecorder()
+	env.QueryDocuments(w, httptest.NewRequest(http.MethodGet, "/query?q=test&highlight=true", nil), params)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body api.SearchResponse
+	_ = json.NewDecoder(w.Body).Decode(&body)
+	resp := *body.Results
+	assert.Equal(t, []string{"a <b>test</b>"}, *resp[0].Highlights)
+	mockSearchService.AssertExpectations(t)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/pkg/jobs"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// submitJob queues doc for background ingestion and responds with 202 and the job.
+func (env *Env) submitJob(w http.ResponseWriter, doc storage.Document) {
+	if env.Jobs == nil {
+		msg := "Background ingestion is not configured"
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	job, err := env.Jobs.Submit(doc)
+	if errors.Is(err, jobs.ErrQueueFull) {
+		msg := "The ingestion queue is full; try again later"
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+	if err != nil {
+		msg := "Failed to queue document"
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		log.Printf("Failed to queue document: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(toAPIJob(job))
+}
+
+// GetJob handles the GET /jobs/{id} endpoint.
+func (env *Env) GetJob(w http.ResponseWriter, r *http.Request, id string) {
+	if env.Jobs == nil {
+		msg := "Background ingestion is not configured"
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	job, ok := env.Jobs.Get(id)
+	if !ok {
+		msg := "Job not found"
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toAPIJob(job))
+}
+
+// toAPIJob converts a job to its API form.
+func toAPIJob(job jobs.Job) api.Job {
+	id, docID, status := job.ID, job.Document.DocumentID, api.JobStatus(job.Status)
+	progress := job.Progress
+	createdAt, updatedAt := job.CreatedAt, job.UpdatedAt
+	apiJob := api.Job{
+		Id:             &id,
+		Status:         &status,
+		DocumentId:     &docID,
+		ChunksTotal:    &progress.Total,
+		ChunksEmbedded: &progress.Embedded,
+		ChunksWritten:  &progress.Written,
+		ChunksFailed:   &progress.Failed,
+		CreatedAt:      &createdAt,
+		UpdatedAt:      &updatedAt,
+	}
+	if job.Error != "" {
+		reason := job.Error
+		apiJob.Error = &reason
+	}
+	return apiJob
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/pkg/jobs"
+	jobs_mocks "github.com/chr1sbest/hybrid-search/pkg/jobs/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnv_StoreDocument_Async(t *testing.T) {
+	t.Run("QueuesJob", func(t *testing.T) {
+		// 1. Arrange
+		store, err := jobs.NewFileStore(t.TempDir())
+		assert.NoError(t, err)
+		// The manager isn't run, so the job stays pending.
+		manager, err := jobs.NewManager(store, new(jobs_mocks.Processor), 1, 10, 0)
+		assert.NoError(t, err)
+		env := &Env{Jobs: manager}
+		async := true
+
+		// 2. Act
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/store?async=true", strings.NewReader(`{"title": "FAQ", "text": "Invoices are emailed monthly."}`))
+		env.StoreDocument(w, req, api.StoreDocumentParams{Async: &async})
+
+		// 3. Assert
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		var job api.Job
+		_ = json.NewDecoder(w.Body).Decode(&job)
+		assert.Equal(t, "/jobs/"+*job.Id, w.Header().Get("Location"))
+		assert.Equal(t, api.JobStatusPending, *job.Status)
+		assert.NotEmpty(t, *job.DocumentId)
+
+		queued, ok := manager.Get(*job.Id)
+		assert.True(t, ok)
+		assert.Equal(t, "FAQ", queued.Document.Title)
+		assert.Equal(t, "Invoices are emailed monthly.", queued.Document.Text)
+
+		w = httptest.NewRecorder()
+		env.GetJob(w, httptest.NewRequest(http.MethodGet, "/jobs/"+*job.Id, nil), *job.Id)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("QueueFull", func(t *testing.T) {
+		store, err := jobs.NewFileStore(t.TempDir())
+		assert.NoError(t, err)
+		manager, err := jobs.NewManager(store, new(jobs_mocks.Processor), 1, 1, 0)
+		assert.NoError(t, err)
+		env := &Env{Jobs: manager}
+		async := true
+
+		var codes []int
+		for range 2 {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/store?async=true", strings.NewReader(`{"text": "Invoices are emailed monthly."}`))
+			env.StoreDocument(w, req, api.StoreDocumentParams{Async: &async})
+			codes = append(codes, w.Code)
+		}
+
+		assert.Equal(t, []int{http.StatusAccepted, http.StatusServiceUnavailable}, codes)
+	})
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		async := true
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/store?async=true", strings.NewReader(`{"text": "Invoices are emailed monthly."}`))
+		(&Env{}).StoreDocument(w, req, api.StoreDocumentParams{Async: &async})
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("RejectsFailurePolicy", func(t *testing.T) {
+		async, partial := true, api.Partial
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/store?async=true&failure_policy=partial", strings.NewReader(`{"text": "Invoices are emailed monthly."}`))
+		(&Env{}).StoreDocument(w, req, api.StoreDocumentParams{Async: &async, FailurePolicy: &partial})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestEnv_GetJob(t *testing.T) {
+	t.Run("NotFound", func(t *testing.T) {
+		store, err := jobs.NewFileStore(t.TempDir())
+		assert.NoError(t, err)
+		manager, err := jobs.NewManager(store, new(jobs_mocks.Processor), 1, 10, 0)
+		assert.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		(&Env{Jobs: manager}).GetJob(w, httptest.NewRequest(http.MethodGet, "/jobs/missing", nil), "missing")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		(&Env{}).GetJob(w, httptest.NewRequest(http.MethodGet, "/jobs/j1", nil), "j1")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// storeThroughOutbox records doc in the outbox and then tries to write it to every store. It
+// responds with 201 when every store acknowledged the document, and with 202 and the pending
+// stores when the outbox will retry some of them.
+func (env *Env) storeThroughOutbox(w http.ResponseWriter, r *http.Request, doc storage.Document) {
+	if err := env.Outbox.Record(doc); err != nil {
+		msg := "Failed to record document"
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		log.Printf("Failed to record document %s in the outbox: %v", doc.DocumentID, err)
+		return
+	}
+	// The outbox writes the document eventually, so it is versioned once it is recorded.
+	env.recordFirstVersion(doc)
+
+	pending, err := env.Outbox.Apply(r.Context(), doc.DocumentID)
+	docID := doc.DocumentID
+	w.Header().Set("Content-Type", "application/json")
+	if len(pending) == 0 {
+		msg := "Document chunked and stored successfully"
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(api.StoreResponse{Message: &msg, DocumentId: &docID})
+		return
+	}
+
+	if err != nil {
+		log.Printf("Writes of document %s are pending and will be retried: %v", docID, err)
+	}
+	stores := make([]api.StoreResponsePendingStores, len(pending))
+	for i, target := range pending {
+		stores[i] = api.StoreResponsePendingStores(target)
+	}
+	msg := "Document recorded; writes to some stores failed and will be retried"
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(api.StoreResponse{Message: &msg, DocumentId: &docID, PendingStores: &stores})
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	embedding_mocks "github.com/chr1sbest/hybrid-search/pkg/embeddings/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/outbox"
+	"github.com/chr1sbest/hybrid-search/pkg/retry"
+	storage_mocks "github.com/chr1sbest/hybrid-search/pkg/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEnv_StoreDocument_Outbox(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		upsertErr error
+		code      int
+		pending   []api.StoreResponsePendingStores
+	}{
+		{name: "Stored", code: http.StatusCreated},
+		{name: "Pending", upsertErr: errors.New("unavailable"), code: http.StatusAccepted, pending: []api.StoreResponsePendingStores{api.Vector}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Arrange
+			mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+			mockVectorStore := new(storage_mocks.VectorStore)
+			mockTextStore := new(storage_mocks.TextStore)
+			mockTextStore.On("IndexMany", mock.Anything, mock.Anything).Return(created, nil)
+			mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+			mockVectorStore.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(tc.upsertErr)
+
+			journal, err := outbox.NewFileJournal(t.TempDir())
+			assert.NoError(t, err)
+			box, err := outbox.New(journal, newStorePipeline(mockEmbeddingClient, mockVectorStore, mockTextStore), retry.Policy{})
+			assert.NoError(t, err)
+			env := &Env{Outbox: box}
+
+			// 2. Act
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/store", strings.NewReader(`{"text": "Invoices are emailed monthly."}`))
+			env.StoreDocument(w, req, api.StoreDocumentParams{})
+
+			// 3. Assert
+			assert.Equal(t, tc.code, w.Code)
+			var resp api.StoreResponse
+			_ = json.NewDecoder(w.Body).Decode(&resp)
+			assert.NotEmpty(t, *resp.DocumentId)
+			if tc.pending == nil {
+				assert.Nil(t, resp.PendingStores)
+				assert.Equal(t, 0, box.Len())
+			} else {
+				assert.Equal(t, tc.pending, *resp.PendingStores)
+				assert.Equal(t, 1, box.Len())
+			}
+			mockVectorStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/chr1sbest/hybrid-search/api"
+)
+
+// defaultSuggestSize is the number of completions returned by /suggest when size is not set.
+const defaultSuggestSize = 5
+
+// maxSuggestSize is the largest size /suggest accepts.
+const maxSuggestSize = 20
+
+// SuggestCompletions handles the GET /suggest endpoint.
+func (env *Env) SuggestCompletions(w http.ResponseWriter, r *http.Request, params api.SuggestCompletionsParams) {
+	if env.Autocompleter == nil {
+		msg := "Autocomplete is not supported by the configured text store"
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	prefix := strings.TrimSpace(params.Prefix)
+	if prefix == "" {
+		msg := "'prefix' query parameter cannot be empty"
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+	size := defaultSuggestSize
+	if params.Size != nil {
+		if *params.Size < 1 || *params.Size > maxSuggestSize {
+			msg := "'size' must be between 1 and 20"
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(api.Error{Message: &msg})
+			return
+		}
+		size = *params.Size
+	}
+
+	suggestions, err := env.Autocompleter.Autocomplete(r.Context(), prefix, size)
+	if err != nil {
+		msg := "Failed to get suggestions"
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		log.Printf("Failed to get suggestions: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(api.SuggestResponse{Suggestions: &suggestions})
+}
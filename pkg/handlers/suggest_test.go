@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	storage_mocks "github.com/chr1sbest/hybrid-search/pkg/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEnv_SuggestCompletions(t *testing.T) {
+	t.Run("ReturnsCompletions", func(t *testing.T) {
+		// 1. Arrange
+		mockAutocompleter := new(storage_mocks.Autocompleter)
+		mockAutocompleter.On("Autocomplete", mock.Anything, "res", defaultSuggestSize).Return([]string{"reset password", "Resetting your account"}, nil)
+		env := &Env{Autocompleter: mockAutocompleter}
+
+		// 2. Act
+		w := httptest.NewRecorder()
+		env.SuggestCompletions(w, httptest.NewRequest(http.MethodGet, "/suggest?prefix=res", nil), api.SuggestCompletionsParams{Prefix: " res "})
+
+		// 3. Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp api.SuggestResponse
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		assert.Equal(t, []string{"reset password", "Resetting your account"}, *resp.Suggestions)
+	})
+
+	t.Run("InvalidParams", func(t *testing.T) {
+		zero, tooMany := 0, maxSuggestSize+1
+		for _, params := range []api.SuggestCompletionsParams{
+			{Prefix: " "},
+			{Prefix: "res", Size: &zero},
+			{Prefix: "res", Size: &tooMany},
+		} {
+			mockAutocompleter := new(storage_mocks.Autocompleter)
+			env := &Env{Autocompleter: mockAutocompleter}
+
+			w := httptest.NewRecorder()
+			env.SuggestCompletions(w, httptest.NewRequest(http.MethodGet, "/suggest", nil), params)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockAutocompleter.AssertNotCalled(t, "Autocomplete", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("StoreError", func(t *testing.T) {
+		mockAutocompleter := new(storage_mocks.Autocompleter)
+		mockAutocompleter.On("Autocomplete", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("down"))
+		env := &Env{Autocompleter: mockAutocompleter}
+
+		w := httptest.NewRecorder()
+		env.SuggestCompletions(w, httptest.NewRequest(http.MethodGet, "/suggest?prefix=res", nil), api.SuggestCompletionsParams{Prefix: "res"})
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("NotSupported", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		(&Env{}).SuggestCompletions(w, httptest.NewRequest(http.MethodGet, "/suggest?prefix=res", nil), api.SuggestCompletionsParams{Prefix: "res"})
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/pkg/chunker"
+	"github.com/chr1sbest/hybrid-search/pkg/extract"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// DefaultMaxUploadBytes is the largest request /documents/upload accepts when
+// Env.MaxUploadBytes is not set.
+const DefaultMaxUploadBytes = 32 << 20
+
+// uploadMemory is the part of a multipart form held in memory; the rest is spooled to disk.
+const uploadMemory = 8 << 20
+
+// The metadata keys set on uploaded documents.
+const (
+	filenameKey  = "filename"
+	mimeTypeKey  = "mime_type"
+	pageCountKey = "page_count"
+)
+
+// UploadDocument handles the POST /documents/upload endpoint. The file's text is extracted
+// and stored as with /store. The pages of a paged file are chunked one by one, so each chunk
+// records its page, even in a file with a single page.
+func (env *Env) UploadDocument(w http.ResponseWriter, r *http.Request, params api.UploadDocumentParams) {
+	maxBytes := env.MaxUploadBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxUploadBytes
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	if err := r.ParseMultipartForm(uploadMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			msg := fmt.Sprintf("Uploads are limited to %d bytes", maxBytes)
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(api.Error{Message: &msg})
+			return
+		}
+		msg := "Invalid multipart form"
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		msg := "'file' is required"
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		msg := "Failed to read the uploaded file"
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	metadata := make(map[string]string)
+	if raw := r.FormValue("metadata"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+			msg := "'metadata' must be a JSON object of strings"
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(api.Error{Message: &msg})
+			return
+		}
+	}
+
+	filename := uploadFilename(header.Filename)
+	mimeType := extract.Detect(filename, header.Header.Get("Content-Type"), data)
+	doc, err := extract.Extract(mimeType, data)
+	if errors.Is(err, extract.ErrUnsupported) {
+		msg := fmt.Sprintf("Unsupported content type %q", mimeType)
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to extract text from %s (%s): %v", filename, mimeType, err)
+		msg := "Failed to extract text from the file"
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	text := doc.Text
+	var pageStarts []int
+	if doc.Pages != nil {
+		text, pageStarts = chunker.JoinPages(doc.Pages)
+		metadata[pageCountKey] = strconv.Itoa(len(doc.Pages))
+	}
+	if strings.TrimSpace(text) == "" {
+		msg := "No text found in the file"
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+	metadata[filenameKey] = filename
+	metadata[mimeTypeKey] = mimeType
+
+	title := strings.TrimSpace(r.FormValue("title"))
+	if title == "" {
+		title = doc.Title
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filename, path.Ext(filename))
+	}
+
+	parentDoc := storage.Document{
+		DocumentID: uuid.New().String(),
+		Title:      title,
+		Text:       text,
+		Metadata:   metadata,
+		PageStarts: pageStarts,
+	}
+	env.storeDocument(w, r, parentDoc, params.Async != nil && *params.Async, params.FailurePolicy)
+}
+
+// uploadFilename returns the base name of an uploaded file. Some clients send the full path,
+// with either kind of separator.
+func uploadFilename(name string) string {
+	return name[strings.LastIndexAny(name, `/\`)+1:]
+}
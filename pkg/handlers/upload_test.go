@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	embedding_mocks "github.com/chr1sbest/hybrid-search/pkg/embeddings/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	storage_mocks "github.com/chr1sbest/hybrid-search/pkg/storage/mocks"
+	"github.com/pinecone-io/go-pinecone/v4/pinecone"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newUploadRequest returns a /documents/upload request with the given file and form fields.
+func newUploadRequest(t *testing.T, filename string, content []byte, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if filename != "" {
+		part, err := form.CreateFormFile("file", filename)
+		assert.NoError(t, err)
+		_, _ = part.Write(content)
+	}
+	for name, value := range fields {
+		assert.NoError(t, form.WriteField(name, value))
+	}
+	assert.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/documents/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestEnv_UploadDocument(t *testing.T) {
+	t.Run("StoresExtractedText", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockVectorStore := new(storage_mocks.VectorStore)
+		mockTextStore := new(storage_mocks.TextStore)
+		env := &Env{Pipeline: newStorePipeline(mockEmbeddingClient, mockVectorStore, mockTextStore)}
+		html := `<html><head><title>Billing FAQ</title></head><body><nav>Home</nav><main><p>Invoices are emailed monthly.</p></main></body></html>`
+		mockTextStore.On("IndexMany", mock.Anything, mock.MatchedBy(func(docs []storage.Document) bool {
+			return docs[0].Title == "Billing FAQ" && docs[0].Text == "Invoices are emailed monthly." &&
+				assert.ObjectsAreEqual(map[string]string{"tag": "billing", "filename": "faq.html", "mime_type": "text/html"}, docs[0].Metadata)
+		})).Return(created, nil)
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, "Invoices are emailed monthly.").Return([]float32{0.1}, nil)
+		mockVectorStore.On("UpsertMany", mock.Anything, mock.Anything, [][]float32{{0.1}}).Return(nil)
+
+		// 2. Act
+		w := httptest.NewRecorder()
+		env.UploadDocument(w, newUploadRequest(t, `C:\docs\faq.html`, []byte(html), map[string]string{"metadata": `{"tag": "billing"}`}), api.UploadDocumentParams{})
+
+		// 3. Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockTextStore.AssertExpectations(t)
+		mockVectorStore.AssertExpectations(t)
+	})
+
+	t.Run("CarriesPagesIntoChunks", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockVectorStore := new(storage_mocks.VectorStore)
+		mockTextStore := new(storage_mocks.TextStore)
+		env := &Env{Pipeline: newStorePipeline(mockEmbeddingClient, mockVectorStore, mockTextStore)}
+		mockTextStore.On("IndexMany", mock.Anything, mock.Anything).Return(created, nil)
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		var chunks []storage.Document
+		mockVectorStore.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { chunks = append(chunks, args.Get(1).([]storage.Document)...) }).
+			Return(nil)
+		pdf, err := os.ReadFile("testdata/two-pages.pdf")
+		assert.NoError(t, err)
+
+		// 2. Act
+		w := httptest.NewRecorder()
+		env.UploadDocument(w, newUploadRequest(t, "guide.pdf", pdf, map[string]string{"title": "User guide"}), api.UploadDocumentParams{})
+
+		// 3. Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Len(t, chunks, 2)
+		assert.Equal(t, map[string]string{"filename": "guide.pdf", "mime_type": "application/pdf", "page_count": "2", "page": "1"}, chunks[0].Metadata)
+		assert.Equal(t, "Page two.", chunks[1].Text)
+		assert.Equal(t, "2", chunks[1].Metadata["page"])
+		indexed := mockTextStore.Calls[0].Arguments.Get(1).([]storage.Document)
+		assert.Equal(t, "User guide", indexed[0].Title)
+		// The stored text is clean; the pages are recorded by where they start.
+		assert.Equal(t, "Page one.\n\nPage two.", indexed[0].Text)
+		assert.Equal(t, []int{0, 11}, indexed[0].PageStarts)
+	})
+
+	t.Run("SinglePagePDFRecordsPage", func(t *testing.T) {
+		// 1. Arrange
+		// A fake Pinecone data plane captures the records the vector store sends.
+		var records []map[string]string
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/records/namespaces/ns1/upsert", r.URL.Path)
+			decoder := json.NewDecoder(r.Body)
+			for decoder.More() {
+				var record map[string]string
+				assert.NoError(t, decoder.Decode(&record))
+				records = append(records, record)
+			}
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer server.Close()
+		pc, err := pinecone.NewClient(pinecone.NewClientParams{ApiKey: "test", RestClient: server.Client()})
+		assert.NoError(t, err)
+		idxConn, err := pc.Index(pinecone.NewIndexConnParams{Host: strings.TrimPrefix(server.URL, "https://"), Namespace: "ns1"})
+		assert.NoError(t, err)
+		defer idxConn.Close()
+
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockTextStore := new(storage_mocks.TextStore)
+		env := &Env{Pipeline: newStorePipeline(mockEmbeddingClient, storage.NewPineconeIndexClient(idxConn), mockTextStore)}
+		mockTextStore.On("IndexMany", mock.Anything, mock.Anything).Return(created, nil)
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		pdf, err := os.ReadFile("testdata/single-page.pdf")
+		assert.NoError(t, err)
+
+		// 2. Act
+		w := httptest.NewRecorder()
+		env.UploadDocument(w, newUploadRequest(t, "refunds.pdf", pdf, nil), api.UploadDocumentParams{})
+
+		// 3. Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Len(t, records, 1)
+		if len(records) == 1 {
+			assert.Equal(t, "1", records[0]["page"])
+			assert.Equal(t, "1", records[0]["page_count"])
+			assert.Equal(t, "Refunds take five days.", records[0]["chunk_text"])
+		}
+	})
+
+	testCases := []struct {
+		name     string
+		filename string
+		content  []byte
+		fields   map[string]string
+		expected int
+	}{
+		{"MissingFile", "", nil, nil, http.StatusBadRequest},
+		{"InvalidMetadata", "notes.txt", []byte("Notes."), map[string]string{"metadata": `["tag"]`}, http.StatusBadRequest},
+		{"Unsupported", "logo.png", []byte("\x89PNG\r\n\x1a\n"), nil, http.StatusUnsupportedMediaType},
+		{"DamagedPDF", "report.pdf", []byte("%PDF-1.4\ngarbage"), nil, http.StatusUnprocessableEntity},
+		{"NoText", "empty.md", []byte("  \n"), nil, http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&Env{}).UploadDocument(w, newUploadRequest(t, tc.filename, tc.content, tc.fields), api.UploadDocumentParams{})
+
+			assert.Equal(t, tc.expected, w.Code)
+			var resp api.Error
+			_ = json.NewDecoder(w.Body).Decode(&resp)
+			assert.NotEmpty(t, *resp.Message)
+			if tc.expected == http.StatusUnprocessableEntity {
+				// Extractor errors are logged, not sent to the client.
+				assert.Equal(t, "Failed to extract text from the file", *resp.Message)
+			}
+		})
+	}
+
+	t.Run("TooLarge", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		(&Env{MaxUploadBytes: 64}).UploadDocument(w, newUploadRequest(t, "notes.txt", bytes.Repeat([]byte("a"), 1024), nil), api.UploadDocumentParams{})
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/chr1sbest/hybrid-search/pkg/versions"
+)
+
+// restoreTimeout bounds the time spent storing the current version again after a failed write.
+const restoreTimeout = 30 * time.Second
+
+// PutDocument handles the PUT /documents/{id} endpoint. The document is stored with the
+// ingest service and recorded as its next version.
+func (env *Env) PutDocument(w http.ResponseWriter, r *http.Request, id string, params api.PutDocumentParams) {
+	if !env.versioningConfigured(w) {
+		return
+	}
+
+	var req api.StoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := "Invalid request body"
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+	var reason string
+	switch {
+	case strings.TrimSpace(id) == "":
+		reason = "The document ID cannot be empty"
+	case strings.Contains(id, "#"):
+		// Chunk IDs are the parent ID followed by "#" and the chunk number.
+		reason = "The document ID cannot contain '#'"
+	case req.Text == "":
+		reason = "'text' field cannot be empty"
+	}
+	if reason != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &reason})
+		return
+	}
+
+	doc := storage.Document{DocumentID: id, Text: req.Text}
+	if req.Title != nil {
+		doc.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Metadata != nil {
+		doc.Metadata = *req.Metadata
+	}
+
+	unlock := env.History.Lock(id)
+	defer unlock()
+	env.writeVersion(w, r, doc, params.IfMatch, 0)
+}
+
+// RollbackDocument handles the POST /documents/{id}/rollback endpoint. The earlier version is
+// stored again and recorded as a new version, so the history is never rewritten.
+func (env *Env) RollbackDocument(w http.ResponseWriter, r *http.Request, id string, params api.RollbackDocumentParams) {
+	if !env.versioningConfigured(w) {
+		return
+	}
+
+	var req api.RollbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := "Invalid request body"
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	unlock := env.History.Lock(id)
+	defer unlock()
+	target, err := env.History.Get(id, req.Version)
+	if errors.Is(err, versions.ErrNotFound) {
+		msg := fmt.Sprintf("Document %s has no version %d", id, req.Version)
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+	if err != nil {
+		msg := "Failed to read document versions"
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		log.Printf("Failed to read versions of document %s: %v", id, err)
+		return
+	}
+	env.writeVersion(w, r, target.Document(id), params.IfMatch, target.Version)
+}
+
+// ListDocumentVersions handles the GET /documents/{id}/versions endpoint.
+func (env *Env) ListDocumentVersions(w http.ResponseWriter, r *http.Request, id string) {
+	if env.History == nil {
+		msg := "Document versioning is not configured"
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	history, err := env.History.List(id)
+	if err != nil {
+		msg := "Failed to read document versions"
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		log.Printf("Failed to read versions of document %s: %v", id, err)
+		return
+	}
+	if len(history) == 0 {
+		msg := "Document has no recorded versions"
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	apiVersions := make([]api.DocumentVersion, len(history))
+	for i, v := range history {
+		apiVersions[i] = toAPIVersion(v)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(versions.Current(history)))
+	json.NewEncoder(w).Encode(api.DocumentVersionList{DocumentId: &id, Versions: &apiVersions})
+}
+
+// versioningConfigured responds with 503 and returns false unless the history and the ingest
+// service are both set.
+func (env *Env) versioningConfigured(w http.ResponseWriter) bool {
+	if env.History != nil && env.IngestService != nil {
+		return true
+	}
+	msg := "Document versioning is not configured"
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(api.Error{Message: &msg})
+	return false
+}
+
+// writeVersion checks ifMatch against the document's current version, stores doc and records
+// it as the next version, then writes the response. restoredFrom is the version a rollback
+// copies, or zero. The caller must hold the document's lock; the ingest service is told so,
+// and leaves the version to be recorded here.
+func (env *Env) writeVersion(w http.ResponseWriter, r *http.Request, doc storage.Document, ifMatch *string, restoredFrom int) {
+	ctx := versions.WithLock(r.Context(), doc.DocumentID)
+	history, err := env.History.List(doc.DocumentID)
+	if err != nil {
+		msg := "Failed to read document versions"
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		log.Printf("Failed to read versions of document %s: %v", doc.DocumentID, err)
+		return
+	}
+	current := versions.Current(history)
+	if ifMatch != nil && !matchesVersion(*ifMatch, current) {
+		msg := fmt.Sprintf("The document's current version is %d", current)
+		w.Header().Set("ETag", versionETag(current))
+		w.WriteHeader(http.StatusPreconditionFailed)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	result := env.IngestService.Ingest(ctx, []storage.Document{doc})[0]
+	if result.Status == ingest.StatusFailed {
+		log.Printf("Failed to store version %d of document %s: %s", current+1, doc.DocumentID, result.Error)
+		msg := env.restoreVersion(ctx, doc.DocumentID, history)
+		if current > 0 {
+			w.Header().Set("ETag", versionETag(current))
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		return
+	}
+
+	v, err := env.History.Record(doc, restoredFrom)
+	if err != nil {
+		msg := "The document was stored, but its version could not be recorded"
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(api.Error{Message: &msg})
+		log.Printf("Failed to record version %d of document %s: %v", current+1, doc.DocumentID, err)
+		return
+	}
+
+	msg := "Document replaced"
+	status := http.StatusOK
+	switch {
+	case restoredFrom > 0:
+		msg = fmt.Sprintf("Version %d restored", restoredFrom)
+	case result.Status == ingest.StatusCreated:
+		msg = "Document created"
+		status = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(v.Version))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(api.VersionResponse{Message: &msg, DocumentId: &doc.DocumentID, Version: &v.Version})
+}
+
+// restoreVersion stores the latest of history again after a failed write, which may have left
+// part of the new version in one of the stores, and returns the message for the response.
+// A document without versions can't be restored, so the response says it may be partly
+// stored.
+func (env *Env) restoreVersion(ctx context.Context, id string, history []versions.Version) string {
+	if len(history) == 0 {
+		return "Failed to store document; it may be partly stored, and has no version"
+	}
+	latest := history[len(history)-1]
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), restoreTimeout)
+	defer cancel()
+	result := env.IngestService.Ingest(ctx, []storage.Document{latest.Document(id)})[0]
+	if result.Status == ingest.StatusFailed {
+		log.Printf("Failed to restore version %d of document %s: %s", latest.Version, id, result.Error)
+		return fmt.Sprintf("Failed to store document, and restoring version %d failed too; it may be partly stored until it is written again", latest.Version)
+	}
+	return "Failed to store document; its version is unchanged"
+}
+
+// recordFirstVersion records doc, just recorded in the outbox under a new ID by /store or
+// /documents/upload, as its first version, so that later versioned writes can match it. It does nothing without
+// a history. A failure is only logged, since the document is stored either way.
+func (env *Env) recordFirstVersion(doc storage.Document) {
+	if env.History == nil {
+		return
+	}
+	unlock := env.History.Lock(doc.DocumentID)
+	defer unlock()
+	if _, err := env.History.Record(doc, 0); err != nil {
+		log.Printf("Failed to record the first version of document %s: %v", doc.DocumentID, err)
+	}
+}
+
+// versionETag formats a version as an ETag.
+func versionETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// matchesVersion reports whether an If-Match header holds the current version. "*" matches
+// any document that has a version, and weak tags are compared like strong ones.
+func matchesVersion(ifMatch string, current int) bool {
+	if strings.TrimSpace(ifMatch) == "*" {
+		return current > 0
+	}
+	for tag := range strings.SplitSeq(ifMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if version, err := strconv.Atoi(strings.Trim(tag, `"`)); err == nil && version == current {
+			return true
+		}
+	}
+	return false
+}
+
+// toAPIVersion converts a version to its API form.
+func toAPIVersion(v versions.Version) api.DocumentVersion {
+	version, title, text, createdAt := v.Version, v.Title, v.Text, v.CreatedAt
+	apiVersion := api.DocumentVersion{Version: &version, Text: &text, CreatedAt: &createdAt}
+	if title != "" {
+		apiVersion.Title = &title
+	}
+	if len(v.Metadata) > 0 {
+		metadata := v.Metadata
+		apiVersion.Metadata = &metadata
+	}
+	if v.RestoredFrom > 0 {
+		restoredFrom := v.RestoredFrom
+		apiVersion.RestoredFrom = &restoredFrom
+	}
+	return apiVersion
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	ingest_mocks "github.com/chr1sbest/hybrid-search/pkg/ingest/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/chr1sbest/hybrid-search/pkg/versions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newHistory returns a History kept in a temporary directory.
+func newHistory(t *testing.T) *versions.History {
+	t.Helper()
+	store, err := versions.NewFileStore(t.TempDir())
+	assert.NoError(t, err)
+	return versions.NewHistory(store)
+}
+
+// putDocument sends PUT /documents/{id} to env.
+func putDocument(env *Env, id, body string, ifMatch *string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/documents/"+id, bytes.NewBufferString(body))
+	env.PutDocument(w, r, id, api.PutDocumentParams{IfMatch: ifMatch})
+	return w
+}
+
+func TestEnv_PutDocument(t *testing.T) {
+	t.Run("RecordsVersions", func(t *testing.T) {
+		// 1. Arrange
+		mockIngest := new(ingest_mocks.Service)
+		mockIngest.On("Ingest", mock.Anything, []storage.Document{
+			{DocumentID: "faq", Title: "Billing", Text: "Invoices are emailed monthly.", Metadata: map[string]string{"tag": "billing"}},
+		}).Return([]ingest.Result{{DocumentID: "faq", Status: ingest.StatusCreated}}).Once()
+		mockIngest.On("Ingest", mock.Anything, []storage.Document{
+			{DocumentID: "faq", Text: "Invoices are emailed weekly."},
+		}).Return([]ingest.Result{{DocumentID: "faq", Status: ingest.StatusUpdated}}).Once()
+		env := &Env{IngestService: mockIngest, History: newHistory(t)}
+
+		// 2. Act
+		created := putDocument(env, "faq", `{"title": " Billing ", "text": "Invoices are emailed monthly.", "metadata": {"tag": "billing"}}`, nil)
+		etag := created.Header().Get("ETag")
+		updated := putDocument(env, "faq", `{"text": "Invoices are emailed weekly."}`, &etag)
+
+		// 3. Assert
+		assert.Equal(t, http.StatusCreated, created.Code)
+		assert.Equal(t, `"1"`, etag)
+		assert.Equal(t, http.StatusOK, updated.Code)
+		assert.Equal(t, `"2"`, updated.Header().Get("ETag"))
+		var resp api.VersionResponse
+		_ = json.NewDecoder(updated.Body).Decode(&resp)
+		assert.Equal(t, "faq", *resp.DocumentId)
+		assert.Equal(t, 2, *resp.Version)
+
+		history, err := env.History.List("faq")
+		assert.NoError(t, err)
+		assert.Len(t, history, 2)
+		assert.Equal(t, "Billing", history[0].Title)
+		assert.Equal(t, "Invoices are emailed weekly.", history[1].Text)
+		mockIngest.AssertExpectations(t)
+	})
+
+	t.Run("StaleVersion", func(t *testing.T) {
+		mockIngest := new(ingest_mocks.Service)
+		mockIngest.On("Ingest", mock.Anything, mock.Anything).Return([]ingest.Result{{DocumentID: "faq", Status: ingest.StatusCreated}}).Once()
+		env := &Env{IngestService: mockIngest, History: newHistory(t)}
+		putDocument(env, "faq", `{"text": "Invoices are emailed monthly."}`, nil)
+
+		for _, ifMatch := range []string{`"0"`, `"2"`, `W/"3", "4"`} {
+			w := putDocument(env, "faq", `{"text": "Invoices are emailed weekly."}`, &ifMatch)
+
+			assert.Equal(t, http.StatusPreconditionFailed, w.Code, ifMatch)
+			assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+		}
+		mockIngest.AssertNumberOfCalls(t, "Ingest", 1)
+	})
+
+	t.Run("CreateOnly", func(t *testing.T) {
+		mockIngest := new(ingest_mocks.Service)
+		env := &Env{IngestService: mockIngest, History: newHistory(t)}
+		ifMatch := "*"
+
+		w := putDocument(env, "faq", `{"text": "Invoices are emailed monthly."}`, &ifMatch)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		mockIngest.AssertNotCalled(t, "Ingest", mock.Anything, mock.Anything)
+	})
+
+	t.Run("HoldsLockDuringIngest", func(t *testing.T) {
+		// 1. Arrange
+		// The ingest service must see the lock, or it would wait for it and record a second
+		// version.
+		mockIngest := new(ingest_mocks.Service)
+		mockIngest.On("Ingest", mock.MatchedBy(func(ctx context.Context) bool {
+			return versions.HoldsLock(ctx, "faq")
+		}), mock.Anything).Return([]ingest.Result{{DocumentID: "faq", Status: ingest.StatusCreated}})
+		env := &Env{IngestService: mockIngest, History: newHistory(t)}
+
+		// 2. Act
+		w := putDocument(env, "faq", `{"text": "Invoices are emailed monthly."}`, nil)
+
+		// 3. Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockIngest.AssertExpectations(t)
+	})
+
+	t.Run("AfterBulkWrite", func(t *testing.T) {
+		// 1. Arrange
+		// A document written through the ingest pipeline has a version too, so "*" and its
+		// ETag both match it.
+		mockIngest := new(ingest_mocks.Service)
+		mockIngest.On("Ingest", mock.Anything, mock.Anything).Return([]ingest.Result{{DocumentID: "faq", Status: ingest.StatusUpdated}})
+		env := &Env{IngestService: mockIngest, History: newHistory(t)}
+		_, err := env.History.Record(storage.Document{DocumentID: "faq", Text: "Invoices are emailed monthly."}, 0)
+		assert.NoError(t, err)
+		anyVersion, current := "*", `"1"`
+
+		// 2. Act
+		first := putDocument(env, "faq", `{"text": "Invoices are emailed weekly."}`, &anyVersion)
+		stale := putDocument(env, "faq", `{"text": "Invoices are emailed daily."}`, &current)
+
+		// 3. Assert
+		assert.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, `"2"`, first.Header().Get("ETag"))
+		assert.Equal(t, http.StatusPreconditionFailed, stale.Code)
+	})
+
+	t.Run("StoreFails", func(t *testing.T) {
+		mockIngest := new(ingest_mocks.Service)
+		mockIngest.On("Ingest", mock.Anything, mock.Anything).Return([]ingest.Result{{DocumentID: "faq", Status: ingest.StatusFailed, Error: "failed to index document: timeout"}})
+		env := &Env{IngestService: mockIngest, History: newHistory(t)}
+
+		w := putDocument(env, "faq", `{"text": "Invoices are emailed monthly."}`, nil)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		var resp api.Error
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		assert.Equal(t, "Failed to store document; it may be partly stored, and has no version", *resp.Message)
+		history, err := env.History.List("faq")
+		assert.NoError(t, err)
+		assert.Empty(t, history)
+		mockIngest.AssertNumberOfCalls(t, "Ingest", 1)
+	})
+
+	for _, tc := range []struct {
+		name          string
+		restoreStatus ingest.Status
+		message       string
+	}{
+		{"RestoresCurrentVersion", ingest.StatusUpdated, "Failed to store document; its version is unchanged"},
+		{"RestoreFails", ingest.StatusFailed, "Failed to store document, and restoring version 1 failed too; it may be partly stored until it is written again"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// 1. Arrange
+			// The failed write may have reached one store, so version 1 is stored again.
+			current := storage.Document{DocumentID: "faq", Text: "Invoices are emailed monthly."}
+			mockIngest := new(ingest_mocks.Service)
+			mockIngest.On("Ingest", mock.Anything, []storage.Document{{DocumentID: "faq", Text: "Invoices are emailed weekly."}}).
+				Return([]ingest.Result{{DocumentID: "faq", Status: ingest.StatusFailed, Error: "failed to write chunk: timeout"}}).Once()
+			mockIngest.On("Ingest", mock.MatchedBy(func(ctx context.Context) bool {
+				return versions.HoldsLock(ctx, "faq")
+			}), []storage.Document{current}).Return([]ingest.Result{{DocumentID: "faq", Status: tc.restoreStatus}}).Once()
+			env := &Env{IngestService: mockIngest, History: newHistory(t)}
+			_, err := env.History.Record(current, 0)
+			assert.NoError(t, err)
+
+			// 2. Act
+			w := putDocument(env, "faq", `{"text": "Invoices are emailed weekly."}`, nil)
+
+			// 3. Assert
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+			assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+			var resp api.Error
+			_ = json.NewDecoder(w.Body).Decode(&resp)
+			assert.Equal(t, tc.message, *resp.Message)
+			history, err := env.History.List("faq")
+			assert.NoError(t, err)
+			assert.Len(t, history, 1)
+			mockIngest.AssertExpectations(t)
+		})
+	}
+
+	t.Run("InvalidRequest", func(t *testing.T) {
+		env := &Env{IngestService: new(ingest_mocks.Service), History: newHistory(t)}
+
+		assert.Equal(t, http.StatusBadRequest, putDocument(env, "faq#1", `{"text": "Chunk-like IDs are rejected."}`, nil).Code)
+		assert.Equal(t, http.StatusBadRequest, putDocument(env, "faq", `{"text": ""}`, nil).Code)
+		assert.Equal(t, http.StatusBadRequest, putDocument(env, "faq", `not json`, nil).Code)
+	})
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		w := putDocument(&Env{IngestService: new(ingest_mocks.Service)}, "faq", `{"text": "Invoices are emailed monthly."}`, nil)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestEnv_RollbackDocument(t *testing.T) {
+	t.Run("RestoresAsNewVersion", func(t *testing.T) {
+		// 1. Arrange
+		first := storage.Document{DocumentID: "faq", Title: "Billing", Text: "Invoices are emailed monthly."}
+		mockIngest := new(ingest_mocks.Service)
+		mockIngest.On("Ingest", mock.Anything, mock.Anything).Return([]ingest.Result{{DocumentID: "faq", Status: ingest.StatusUpdated}})
+		env := &Env{IngestService: mockIngest, History: newHistory(t)}
+		putDocument(env, "faq", `{"title": "Billing", "text": "Invoices are emailed monthly."}`, nil)
+		putDocument(env, "faq", `{"text": "Invoices are emailed weekly."}`, nil)
+		ifMatch := `"2"`
+
+		// 2. Act
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/documents/faq/rollback", bytes.NewBufferString(`{"version": 1}`))
+		env.RollbackDocument(w, r, "faq", api.RollbackDocumentParams{IfMatch: &ifMatch})
+
+		// 3. Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+		mockIngest.AssertCalled(t, "Ingest", mock.Anything, []storage.Document{first})
+		history, err := env.History.List("faq")
+		assert.NoError(t, err)
+		assert.Len(t, history, 3)
+		assert.Equal(t, 1, history[2].RestoredFrom)
+		assert.Equal(t, first.Text, history[2].Text)
+	})
+
+	t.Run("UnknownVersion", func(t *testing.T) {
+		mockIngest := new(ingest_mocks.Service)
+		env := &Env{IngestService: mockIngest, History: newHistory(t)}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/documents/faq/rollback", bytes.NewBufferString(`{"version": 4}`))
+		env.RollbackDocument(w, r, "faq", api.RollbackDocumentParams{})
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockIngest.AssertNotCalled(t, "Ingest", mock.Anything, mock.Anything)
+	})
+}
+
+func TestEnv_ListDocumentVersions(t *testing.T) {
+	t.Run("ListsOldestFirst", func(t *testing.T) {
+		mockIngest := new(ingest_mocks.Service)
+		mockIngest.On("Ingest", mock.Anything, mock.Anything).Return([]ingest.Result{{DocumentID: "faq", Status: ingest.StatusCreated}})
+		env := &Env{IngestService: mockIngest, History: newHistory(t)}
+		putDocument(env, "faq", `{"text": "Invoices are emailed monthly.", "metadata": {"tag": "billing"}}`, nil)
+		putDocument(env, "faq", `{"text": "Invoices are emailed weekly."}`, nil)
+
+		w := httptest.NewRecorder()
+		env.ListDocumentVersions(w, httptest.NewRequest(http.MethodGet, "/documents/faq/versions", nil), "faq")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+		var resp api.DocumentVersionList
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		assert.Equal(t, "faq", *resp.DocumentId)
+		list := *resp.Versions
+		assert.Len(t, list, 2)
+		assert.Equal(t, 1, *list[0].Version)
+		assert.Equal(t, map[string]string{"tag": "billing"}, *list[0].Metadata)
+		assert.Equal(t, "Invoices are emailed weekly.", *list[1].Text)
+		assert.Nil(t, list[1].RestoredFrom)
+	})
+
+	t.Run("NoVersions", func(t *testing.T) {
+		env := &Env{History: newHistory(t)}
+
+		w := httptest.NewRecorder()
+		env.ListDocumentVersions(w, httptest.NewRequest(http.MethodGet, "/documents/faq/versions", nil), "faq")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
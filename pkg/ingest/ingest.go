@@ -0,0 +1,534 @@
+package ingest
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/chunker"
+	"github.com/chr1sbest/hybrid-search/pkg/embeddings"
+	"github.com/chr1sbest/hybrid-search/pkg/retry"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/chr1sbest/hybrid-search/pkg/versions"
+	"golang.org/x/sync/errgroup"
+)
+
+// compensationTimeout bounds the time spent removing a partially stored document.
+const compensationTimeout = 30 * time.Second
+
+// Status is the outcome of ingesting one document.
+type Status string
+
+const (
+	// StatusCreated means the document is new.
+	StatusCreated Status = "created"
+	// StatusUpdated means the document replaced one with the same ID.
+	StatusUpdated Status = "updated"
+	// StatusFailed means the document could not be stored; Result.Error says why.
+	StatusFailed Status = "failed"
+)
+
+// Result is the outcome of ingesting one document.
+type Result struct {
+	DocumentID string
+	Status     Status
+	// Error is the reason the document failed. It is empty unless Status is StatusFailed.
+	Error string
+	// Version is the version the document was recorded as, or zero if versions aren't
+	// recorded.
+	Version int
+}
+
+// FailurePolicy decides what happens to a document when some of its chunks still fail after
+// retries.
+type FailurePolicy string
+
+const (
+	// AllOrNothing removes the document and the chunks that were written.
+	AllOrNothing FailurePolicy = "all_or_nothing"
+	// Partial keeps the document and the chunks that were written.
+	Partial FailurePolicy = "partial"
+)
+
+// ChunkFailure is a chunk that could not be stored.
+type ChunkFailure struct {
+	ChunkID string
+	Err     error
+}
+
+// Error implements the error interface.
+func (f ChunkFailure) Error() string {
+	return fmt.Sprintf("chunk %s: %v", f.ChunkID, f.Err)
+}
+
+// StoreResult is the outcome of Pipeline.Store.
+type StoreResult struct {
+	// Chunks is the number of chunks the document was split into.
+	Chunks int
+	// Failed lists the chunks that could not be stored.
+	Failed []ChunkFailure
+	// Version is the version the document was recorded as, or zero if versions aren't
+	// recorded.
+	Version int
+}
+
+// Progress counts the chunks of a document as they move through the pipeline.
+type Progress struct {
+	Total    int `json:"total"`
+	Embedded int `json:"embedded"`
+	Written  int `json:"written"`
+	Failed   int `json:"failed"`
+}
+
+// Service defines the interface for storing documents in bulk.
+type Service interface {
+	// Ingest chunks, embeds and stores docs, and returns a result for each, in the same order.
+	// A document that fails does not stop the others.
+	Ingest(ctx context.Context, docs []storage.Document) []Result
+}
+
+// Pipeline ingests documents in batches, several at a time: each batch is indexed in the text
+// store with one bulk request, then its chunks are embedded and upserted to the vector store
+// together. It implements the Service interface. Store, Process, Index and WriteChunks write a
+// single document, so that every write to the stores goes through the same steps.
+type Pipeline struct {
+	chunker         *chunker.Chunker
+	embeddingClient embeddings.EmbeddingClient
+	vectorStore     storage.VectorStore
+	textStore       storage.TextStore
+	batchSize       int
+	concurrency     int
+	history         *versions.History
+	retry           retry.Policy
+	failurePolicy   FailurePolicy
+}
+
+// Option configures a Pipeline.
+type Option func(*Pipeline)
+
+// WithHistory records each document the pipeline stores as the document's next version in
+// history, so documents written in bulk are versioned like those written by
+// PUT /documents/{id}. A document is locked in history from before it is written until its
+// version is recorded.
+func WithHistory(history *versions.History) Option {
+	return func(p *Pipeline) {
+		p.history = history
+	}
+}
+
+// WithRetry retries indexing documents, embedding each chunk and writing chunks under policy.
+// Without it, each step is tried once.
+func WithRetry(policy retry.Policy) Option {
+	return func(p *Pipeline) {
+		p.retry = policy
+	}
+}
+
+// WithFailurePolicy sets the policy applied to documents written one at a time, by Process
+// and by Store when no policy is given. Without it, AllOrNothing applies.
+func WithFailurePolicy(policy FailurePolicy) Option {
+	return func(p *Pipeline) {
+		p.failurePolicy = policy
+	}
+}
+
+// NewPipeline creates a Pipeline that processes up to concurrency batches of batchSize
+// documents at a time. Values below 1 are treated as 1.
+func NewPipeline(
+	chunkr *chunker.Chunker,
+	embeddingClient embeddings.EmbeddingClient,
+	vectorStore storage.VectorStore,
+	textStore storage.TextStore,
+	batchSize, concurrency int,
+	opts ...Option,
+) *Pipeline {
+	p := &Pipeline{
+		chunker:         chunkr,
+		embeddingClient: embeddingClient,
+		vectorStore:     vectorStore,
+		textStore:       textStore,
+		batchSize:       max(batchSize, 1),
+		concurrency:     max(concurrency, 1),
+		failurePolicy:   AllOrNothing,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Ingest implements the Service interface. A document is indexed in the text store before its
+// chunks are embedded, so a document that fails at the embedding or vector stage may still be
+// found by lexical search; ingesting it again replaces both. When a document replaces one
+// that had more chunks, the extra chunks are deleted if the vector store implements
+// storage.IDLister. With a history, each stored document is recorded as a new version, unless
+// ctx holds its lock (see versions.WithLock).
+func (p *Pipeline) Ingest(ctx context.Context, docs []storage.Document) []Result {
+	results := make([]Result, len(docs))
+	for i, doc := range docs {
+		results[i].DocumentID = doc.DocumentID
+	}
+
+	var g errgroup.Group
+	g.SetLimit(p.concurrency)
+	for start := 0; start < len(docs); start += p.batchSize {
+		end := min(start+p.batchSize, len(docs))
+		g.Go(func() error {
+			p.ingestBatch(ctx, docs[start:end], results[start:end])
+			return nil
+		})
+	}
+	g.Wait()
+	return results
+}
+
+// Store writes a single document to both stores: the document is indexed in the text store,
+// then its chunks are embedded and written to the vector store in batches of the pipeline's
+// batch size. Each step is retried under the pipeline's retry policy, and chunks that still
+// fail are handled by policy, or the pipeline's failure policy when it is empty. It returns an
+// error if the document could not be indexed, or if it was removed again under AllOrNothing.
+// A document that is kept is recorded as a new version, as Ingest does, and chunks left
+// behind by an earlier version with more chunks are deleted.
+func (p *Pipeline) Store(ctx context.Context, doc storage.Document, policy FailurePolicy) (StoreResult, error) {
+	return p.store(ctx, doc, policy, func(Progress) {})
+}
+
+// Process stores a single document under the pipeline's failure policy, as Store does,
+// calling progress whenever a chunk is embedded, written or fails. It returns an error if the
+// document could not be stored in full.
+func (p *Pipeline) Process(ctx context.Context, doc storage.Document, progress func(Progress)) error {
+	result, err := p.store(ctx, doc, "", progress)
+	if err != nil {
+		return err
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d of %d chunks failed: %w", len(result.Failed), result.Chunks, result.Failed[0])
+	}
+	return nil
+}
+
+// Index indexes doc in the text store, retrying under the pipeline's retry policy. It doesn't
+// touch the vector store or the document's history.
+func (p *Pipeline) Index(ctx context.Context, doc storage.Document) error {
+	_, err := p.index(ctx, doc)
+	return err
+}
+
+// WriteChunks splits doc into chunks, embeds them and writes them to the vector store,
+// retrying each step under the pipeline's retry policy, and returns the number of chunks. It
+// returns an error if any chunk failed; the others are still written. It doesn't touch the
+// text store or the document's history.
+func (p *Pipeline) WriteChunks(ctx context.Context, doc storage.Document) (int, error) {
+	chunks := p.chunk(doc)
+	_, failed := p.writeChunks(ctx, chunks, func(Progress) {})
+	if len(failed) > 0 {
+		return len(chunks), fmt.Errorf("%d of %d chunks failed: %w", len(failed), len(chunks), failed[0])
+	}
+	return len(chunks), nil
+}
+
+// store implements Store, reporting progress as it goes.
+func (p *Pipeline) store(ctx context.Context, doc storage.Document, policy FailurePolicy, progress func(Progress)) (StoreResult, error) {
+	defer p.lock(ctx, []storage.Document{doc})()
+
+	created, err := p.index(ctx, doc)
+	if err != nil {
+		return StoreResult{}, fmt.Errorf("failed to index document: %w", err)
+	}
+
+	chunks := p.chunk(doc)
+	written, failed := p.writeChunks(ctx, chunks, progress)
+	result := StoreResult{Chunks: len(chunks), Failed: failed}
+	if len(failed) > 0 && cmp.Or(policy, p.failurePolicy) == AllOrNothing {
+		p.remove(ctx, doc.DocumentID, written)
+		return result, fmt.Errorf("%d of %d chunks failed, so the document was removed: %w", len(failed), len(chunks), failed[0])
+	}
+
+	if !created {
+		if err := p.deleteStaleChunks(ctx, doc.DocumentID, len(chunks)); err != nil {
+			return result, fmt.Errorf("failed to delete stale chunks: %w", err)
+		}
+	}
+	if result.Version, err = p.record(ctx, doc); err != nil {
+		return result, fmt.Errorf("failed to record version: %w", err)
+	}
+	return result, nil
+}
+
+// index indexes doc in the text store, retrying under the pipeline's retry policy, and reports
+// whether it is new.
+func (p *Pipeline) index(ctx context.Context, doc storage.Document) (bool, error) {
+	var created bool
+	err := p.retry.Do(ctx, func() error {
+		indexed, err := p.textStore.IndexMany(ctx, []storage.Document{doc})
+		if err != nil {
+			return err
+		}
+		created = indexed[0].Created
+		return indexed[0].Err
+	})
+	return created, err
+}
+
+// chunk splits doc into chunks that carry its metadata.
+func (p *Pipeline) chunk(doc storage.Document) []storage.Document {
+	chunks := p.chunker.ChunkDocument(doc)
+	for i := range chunks {
+		chunks[i].Metadata = chunker.MergeMetadata(doc.Metadata, chunks[i].Metadata)
+	}
+	return chunks
+}
+
+// embed creates the embedding of a chunk, retrying under the pipeline's retry policy.
+func (p *Pipeline) embed(ctx context.Context, chunk storage.Document) ([]float32, error) {
+	var vector []float32
+	err := p.retry.Do(ctx, func() error {
+		var err error
+		vector, err = p.embeddingClient.CreateEmbedding(ctx, chunk.Text)
+		return err
+	})
+	return vector, err
+}
+
+// upsert writes chunks to the vector store, retrying under the pipeline's retry policy.
+func (p *Pipeline) upsert(ctx context.Context, chunks []storage.Document, vectors [][]float32) error {
+	return p.retry.Do(ctx, func() error {
+		return p.vectorStore.UpsertMany(ctx, chunks, vectors)
+	})
+}
+
+// writeChunks embeds chunks and writes them in batches of the pipeline's batch size, calling
+// progress whenever a chunk is embedded, written or fails. It returns the IDs of the chunks
+// written and the chunks that failed.
+func (p *Pipeline) writeChunks(ctx context.Context, chunks []storage.Document, progress func(Progress)) ([]string, []ChunkFailure) {
+	prog := Progress{Total: len(chunks)}
+	progress(prog)
+
+	var failed []ChunkFailure
+	var embedded []storage.Document
+	var vectors [][]float32
+	for _, chunk := range chunks {
+		vector, err := p.embed(ctx, chunk)
+		if err != nil {
+			failed = append(failed, ChunkFailure{ChunkID: chunk.DocumentID, Err: fmt.Errorf("failed to create embedding: %w", err)})
+			prog.Failed++
+		} else {
+			embedded = append(embedded, chunk)
+			vectors = append(vectors, vector)
+			prog.Embedded++
+		}
+		progress(prog)
+	}
+
+	var written []string
+	for start := 0; start < len(embedded); start += p.batchSize {
+		end := min(start+p.batchSize, len(embedded))
+		if err := p.upsert(ctx, embedded[start:end], vectors[start:end]); err != nil {
+			for _, chunk := range embedded[start:end] {
+				failed = append(failed, ChunkFailure{ChunkID: chunk.DocumentID, Err: fmt.Errorf("failed to write chunk: %w", err)})
+			}
+			prog.Failed += end - start
+		} else {
+			for _, chunk := range embedded[start:end] {
+				written = append(written, chunk.DocumentID)
+			}
+			prog.Written += end - start
+		}
+		progress(prog)
+	}
+	return written, failed
+}
+
+// remove undoes a failed store by deleting the document and the chunks that were written. It
+// carries on after ctx is cancelled, and logs what it could not remove.
+func (p *Pipeline) remove(ctx context.Context, id string, chunkIDs []string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), compensationTimeout)
+	defer cancel()
+
+	if len(chunkIDs) > 0 {
+		err := p.retry.Do(ctx, func() error {
+			return p.vectorStore.Delete(ctx, chunkIDs)
+		})
+		if err != nil {
+			log.Printf("Failed to remove %d chunks of document %s: %v", len(chunkIDs), id, err)
+		}
+	}
+	err := p.retry.Do(ctx, func() error {
+		return p.textStore.Delete(ctx, id)
+	})
+	if err != nil {
+		log.Printf("Failed to remove document %s: %v", id, err)
+	}
+}
+
+// ingestBatch ingests docs and records their outcomes in results, which is aligned with docs.
+func (p *Pipeline) ingestBatch(ctx context.Context, docs []storage.Document, results []Result) {
+	defer p.lock(ctx, docs)()
+
+	var indexed []storage.IndexResult
+	err := p.retry.Do(ctx, func() error {
+		var err error
+		indexed, err = p.textStore.IndexMany(ctx, docs)
+		return err
+	})
+	if err != nil {
+		for i := range results {
+			fail(&results[i], "failed to index document", err)
+		}
+		return
+	}
+
+	// owners holds the index in docs of the document each chunk belongs to, and counts the
+	// number of chunks of each document.
+	var chunks []storage.Document
+	var owners []int
+	counts := make([]int, len(docs))
+	for i, res := range indexed {
+		if res.Err != nil {
+			fail(&results[i], "failed to index document", res.Err)
+			continue
+		}
+		results[i].Status = StatusUpdated
+		if res.Created {
+			results[i].Status = StatusCreated
+		}
+		for _, chunk := range p.chunk(docs[i]) {
+			chunks = append(chunks, chunk)
+			owners = append(owners, i)
+			counts[i]++
+		}
+	}
+
+	vectors := make([][]float32, len(chunks))
+	for i, chunk := range chunks {
+		if results[owners[i]].Status == StatusFailed {
+			continue
+		}
+		vector, err := p.embed(ctx, chunk)
+		if err != nil {
+			fail(&results[owners[i]], fmt.Sprintf("failed to create embedding for chunk %s", chunk.DocumentID), err)
+			continue
+		}
+		vectors[i] = vector
+	}
+
+	// Only the chunks of documents that are still succeeding are upserted.
+	var upsert []storage.Document
+	var upsertVectors [][]float32
+	var upsertOwners []int
+	for i, chunk := range chunks {
+		if results[owners[i]].Status != StatusFailed {
+			upsert = append(upsert, chunk)
+			upsertVectors = append(upsertVectors, vectors[i])
+			upsertOwners = append(upsertOwners, owners[i])
+		}
+	}
+	if len(upsert) > 0 {
+		if err := p.upsert(ctx, upsert, upsertVectors); err != nil {
+			for _, owner := range upsertOwners {
+				if results[owner].Status != StatusFailed {
+					fail(&results[owner], "failed to store chunks", err)
+				}
+			}
+		}
+	}
+
+	// A replaced document may have had more chunks than it has now.
+	for i, doc := range docs {
+		if results[i].Status != StatusUpdated {
+			continue
+		}
+		if err := p.deleteStaleChunks(ctx, doc.DocumentID, counts[i]); err != nil {
+			fail(&results[i], "failed to delete stale chunks", err)
+		}
+	}
+
+	for i, doc := range docs {
+		if results[i].Status == StatusFailed {
+			continue
+		}
+		version, err := p.record(ctx, doc)
+		if err != nil {
+			fail(&results[i], "failed to record version", err)
+			continue
+		}
+		results[i].Version = version
+	}
+}
+
+// lock locks docs in the history, in ID order so that batches sharing documents can't
+// deadlock, and returns a function that unlocks them. Documents whose lock ctx holds are
+// skipped. It does nothing without a history.
+func (p *Pipeline) lock(ctx context.Context, docs []storage.Document) (unlock func()) {
+	if p.history == nil {
+		return func() {}
+	}
+	var ids []string
+	for _, doc := range docs {
+		if !versions.HoldsLock(ctx, doc.DocumentID) {
+			ids = append(ids, doc.DocumentID)
+		}
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	unlocks := make([]func(), len(ids))
+	for i, id := range ids {
+		unlocks[i] = p.history.Lock(id)
+	}
+	return func() {
+		for _, unlock := range slices.Backward(unlocks) {
+			unlock()
+		}
+	}
+}
+
+// record records doc as its next version and returns the version number. It returns zero
+// without a history, or when ctx holds the document's lock, since its holder records it.
+func (p *Pipeline) record(ctx context.Context, doc storage.Document) (int, error) {
+	if p.history == nil || versions.HoldsLock(ctx, doc.DocumentID) {
+		return 0, nil
+	}
+	v, err := p.history.Record(doc, 0)
+	if err != nil {
+		return 0, err
+	}
+	return v.Version, nil
+}
+
+// deleteStaleChunks deletes the chunks of a document numbered count or higher, which an
+// earlier version with more chunks left behind. It does nothing unless the vector store can
+// list its IDs.
+func (p *Pipeline) deleteStaleChunks(ctx context.Context, id string, count int) error {
+	lister, ok := p.vectorStore.(storage.IDLister)
+	if !ok {
+		return nil
+	}
+
+	var stale []string
+	err := lister.ListIDs(ctx, id+"#", func(ids []string) error {
+		for _, chunkID := range ids {
+			// The prefix also matches the chunks of documents whose IDs extend this one's.
+			if parent, i, ok := chunker.ParseChunkID(chunkID); ok && parent == id && i >= count {
+				stale = append(stale, chunkID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	return p.vectorStore.Delete(ctx, stale)
+}
+
+// fail marks a result as failed with a reason.
+func fail(result *Result, reason string, err error) {
+	result.Status = StatusFailed
+	result.Error = fmt.Sprintf("%s: %v", reason, err)
+}
@@ -0,0 +1,495 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/chunker"
+	embedding_mocks "github.com/chr1sbest/hybrid-search/pkg/embeddings/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/retry"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	storage_mocks "github.com/chr1sbest/hybrid-search/pkg/storage/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/versions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testDocs = []storage.Document{
+	{DocumentID: "a", Text: "Invoices are emailed monthly.", Metadata: map[string]string{"tag": "billing"}},
+	{DocumentID: "b", Text: "Passwords can be reset from settings."},
+	{DocumentID: "c", Text: "Exports are available as CSV."},
+}
+
+func TestPipeline_Ingest(t *testing.T) {
+	t.Run("ReportsCreatedAndUpdated", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockVector := new(storage_mocks.VectorStore)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, testDocs[:2]).Return([]storage.IndexResult{
+			{DocumentID: "a", Created: true},
+			{DocumentID: "b"},
+		}, nil).Once()
+		mockText.On("IndexMany", mock.Anything, testDocs[2:]).Return([]storage.IndexResult{
+			{DocumentID: "c", Created: true},
+		}, nil).Once()
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVector.On("UpsertMany", mock.Anything, mock.MatchedBy(func(chunks []storage.Document) bool {
+			return len(chunks) == 2 && chunks[0].DocumentID == "a#0" && chunks[0].Metadata["tag"] == "billing" &&
+				chunks[1].DocumentID == "b#0"
+		}), [][]float32{{0.1}, {0.1}}).Return(nil).Once()
+		mockVector.On("UpsertMany", mock.Anything, mock.MatchedBy(func(chunks []storage.Document) bool {
+			return len(chunks) == 1 && chunks[0].DocumentID == "c#0"
+		}), [][]float32{{0.1}}).Return(nil).Once()
+
+		pipeline := NewPipeline(chunker.NewChunker(512, 50), mockEmbed, mockVector, mockText, 2, 1)
+
+		// 2. Act
+		results := pipeline.Ingest(context.Background(), testDocs)
+
+		// 3. Assert
+		assert.Equal(t, []Result{
+			{DocumentID: "a", Status: StatusCreated},
+			{DocumentID: "b", Status: StatusUpdated},
+			{DocumentID: "c", Status: StatusCreated},
+		}, results)
+		mockText.AssertExpectations(t)
+		mockVector.AssertExpectations(t)
+	})
+
+	t.Run("FailsOnlyTheFailedDocuments", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockVector := new(storage_mocks.VectorStore)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, testDocs).Return([]storage.IndexResult{
+			{DocumentID: "a", Err: &storage.BulkItemError{Status: 400, Reason: "mapper_parsing_exception: bad field"}},
+			{DocumentID: "b", Created: true},
+			{DocumentID: "c", Created: true},
+		}, nil)
+		mockEmbed.On("CreateEmbedding", mock.Anything, testDocs[1].Text).Return(nil, errors.New("rate limited"))
+		mockEmbed.On("CreateEmbedding", mock.Anything, testDocs[2].Text).Return([]float32{0.3}, nil)
+		mockVector.On("UpsertMany", mock.Anything, mock.MatchedBy(func(chunks []storage.Document) bool {
+			return len(chunks) == 1 && chunks[0].DocumentID == "c#0"
+		}), [][]float32{{0.3}}).Return(nil)
+
+		pipeline := NewPipeline(chunker.NewChunker(512, 50), mockEmbed, mockVector, mockText, 10, 2)
+
+		// 2. Act
+		results := pipeline.Ingest(context.Background(), testDocs)
+
+		// 3. Assert
+		assert.Equal(t, []Result{
+			{DocumentID: "a", Status: StatusFailed, Error: "failed to index document: status 400: mapper_parsing_exception: bad field"},
+			{DocumentID: "b", Status: StatusFailed, Error: "failed to create embedding for chunk b#0: rate limited"},
+			{DocumentID: "c", Status: StatusCreated},
+		}, results)
+		mockEmbed.AssertNotCalled(t, "CreateEmbedding", mock.Anything, testDocs[0].Text)
+		mockVector.AssertExpectations(t)
+	})
+
+	t.Run("FailsBatchWhenStoreFails", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockVector := new(storage_mocks.VectorStore)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, testDocs[:2]).Return([]storage.IndexResult{
+			{DocumentID: "a", Created: true},
+			{DocumentID: "b", Created: true},
+		}, nil)
+		mockText.On("IndexMany", mock.Anything, testDocs[2:]).Return(nil, errors.New("connection refused"))
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("quota exceeded"))
+
+		pipeline := NewPipeline(chunker.NewChunker(512, 50), mockEmbed, mockVector, mockText, 2, 2)
+
+		// 2. Act
+		results := pipeline.Ingest(context.Background(), testDocs)
+
+		// 3. Assert
+		assert.Equal(t, []Result{
+			{DocumentID: "a", Status: StatusFailed, Error: "failed to store chunks: quota exceeded"},
+			{DocumentID: "b", Status: StatusFailed, Error: "failed to store chunks: quota exceeded"},
+			{DocumentID: "c", Status: StatusFailed, Error: "failed to index document: connection refused"},
+		}, results)
+	})
+}
+
+// listingVectorStore is a vector store that can list its IDs.
+type listingVectorStore struct {
+	*storage_mocks.VectorStore
+	*storage_mocks.IDLister
+}
+
+func TestPipeline_Ingest_StaleChunks(t *testing.T) {
+	doc := storage.Document{DocumentID: "faq", Text: "Invoices are emailed weekly."}
+
+	t.Run("DeletesChunksPastTheNewCount", func(t *testing.T) {
+		// 1. Arrange: an earlier version of faq had three chunks, and faq#1 is another document.
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return(nil, nil)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, []storage.Document{doc}).Return([]storage.IndexResult{{DocumentID: "faq"}}, nil)
+		vector := listingVectorStore{new(storage_mocks.VectorStore), new(storage_mocks.IDLister)}
+		vector.VectorStore.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		vector.IDLister.On("ListIDs", mock.Anything, "faq#", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			_ = args.Get(2).(func([]string) error)([]string{"faq#0", "faq#1", "faq#1#0", "faq#2"})
+		})
+		vector.VectorStore.On("Delete", mock.Anything, []string{"faq#1", "faq#2"}).Return(nil).Once()
+		pipeline := NewPipeline(chunker.NewChunker(512, 50), mockEmbed, vector, mockText, 10, 1)
+
+		// 2. Act
+		results := pipeline.Ingest(context.Background(), []storage.Document{doc})
+
+		// 3. Assert
+		assert.Equal(t, []Result{{DocumentID: "faq", Status: StatusUpdated}}, results)
+		vector.VectorStore.AssertExpectations(t)
+	})
+
+	t.Run("NewDocumentsAreNotListed", func(t *testing.T) {
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return(nil, nil)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, mock.Anything).Return([]storage.IndexResult{{DocumentID: "faq", Created: true}}, nil)
+		vector := listingVectorStore{new(storage_mocks.VectorStore), new(storage_mocks.IDLister)}
+		vector.VectorStore.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		pipeline := NewPipeline(chunker.NewChunker(512, 50), mockEmbed, vector, mockText, 10, 1)
+
+		results := pipeline.Ingest(context.Background(), []storage.Document{doc})
+
+		assert.Equal(t, StatusCreated, results[0].Status)
+		vector.IDLister.AssertNotCalled(t, "ListIDs", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ListError", func(t *testing.T) {
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return(nil, nil)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, mock.Anything).Return([]storage.IndexResult{{DocumentID: "faq"}}, nil)
+		vector := listingVectorStore{new(storage_mocks.VectorStore), new(storage_mocks.IDLister)}
+		vector.VectorStore.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		vector.IDLister.On("ListIDs", mock.Anything, "faq#", mock.Anything).Return(errors.New("unavailable"))
+		pipeline := NewPipeline(chunker.NewChunker(512, 50), mockEmbed, vector, mockText, 10, 1)
+
+		results := pipeline.Ingest(context.Background(), []storage.Document{doc})
+
+		assert.Equal(t, StatusFailed, results[0].Status)
+		assert.Equal(t, "failed to delete stale chunks: unavailable", results[0].Error)
+	})
+}
+
+// newHistory returns a History kept in a temporary directory.
+func newHistory(t *testing.T) *versions.History {
+	t.Helper()
+	store, err := versions.NewFileStore(t.TempDir())
+	assert.NoError(t, err)
+	return versions.NewHistory(store)
+}
+
+func TestPipeline_Ingest_Versions(t *testing.T) {
+	newPipeline := func(history *versions.History) *Pipeline {
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, mock.Anything).Return([]storage.IndexResult{
+			{DocumentID: "a", Err: &storage.BulkItemError{Status: 400, Reason: "mapper_parsing_exception: bad field"}},
+			{DocumentID: "b"},
+			{DocumentID: "c", Created: true},
+		}, nil)
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVector := new(storage_mocks.VectorStore)
+		mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		return NewPipeline(chunker.NewChunker(512, 50), mockEmbed, mockVector, mockText, 10, 1, WithHistory(history))
+	}
+
+	t.Run("RecordsStoredDocuments", func(t *testing.T) {
+		// 1. Arrange
+		history := newHistory(t)
+		_, err := history.Record(storage.Document{DocumentID: "b", Text: "Passwords are reset by support."}, 0)
+		assert.NoError(t, err)
+
+		// 2. Act
+		results := newPipeline(history).Ingest(context.Background(), testDocs)
+
+		// 3. Assert
+		assert.Equal(t, 0, results[0].Version)
+		assert.Equal(t, 2, results[1].Version)
+		assert.Equal(t, 1, results[2].Version)
+		listed, err := history.List("b")
+		assert.NoError(t, err)
+		assert.Equal(t, testDocs[1].Text, listed[1].Text)
+		failed, err := history.List("a")
+		assert.NoError(t, err)
+		assert.Empty(t, failed)
+	})
+
+	t.Run("LeavesLockedDocumentsToTheCaller", func(t *testing.T) {
+		// 1. Arrange
+		history := newHistory(t)
+		unlock := history.Lock("b")
+		defer unlock()
+		ctx := versions.WithLock(context.Background(), "b")
+
+		// 2. Act
+		results := newPipeline(history).Ingest(ctx, testDocs)
+
+		// 3. Assert
+		assert.Equal(t, 0, results[1].Version)
+		assert.Equal(t, 1, results[2].Version)
+		listed, err := history.List("b")
+		assert.NoError(t, err)
+		assert.Empty(t, listed)
+	})
+}
+
+func TestNewPipeline_ClampsSizes(t *testing.T) {
+	mockText := new(storage_mocks.TextStore)
+	mockText.On("IndexMany", mock.Anything, mock.Anything).Return(func(ctx context.Context, docs []storage.Document) []storage.IndexResult {
+		return make([]storage.IndexResult, len(docs))
+	}, nil)
+	mockEmbed := new(embedding_mocks.EmbeddingClient)
+	mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return(nil, nil)
+	mockVector := new(storage_mocks.VectorStore)
+	mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	pipeline := NewPipeline(chunker.NewChunker(512, 50), mockEmbed, mockVector, mockText, 0, -1)
+
+	results := pipeline.Ingest(context.Background(), testDocs)
+
+	assert.Len(t, results, len(testDocs))
+	mockText.AssertNumberOfCalls(t, "IndexMany", len(testDocs))
+}
+
+func TestPipeline_Process(t *testing.T) {
+	doc := storage.Document{DocumentID: "p1", Text: "First chunk text. Second chunk text. Third chunk text."}
+	created := []storage.IndexResult{{DocumentID: "p1", Created: true}}
+
+	t.Run("ReportsProgress", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockVector := new(storage_mocks.VectorStore)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, []storage.Document{doc}).Return(created, nil)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		pipeline := NewPipeline(chunker.NewChunker(20, 0), mockEmbed, mockVector, mockText, 2, 1)
+		var progress []Progress
+
+		// 2. Act
+		err := pipeline.Process(context.Background(), doc, func(p Progress) { progress = append(progress, p) })
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []Progress{
+			{Total: 3},
+			{Total: 3, Embedded: 1},
+			{Total: 3, Embedded: 2},
+			{Total: 3, Embedded: 3},
+			{Total: 3, Embedded: 3, Written: 2},
+			{Total: 3, Embedded: 3, Written: 3},
+		}, progress)
+		mockVector.AssertNumberOfCalls(t, "UpsertMany", 2)
+	})
+
+	t.Run("CountsFailedChunks", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockVector := new(storage_mocks.VectorStore)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, []storage.Document{doc}).Return(created, nil)
+		mockEmbed.On("CreateEmbedding", mock.Anything, "Second chunk text.").Return(nil, errors.New("rate limited"))
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		pipeline := NewPipeline(chunker.NewChunker(20, 0), mockEmbed, mockVector, mockText, 10, 1, WithFailurePolicy(Partial))
+		var last Progress
+
+		// 2. Act
+		err := pipeline.Process(context.Background(), doc, func(p Progress) { last = p })
+
+		// 3. Assert
+		assert.EqualError(t, err, "1 of 3 chunks failed: chunk p1#1: failed to create embedding: rate limited")
+		assert.Equal(t, Progress{Total: 3, Embedded: 2, Written: 2, Failed: 1}, last)
+	})
+
+	t.Run("RecordsVersion", func(t *testing.T) {
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockVector := new(storage_mocks.VectorStore)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, []storage.Document{doc}).Return(created, nil)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		history := newHistory(t)
+
+		pipeline := NewPipeline(chunker.NewChunker(20, 0), mockEmbed, mockVector, mockText, 10, 1, WithHistory(history))
+		err := pipeline.Process(context.Background(), doc, func(Progress) {})
+
+		assert.NoError(t, err)
+		listed, err := history.List("p1")
+		assert.NoError(t, err)
+		assert.Len(t, listed, 1)
+		assert.Equal(t, doc.Text, listed[0].Text)
+	})
+
+	t.Run("DeletesStaleChunks", func(t *testing.T) {
+		// 1. Arrange: an earlier version of p1 had five chunks.
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, []storage.Document{doc}).Return([]storage.IndexResult{{DocumentID: "p1"}}, nil)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		vector := listingVectorStore{new(storage_mocks.VectorStore), new(storage_mocks.IDLister)}
+		vector.VectorStore.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		vector.IDLister.On("ListIDs", mock.Anything, "p1#", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			_ = args.Get(2).(func([]string) error)([]string{"p1#0", "p1#1", "p1#2", "p1#3", "p1#4"})
+		})
+		vector.VectorStore.On("Delete", mock.Anything, []string{"p1#3", "p1#4"}).Return(nil).Once()
+		pipeline := NewPipeline(chunker.NewChunker(20, 0), mockEmbed, vector, mockText, 10, 1)
+
+		// 2. Act
+		err := pipeline.Process(context.Background(), doc, func(Progress) {})
+
+		// 3. Assert
+		assert.NoError(t, err)
+		vector.VectorStore.AssertExpectations(t)
+	})
+
+	t.Run("IndexError", func(t *testing.T) {
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, []storage.Document{doc}).Return(nil, errors.New("connection refused"))
+
+		pipeline := NewPipeline(chunker.NewChunker(20, 0), nil, nil, mockText, 10, 1)
+
+		err := pipeline.Process(context.Background(), doc, func(Progress) {})
+
+		assert.EqualError(t, err, "failed to index document: connection refused")
+	})
+}
+
+func TestPipeline_Store(t *testing.T) {
+	doc := storage.Document{DocumentID: "p1", Text: "First chunk text. Second chunk text. Third chunk text."}
+	created := []storage.IndexResult{{DocumentID: "p1", Created: true}}
+	unavailable := errors.New("unavailable")
+
+	t.Run("RetriesFailedSteps", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockVector := new(storage_mocks.VectorStore)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, mock.Anything).Return(nil, unavailable).Once()
+		mockText.On("IndexMany", mock.Anything, mock.Anything).Return(created, nil).Once()
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return(nil, unavailable).Once()
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(unavailable).Once()
+		mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		pipeline := NewPipeline(chunker.NewChunker(20, 0), mockEmbed, mockVector, mockText, 10, 1,
+			WithRetry(retry.Policy{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+
+		// 2. Act
+		result, err := pipeline.Store(context.Background(), doc, "")
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Equal(t, StoreResult{Chunks: 3}, result)
+		mockVector.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("AllOrNothingRemovesWrittenData", func(t *testing.T) {
+		// 1. Arrange: the first chunk fails; the rest are written and must be removed again.
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockVector := new(storage_mocks.VectorStore)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, mock.Anything).Return(created, nil)
+		mockEmbed.On("CreateEmbedding", mock.Anything, "First chunk text.").Return(nil, unavailable)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		mockVector.On("Delete", mock.Anything, []string{"p1#1", "p1#2"}).Return(nil).Once()
+		mockText.On("Delete", mock.Anything, "p1").Return(nil).Once()
+		history := newHistory(t)
+		pipeline := NewPipeline(chunker.NewChunker(20, 0), mockEmbed, mockVector, mockText, 10, 1, WithHistory(history))
+
+		// 2. Act
+		result, err := pipeline.Store(context.Background(), doc, AllOrNothing)
+
+		// 3. Assert
+		assert.EqualError(t, err, "1 of 3 chunks failed, so the document was removed: chunk p1#0: failed to create embedding: unavailable")
+		assert.Equal(t, 3, result.Chunks)
+		mockVector.AssertExpectations(t)
+		mockText.AssertExpectations(t)
+		listed, _ := history.List("p1")
+		assert.Empty(t, listed, "A removed document should not be versioned")
+	})
+
+	t.Run("PartialListsFailedChunks", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockVector := new(storage_mocks.VectorStore)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, mock.Anything).Return(created, nil)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(unavailable).Once()
+		mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		pipeline := NewPipeline(chunker.NewChunker(20, 0), mockEmbed, mockVector, mockText, 2, 1, WithFailurePolicy(Partial))
+
+		// 2. Act
+		result, err := pipeline.Store(context.Background(), doc, "")
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 3, result.Chunks)
+		assert.Len(t, result.Failed, 2)
+		assert.Equal(t, "chunk p1#0: failed to write chunk: unavailable", result.Failed[0].Error())
+		assert.Equal(t, "p1#1", result.Failed[1].ChunkID)
+		mockText.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RequestPolicyOverridesDefault", func(t *testing.T) {
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockVector := new(storage_mocks.VectorStore)
+		mockText := new(storage_mocks.TextStore)
+		mockText.On("IndexMany", mock.Anything, mock.Anything).Return(created, nil)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return(nil, unavailable)
+		pipeline := NewPipeline(chunker.NewChunker(20, 0), mockEmbed, mockVector, mockText, 10, 1)
+
+		result, err := pipeline.Store(context.Background(), doc, Partial)
+
+		assert.NoError(t, err)
+		assert.Len(t, result.Failed, 3)
+		mockText.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPipeline_WriteChunks(t *testing.T) {
+	doc := storage.Document{DocumentID: "p1", Text: "First chunk text. Second chunk text.", Metadata: map[string]string{"tag": "billing"}}
+
+	t.Run("WritesChunksWithMetadata", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockVector := new(storage_mocks.VectorStore)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVector.On("UpsertMany", mock.Anything, mock.MatchedBy(func(chunks []storage.Document) bool {
+			return len(chunks) == 2 && chunks[0].DocumentID == "p1#0" && chunks[1].Metadata["tag"] == "billing"
+		}), [][]float32{{0.1}, {0.1}}).Return(nil).Once()
+		pipeline := NewPipeline(chunker.NewChunker(20, 0), mockEmbed, mockVector, nil, 10, 1)
+
+		// 2. Act
+		count, err := pipeline.WriteChunks(context.Background(), doc)
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+		mockVector.AssertExpectations(t)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockEmbed := new(embedding_mocks.EmbeddingClient)
+		mockVector := new(storage_mocks.VectorStore)
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("quota exceeded"))
+		pipeline := NewPipeline(chunker.NewChunker(20, 0), mockEmbed, mockVector, nil, 10, 1)
+
+		_, err := pipeline.WriteChunks(context.Background(), doc)
+
+		assert.EqualError(t, err, "2 of 2 chunks failed: chunk p1#0: failed to write chunk: quota exceeded")
+	})
+}
@@ -0,0 +1,51 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	ingest "github.com/chr1sbest/hybrid-search/pkg/ingest"
+	mock "github.com/stretchr/testify/mock"
+
+	storage "github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// Service is an autogenerated mock type for the Service type
+type Service struct {
+	mock.Mock
+}
+
+// Ingest provides a mock function with given fields: ctx, docs
+func (_m *Service) Ingest(ctx context.Context, docs []storage.Document) []ingest.Result {
+	ret := _m.Called(ctx, docs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ingest")
+	}
+
+	var r0 []ingest.Result
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.Document) []ingest.Result); ok {
+		r0 = rf(ctx, docs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ingest.Result)
+		}
+	}
+
+	return r0
+}
+
+// NewService creates a new instance of Service. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Service {
+	mock := &Service{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,103 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// RemoteClient ingests documents through the POST /documents:batch endpoint of a running
+// server. It implements the Service interface, so tools can run against a server or the
+// in-process Pipeline alike.
+type RemoteClient struct {
+	client   *http.Client
+	endpoint string
+}
+
+// NewRemoteClient creates a client for the server at baseURL (for example
+// "http://localhost:8080"). Each call to Ingest sends one request, so callers must keep
+// batches within the server's BATCH_MAX_DOCUMENTS.
+func NewRemoteClient(baseURL string) *RemoteClient {
+	return &RemoteClient{
+		client:   http.DefaultClient,
+		endpoint: strings.TrimSuffix(baseURL, "/") + "/documents:batch",
+	}
+}
+
+// Ingest implements the Service interface. If the request fails as a whole, every document
+// is reported as failed with the reason.
+func (c *RemoteClient) Ingest(ctx context.Context, docs []storage.Document) []Result {
+	results, err := c.post(ctx, docs)
+	if err != nil {
+		results = make([]Result, len(docs))
+		for i, doc := range docs {
+			results[i] = Result{DocumentID: doc.DocumentID, Status: StatusFailed, Error: err.Error()}
+		}
+	}
+	return results
+}
+
+// post sends docs as one batch and returns the server's results.
+func (c *RemoteClient) post(ctx context.Context, docs []storage.Document) ([]Result, error) {
+	batch := api.BatchStoreRequest{Documents: make([]api.BatchDocument, len(docs))}
+	for i, doc := range docs {
+		batch.Documents[i] = api.BatchDocument{Id: doc.DocumentID, Text: doc.Text}
+		if doc.Title != "" {
+			batch.Documents[i].Title = &doc.Title
+		}
+		if len(doc.Metadata) > 0 {
+			batch.Documents[i].Metadata = &doc.Metadata
+		}
+	}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling batch request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating batch request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling batch endpoint: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("batch endpoint returned %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var batchRes api.BatchStoreResponse
+	if err := json.NewDecoder(res.Body).Decode(&batchRes); err != nil {
+		return nil, fmt.Errorf("error decoding batch response: %w", err)
+	}
+	if batchRes.Results == nil || len(*batchRes.Results) != len(docs) {
+		return nil, fmt.Errorf("batch endpoint returned results for a different number of documents")
+	}
+
+	results := make([]Result, len(docs))
+	for i, r := range *batchRes.Results {
+		results[i] = Result{DocumentID: docs[i].DocumentID, Status: StatusFailed}
+		if r.Status != nil {
+			results[i].Status = Status(*r.Status)
+		}
+		if r.Error != nil {
+			results[i].Error = *r.Error
+		}
+		if r.Version != nil {
+			results[i].Version = *r.Version
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,57 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteClient_Ingest(t *testing.T) {
+	t.Run("ReturnsServerResults", func(t *testing.T) {
+		// 1. Arrange
+		var received api.BatchStoreRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/documents:batch", r.URL.Path)
+			_ = json.NewDecoder(r.Body).Decode(&received)
+			created, failed := api.BatchResultStatusCreated, api.BatchResultStatusFailed
+			reason, version := "'text' cannot be empty", 3
+			json.NewEncoder(w).Encode(api.BatchStoreResponse{Results: &[]api.BatchResult{
+				{Id: &received.Documents[0].Id, Status: &created, Version: &version},
+				{Id: &received.Documents[1].Id, Status: &created},
+				{Id: &received.Documents[2].Id, Status: &failed, Error: &reason},
+			}})
+		}))
+		defer server.Close()
+
+		// 2. Act
+		results := NewRemoteClient(server.URL+"/").Ingest(context.Background(), testDocs)
+
+		// 3. Assert
+		assert.Equal(t, []Result{
+			{DocumentID: "a", Status: StatusCreated, Version: 3},
+			{DocumentID: "b", Status: StatusCreated},
+			{DocumentID: "c", Status: StatusFailed, Error: "'text' cannot be empty"},
+		}, results)
+		assert.Len(t, received.Documents, 3)
+		assert.Equal(t, map[string]string{"tag": "billing"}, *received.Documents[0].Metadata)
+		assert.Nil(t, received.Documents[1].Metadata)
+	})
+
+	t.Run("RequestFails", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Batch ingestion is not configured", http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		results := NewRemoteClient(server.URL).Ingest(context.Background(), testDocs[:1])
+
+		assert.Equal(t, []Result{
+			{DocumentID: "a", Status: StatusFailed, Error: "batch endpoint returned 503 Service Unavailable: Batch ingestion is not configured"},
+		}, results)
+	})
+}
@@ -0,0 +1,263 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// ErrQueueFull is returned by Submit when the queue has no room for another job.
+var ErrQueueFull = errors.New("ingestion queue is full")
+
+// maxPruneInterval is the longest time between removals of expired jobs.
+const maxPruneInterval = time.Hour
+
+// Status is the state of a job.
+type Status string
+
+const (
+	// StatusPending means the job is waiting in the queue.
+	StatusPending Status = "pending"
+	// StatusRunning means a worker is ingesting the document.
+	StatusRunning Status = "running"
+	// StatusSucceeded means the document and all of its chunks were stored.
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed means the document or some of its chunks could not be stored; Job.Error
+	// says why.
+	StatusFailed Status = "failed"
+)
+
+// Job is the ingestion of one document in the background.
+type Job struct {
+	ID     string `json:"id"`
+	Status Status `json:"status"`
+	// Document is the document to ingest. Its text is dropped once the job has finished.
+	Document storage.Document `json:"document"`
+	Progress ingest.Progress  `json:"progress"`
+	// Error is why the job failed. It is empty unless Status is StatusFailed.
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Finished reports whether the job has succeeded or failed.
+func (j Job) Finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// Processor ingests the document of a job, reporting its progress as it goes.
+type Processor interface {
+	Process(ctx context.Context, doc storage.Document, progress func(ingest.Progress)) error
+}
+
+// Manager queues ingestion jobs and runs them on a bounded pool of workers. Jobs are saved to
+// a Store whenever their status changes; progress is only kept in memory. It is safe for
+// concurrent use.
+type Manager struct {
+	store     Store
+	processor Processor
+	workers   int
+	retention time.Duration
+	queue     chan string
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// NewManager creates a Manager with workers workers and room for queueSize pending jobs.
+// Unfinished jobs in the store are queued again, oldest first; a job that was running when the
+// process stopped starts over, which is safe because chunk IDs are deterministic. Finished jobs
+// are kept for retention, or forever when retention is zero.
+func NewManager(store Store, processor Processor, workers, queueSize int, retention time.Duration) (*Manager, error) {
+	saved, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		store:     store,
+		processor: processor,
+		workers:   workers,
+		retention: retention,
+		jobs:      make(map[string]*Job, len(saved)),
+	}
+	var pending []*Job
+	for _, job := range saved {
+		if !job.Finished() {
+			job.Status = StatusPending
+			job.Progress = ingest.Progress{}
+			pending = append(pending, &job)
+		}
+		m.jobs[job.ID] = &job
+	}
+	slices.SortFunc(pending, func(a, b *Job) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	m.queue = make(chan string, max(queueSize, len(pending)))
+	for _, job := range pending {
+		m.queue <- job.ID
+	}
+	m.prune()
+	return m, nil
+}
+
+// Submit saves a job for doc and queues it.
+func (m *Manager) Submit(doc storage.Document) (Job, error) {
+	now := time.Now().UTC()
+	job := &Job{
+		ID:        uuid.New().String(),
+		Status:    StatusPending,
+		Document:  doc,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if len(m.queue) == cap(m.queue) {
+		return Job{}, ErrQueueFull
+	}
+	if err := m.store.Save(*job); err != nil {
+		return Job{}, err
+	}
+
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	m.mu.Unlock()
+
+	select {
+	case m.queue <- job.ID:
+		return *job, nil
+	default:
+		m.mu.Lock()
+		delete(m.jobs, job.ID)
+		m.mu.Unlock()
+		if err := m.store.Delete(job.ID); err != nil {
+			log.Printf("Failed to delete rejected job %s: %v", job.ID, err)
+		}
+		return Job{}, ErrQueueFull
+	}
+}
+
+// Get returns a copy of the job with the given ID.
+func (m *Manager) Get(id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Run processes queued jobs until ctx is cancelled, and waits for the workers to stop. A job
+// interrupted by cancellation is left unfinished in the store, to be run again on restart.
+func (m *Manager) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range m.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-m.queue:
+					m.process(ctx, id)
+				}
+			}
+		}()
+	}
+
+	if m.retention > 0 {
+		ticker := time.NewTicker(min(m.retention, maxPruneInterval))
+		defer ticker.Stop()
+	loop:
+		for {
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-ticker.C:
+				m.prune()
+			}
+		}
+	}
+	wg.Wait()
+}
+
+// process runs the job with the given ID.
+func (m *Manager) process(ctx context.Context, id string) {
+	doc, ok := m.transition(id, func(job *Job) { job.Status = StatusRunning })
+	if !ok {
+		return
+	}
+
+	err := m.processor.Process(ctx, doc, func(progress ingest.Progress) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if job, ok := m.jobs[id]; ok {
+			job.Progress = progress
+			job.UpdatedAt = time.Now().UTC()
+		}
+	})
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.transition(id, func(job *Job) {
+		job.Status = StatusSucceeded
+		if err != nil {
+			job.Status = StatusFailed
+			job.Error = err.Error()
+			log.Printf("Ingestion job %s failed: %v", id, err)
+		}
+		job.Document.Text = ""
+	})
+}
+
+// transition applies change to a job and saves it. It returns the job's document, and false
+// if the job no longer exists.
+func (m *Manager) transition(id string, change func(job *Job)) (storage.Document, bool) {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	if !ok {
+		m.mu.Unlock()
+		return storage.Document{}, false
+	}
+	change(job)
+	job.UpdatedAt = time.Now().UTC()
+	snapshot := *job
+	m.mu.Unlock()
+
+	if err := m.store.Save(snapshot); err != nil {
+		log.Printf("Failed to save job %s: %v", id, err)
+	}
+	return snapshot.Document, true
+}
+
+// prune removes finished jobs that are older than the retention period.
+func (m *Manager) prune() {
+	if m.retention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-m.retention)
+
+	m.mu.Lock()
+	var expired []string
+	for id, job := range m.jobs {
+		if job.Finished() && job.UpdatedAt.Before(cutoff) {
+			expired = append(expired, id)
+			delete(m.jobs, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, id := range expired {
+		if err := m.store.Delete(id); err != nil {
+			log.Printf("Failed to delete expired job %s: %v", id, err)
+		}
+	}
+}
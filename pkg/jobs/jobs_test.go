@@ -0,0 +1,135 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	jobs_mocks "github.com/chr1sbest/hybrid-search/pkg/jobs/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// waitFinished polls until the job has finished or a second has passed.
+func waitFinished(t *testing.T, m *Manager, id string) Job {
+	t.Helper()
+	var job Job
+	assert.Eventually(t, func() bool {
+		job, _ = m.Get(id)
+		return job.Finished()
+	}, time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestManager(t *testing.T) {
+	doc := storage.Document{DocumentID: "p1", Text: "Invoices are emailed monthly."}
+
+	t.Run("RunsSubmittedJobs", func(t *testing.T) {
+		// 1. Arrange
+		store, err := NewFileStore(t.TempDir())
+		assert.NoError(t, err)
+		mockProcessor := new(jobs_mocks.Processor)
+		mockProcessor.On("Process", mock.Anything, doc, mock.Anything).
+			Run(func(args mock.Arguments) {
+				args.Get(2).(func(ingest.Progress))(ingest.Progress{Total: 2, Embedded: 2, Written: 2})
+			}).
+			Return(nil).Once()
+		mockProcessor.On("Process", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("1 of 2 chunks failed")).Once()
+
+		manager, err := NewManager(store, mockProcessor, 1, 10, 0)
+		assert.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// 2. Act
+		succeeded, err := manager.Submit(doc)
+		assert.NoError(t, err)
+		failed, err := manager.Submit(storage.Document{DocumentID: "p2", Text: "Exports are CSV."})
+		assert.NoError(t, err)
+		go manager.Run(ctx)
+
+		// 3. Assert
+		assert.Equal(t, StatusPending, succeeded.Status)
+		job := waitFinished(t, manager, succeeded.ID)
+		assert.Equal(t, StatusSucceeded, job.Status)
+		assert.Equal(t, ingest.Progress{Total: 2, Embedded: 2, Written: 2}, job.Progress)
+		assert.Empty(t, job.Document.Text)
+		assert.Equal(t, "p1", job.Document.DocumentID)
+
+		job = waitFinished(t, manager, failed.ID)
+		assert.Equal(t, StatusFailed, job.Status)
+		assert.Equal(t, "1 of 2 chunks failed", job.Error)
+
+		saved, err := store.Load()
+		assert.NoError(t, err)
+		assert.Len(t, saved, 2)
+	})
+
+	t.Run("ResumesUnfinishedJobs", func(t *testing.T) {
+		// 1. Arrange
+		store, err := NewFileStore(t.TempDir())
+		assert.NoError(t, err)
+		now := time.Now().UTC()
+		assert.NoError(t, store.Save(Job{ID: "running", Status: StatusRunning, Document: doc, Progress: ingest.Progress{Total: 2, Embedded: 1}, CreatedAt: now}))
+		assert.NoError(t, store.Save(Job{ID: "done", Status: StatusSucceeded, Document: storage.Document{DocumentID: "p0"}, CreatedAt: now, UpdatedAt: now}))
+		assert.NoError(t, store.Save(Job{ID: "expired", Status: StatusFailed, CreatedAt: now.Add(-48 * time.Hour), UpdatedAt: now.Add(-48 * time.Hour)}))
+		mockProcessor := new(jobs_mocks.Processor)
+		mockProcessor.On("Process", mock.Anything, doc, mock.Anything).Return(nil).Once()
+
+		// 2. Act
+		manager, err := NewManager(store, mockProcessor, 2, 10, 24*time.Hour)
+		assert.NoError(t, err)
+		resumed, _ := manager.Get("running")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go manager.Run(ctx)
+
+		// 3. Assert
+		assert.Equal(t, StatusPending, resumed.Status)
+		assert.Equal(t, ingest.Progress{}, resumed.Progress)
+		assert.Equal(t, StatusSucceeded, waitFinished(t, manager, "running").Status)
+		_, ok := manager.Get("done")
+		assert.True(t, ok)
+		_, ok = manager.Get("expired")
+		assert.False(t, ok)
+		mockProcessor.AssertExpectations(t)
+	})
+
+	t.Run("QueueFull", func(t *testing.T) {
+		store, err := NewFileStore(t.TempDir())
+		assert.NoError(t, err)
+		manager, err := NewManager(store, new(jobs_mocks.Processor), 1, 1, 0)
+		assert.NoError(t, err)
+
+		_, err = manager.Submit(doc)
+		assert.NoError(t, err)
+		_, err = manager.Submit(doc)
+		assert.ErrorIs(t, err, ErrQueueFull)
+
+		saved, err := store.Load()
+		assert.NoError(t, err)
+		assert.Len(t, saved, 1)
+	})
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	assert.NoError(t, err)
+	job := Job{ID: "j1", Status: StatusPending, Document: storage.Document{DocumentID: "p1", Text: "text"}, CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	assert.NoError(t, store.Save(job))
+	job.Status = StatusRunning
+	assert.NoError(t, store.Save(job))
+	saved, err := store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []Job{job}, saved)
+
+	assert.NoError(t, store.Delete("j1"))
+	assert.NoError(t, store.Delete("j1"))
+	saved, err = store.Load()
+	assert.NoError(t, err)
+	assert.Empty(t, saved)
+}
@@ -0,0 +1,50 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	ingest "github.com/chr1sbest/hybrid-search/pkg/ingest"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// Processor is an autogenerated mock type for the Processor type
+type Processor struct {
+	mock.Mock
+}
+
+// Process provides a mock function with given fields: ctx, doc, progress
+func (_m *Processor) Process(ctx context.Context, doc storage.Document, progress func(ingest.Progress)) error {
+	ret := _m.Called(ctx, doc, progress)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Process")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Document, func(ingest.Progress)) error); ok {
+		r0 = rf(ctx, doc, progress)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewProcessor creates a new instance of Processor. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewProcessor(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Processor {
+	mock := &Processor{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
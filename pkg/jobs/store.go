@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store persists jobs so that pending work survives restarts.
+type Store interface {
+	// Save adds or replaces a job.
+	Save(job Job) error
+	// Delete removes a job. Deleting a job that doesn't exist is not an error.
+	Delete(id string) error
+	// Load returns every saved job.
+	Load() ([]Job, error)
+}
+
+// FileStore is a Store that keeps each job as a JSON file in a directory.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore in dir, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create jobs directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Save implements the Store interface. The file is written to a temporary name and renamed
+// into place, so a crash never leaves a partially written job.
+func (s *FileStore) Save(job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("error marshalling job %s: %w", job.ID, err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, job.ID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error saving job %s: %w", job.ID, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error saving job %s: %w", job.ID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error saving job %s: %w", job.ID, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(job.ID)); err != nil {
+		return fmt.Errorf("error saving job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// Delete implements the Store interface.
+func (s *FileStore) Delete(id string) error {
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error deleting job %s: %w", id, err)
+	}
+	return nil
+}
+
+// Load implements the Store interface.
+func (s *FileStore) Load() ([]Job, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs directory: %w", err)
+	}
+
+	var jobs []Job
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading job file %s: %w", entry.Name(), err)
+		}
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil {
+			return nil, fmt.Errorf("error parsing job file %s: %w", entry.Name(), err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
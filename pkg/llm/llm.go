@@ -0,0 +1,198 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Chat message roles.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is a single chat message.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatClient is the interface for any chat-completion model.
+// This allows for a pluggable language model.
+type ChatClient interface {
+	// Complete sends the conversation to the model and returns its reply.
+	Complete(ctx context.Context, messages []Message) (string, error)
+	// Stream sends the conversation to the model and calls onToken with each piece of the
+	// reply as it is generated. It returns the full reply. Streaming stops with an error if
+	// onToken returns one.
+	Stream(ctx context.Context, messages []Message, onToken func(token string) error) (string, error)
+}
+
+// OpenAIClient talks to an OpenAI-compatible /chat/completions endpoint, such as the ones
+// served by vLLM, Ollama or llama.cpp. It implements the ChatClient interface.
+type OpenAIClient struct {
+	client   *http.Client
+	endpoint string
+	model    string
+	apiKey   string
+}
+
+// NewOpenAIClient creates a client for the /chat/completions endpoint under baseURL
+// (for example "http://localhost:11434/v1"). apiKey is optional.
+func NewOpenAIClient(baseURL, model, apiKey string) *OpenAIClient {
+	return &OpenAIClient{
+		client:   http.DefaultClient,
+		endpoint: strings.TrimSuffix(baseURL, "/") + "/chat/completions",
+		model:    model,
+		apiKey:   apiKey,
+	}
+}
+
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+	Stream      bool      `json:"stream,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+}
+
+type chatStreamChunk struct {
+	Choices []struct {
+		Delta Message `json:"delta"`
+	} `json:"choices"`
+}
+
+// Complete sends the conversation to the endpoint and returns the first choice's content.
+// Requests use a temperature of 0 so replies are as repeatable as the backend allows.
+func (c *OpenAIClient) Complete(ctx context.Context, messages []Message) (string, error) {
+	res, err := c.post(ctx, chatRequest{Model: c.model, Messages: messages})
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var completion chatResponse
+	if err := json.NewDecoder(res.Body).Decode(&completion); err != nil {
+		return "", fmt.Errorf("error parsing chat response: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("chat response has no choices")
+	}
+	return completion.Choices[0].Message.Content, nil
+}
+
+// Stream requests a streamed completion and reads the server-sent events as they arrive,
+// passing each content delta of the first choice to onToken.
+func (c *OpenAIClient) Stream(ctx context.Context, messages []Message, onToken func(token string) error) (string, error) {
+	res, err := c.post(ctx, chatRequest{Model: c.model, Messages: messages, Stream: true})
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var reply strings.Builder
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return reply.String(), fmt.Errorf("error parsing chat stream chunk: %w", err)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		token := chunk.Choices[0].Delta.Content
+		reply.WriteString(token)
+		if err := onToken(token); err != nil {
+			return reply.String(), err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return reply.String(), fmt.Errorf("error reading chat stream: %w", err)
+	}
+	return reply.String(), nil
+}
+
+// post sends a chat request and returns the response if the endpoint accepted it.
+// The caller must close the response body.
+func (c *OpenAIClient) post(ctx context.Context, chatReq chatRequest) (*http.Response, error) {
+	body, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling chat request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating chat request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling chat endpoint: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("chat endpoint returned %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+	return res, nil
+}
+
+// charsPerToken is the rough number of characters per token for English text.
+// It is used to budget prompts without depending on a model-specific tokenizer.
+const charsPerToken = 4
+
+// EstimateTokens returns an approximate token count for text.
+func EstimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// TruncateToTokens shortens text to roughly maxTokens tokens, cutting at a word boundary
+// where possible and marking the cut with an ellipsis.
+func TruncateToTokens(text string, maxTokens int) string {
+	maxChars := maxTokens * charsPerToken
+	if len(text) <= maxChars {
+		return text
+	}
+	if maxChars <= 0 {
+		return ""
+	}
+
+	cut := text[:maxChars]
+	if i := strings.LastIndexAny(cut, " \n\t"); i > maxChars/2 {
+		cut = cut[:i]
+	}
+	// Avoid splitting a multi-byte character.
+	for !utf8.ValidString(cut) {
+		cut = cut[:len(cut)-1]
+	}
+	return strings.TrimSpace(cut) + "…"
+}
@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAIClient_Complete(t *testing.T) {
+	t.Run("ReturnsFirstChoice", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+			assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+
+			var req chatRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "local-model", req.Model)
+			assert.Equal(t, []Message{{Role: RoleUser, Content: "hello"}}, req.Messages)
+
+			w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "hi there"}}]}`))
+		}))
+		defer server.Close()
+
+		client := NewOpenAIClient(server.URL+"/v1/", "local-model", "key")
+		reply, err := client.Complete(context.Background(), []Message{{Role: RoleUser, Content: "hello"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "hi there", reply)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for name, handler := range map[string]http.HandlerFunc{
+			"ServerError": func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) },
+			"NoChoices":   func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"choices": []}`)) },
+			"Malformed":   func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{`)) },
+		} {
+			t.Run(name, func(t *testing.T) {
+				server := httptest.NewServer(handler)
+				defer server.Close()
+
+				_, err := NewOpenAIClient(server.URL, "m", "").Complete(context.Background(), nil)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestOpenAIClient_Stream(t *testing.T) {
+	t.Run("ForwardsDeltas", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req chatRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.True(t, req.Stream)
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: {\"choices\": [{\"delta\": {\"role\": \"assistant\"}}]}\n\n"))
+			w.Write([]byte("data: {\"choices\": [{\"delta\": {\"content\": \"Hel\"}}]}\n\n"))
+			w.Write([]byte(": keep-alive\n\n"))
+			w.Write([]byte("data: {\"choices\": [{\"delta\": {\"content\": \"lo\"}}]}\n\n"))
+			w.Write([]byte("data: [DONE]\n\n"))
+		}))
+		defer server.Close()
+
+		var tokens []string
+		reply, err := NewOpenAIClient(server.URL, "m", "").Stream(context.Background(), nil, func(token string) error {
+			tokens = append(tokens, token)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello", reply)
+		assert.Equal(t, []string{"Hel", "lo"}, tokens)
+	})
+
+	t.Run("StopsWhenCallbackFails", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("data: {\"choices\": [{\"delta\": {\"content\": \"a\"}}]}\n\n"))
+			w.Write([]byte("data: {\"choices\": [{\"delta\": {\"content\": \"b\"}}]}\n\n"))
+		}))
+		defer server.Close()
+
+		calls := 0
+		_, err := NewOpenAIClient(server.URL, "m", "").Stream(context.Background(), nil, func(string) error {
+			calls++
+			return errors.New("client went away")
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("ServerError", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		_, err := NewOpenAIClient(server.URL, "m", "").Stream(context.Background(), nil, func(string) error { return nil })
+		assert.Error(t, err)
+	})
+}
+
+func TestTruncateToTokens(t *testing.T) {
+	assert.Equal(t, "short", TruncateToTokens("short", 10))
+	assert.Equal(t, "", TruncateToTokens("anything", 0))
+
+	long := strings.Repeat("word ", 100)
+	truncated := TruncateToTokens(long, 10)
+	assert.LessOrEqual(t, len(truncated), 10*charsPerToken+len("…"))
+	assert.True(t, strings.HasSuffix(truncated, "word…"), "Should cut at a word boundary")
+
+	multiByte := strings.Repeat("é", 50)
+	assert.True(t, utf8.ValidString(TruncateToTokens(multiByte, 3)))
+}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, EstimateTokens(""))
+	assert.Equal(t, 1, EstimateTokens("abc"))
+	assert.Equal(t, 3, EstimateTokens("twelve chars"))
+}
@@ -0,0 +1,85 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	llm "github.com/chr1sbest/hybrid-search/pkg/llm"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ChatClient is an autogenerated mock type for the ChatClient type
+type ChatClient struct {
+	mock.Mock
+}
+
+// Complete provides a mock function with given fields: ctx, messages
+func (_m *ChatClient) Complete(ctx context.Context, messages []llm.Message) (string, error) {
+	ret := _m.Called(ctx, messages)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Complete")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []llm.Message) (string, error)); ok {
+		return rf(ctx, messages)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []llm.Message) string); ok {
+		r0 = rf(ctx, messages)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []llm.Message) error); ok {
+		r1 = rf(ctx, messages)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Stream provides a mock function with given fields: ctx, messages, onToken
+func (_m *ChatClient) Stream(ctx context.Context, messages []llm.Message, onToken func(string) error) (string, error) {
+	ret := _m.Called(ctx, messages, onToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Stream")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []llm.Message, func(string) error) (string, error)); ok {
+		return rf(ctx, messages, onToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []llm.Message, func(string) error) string); ok {
+		r0 = rf(ctx, messages, onToken)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []llm.Message, func(string) error) error); ok {
+		r1 = rf(ctx, messages, onToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewChatClient creates a new instance of ChatClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewChatClient(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ChatClient {
+	mock := &ChatClient{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,101 @@
+package loader
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Checkpoint records the IDs of the documents that have been stored, so an interrupted load
+// can resume without storing them again. IDs are appended to a file, one JSON string per
+// line, and synced before Mark returns. It is safe for concurrent use.
+type Checkpoint struct {
+	mu   sync.Mutex
+	file *os.File
+	done map[string]bool
+}
+
+// OpenCheckpoint opens the checkpoint at path, creating it if needed, and loads the IDs it
+// holds.
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+
+	c := &Checkpoint{file: file, done: make(map[string]bool)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLLine)
+	for scanner.Scan() {
+		// A line cut short by a crash is ignored. Its document is simply stored again.
+		var id string
+		if json.Unmarshal(scanner.Bytes(), &id) == nil {
+			c.done[id] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading checkpoint: %w", err)
+	}
+
+	// Start a new line if the file ends in a partial one.
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		buf := make([]byte, 1)
+		if _, err := file.ReadAt(buf, info.Size()-1); err == nil && buf[0] != '\n' {
+			if _, err := file.Write([]byte("\n")); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("error writing checkpoint: %w", err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// Done reports whether the document with the given ID has been stored.
+func (c *Checkpoint) Done(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done[id]
+}
+
+// Len returns the number of documents recorded as stored.
+func (c *Checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.done)
+}
+
+// Mark records that the documents with the given IDs have been stored.
+func (c *Checkpoint) Mark(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var buf []byte
+	for _, id := range ids {
+		line, err := json.Marshal(id)
+		if err != nil {
+			return fmt.Errorf("error marshalling checkpoint ID: %w", err)
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.file.Write(buf); err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	if err := c.file.Sync(); err != nil {
+		return fmt.Errorf("error syncing checkpoint: %w", err)
+	}
+	for _, id := range ids {
+		c.done[id] = true
+	}
+	return nil
+}
+
+// Close closes the checkpoint file.
+func (c *Checkpoint) Close() error {
+	return c.file.Close()
+}
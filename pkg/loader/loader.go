@@ -0,0 +1,144 @@
+package loader
+
+import (
+	"cmp"
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"golang.org/x/sync/errgroup"
+)
+
+// Options configures Load.
+type Options struct {
+	// BatchSize is the number of documents sent to the service at once.
+	BatchSize int
+	// Concurrency is the number of batches in flight at once.
+	Concurrency int
+	// Checkpoint, if set, skips the documents it holds and records each document once it is
+	// stored.
+	Checkpoint *Checkpoint
+	// Progress, if set, is called with the running totals after each batch. Calls are not
+	// concurrent.
+	Progress func(Stats)
+}
+
+// Stats counts the documents seen by Load.
+type Stats struct {
+	// Read counts every document read from the source, including skipped ones.
+	Read int
+	// Skipped counts the documents the checkpoint already holds.
+	Skipped int
+	Created int
+	Updated int
+	// Failed counts the documents that were invalid or could not be stored. They are not
+	// checkpointed, so resuming the load tries them again.
+	Failed int
+}
+
+// Load reads every document from src and stores it with svc, in batches that run
+// concurrently. Documents that fail are logged and counted, and don't stop the load. It
+// returns early if the source can't be read, the checkpoint can't be written, or ctx is
+// cancelled.
+func Load(ctx context.Context, src Source, svc ingest.Service, opts Options) (Stats, error) {
+	batchSize := max(opts.BatchSize, 1)
+
+	var mu sync.Mutex
+	var stats Stats
+	// seen holds the IDs read so far, to catch duplicates, which would overwrite each other.
+	seen := make(map[string]bool)
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(opts.Concurrency, 1))
+	store := func(batch []storage.Document) {
+		g.Go(func() error {
+			results := svc.Ingest(ctx, batch)
+
+			var stored []string
+			mu.Lock()
+			defer mu.Unlock()
+			for _, result := range results {
+				switch result.Status {
+				case ingest.StatusCreated:
+					stats.Created++
+				case ingest.StatusUpdated:
+					stats.Updated++
+				default:
+					stats.Failed++
+					log.Printf("Failed to store document %s: %s", result.DocumentID, result.Error)
+					continue
+				}
+				stored = append(stored, result.DocumentID)
+			}
+			if opts.Checkpoint != nil {
+				if err := opts.Checkpoint.Mark(stored); err != nil {
+					return err
+				}
+			}
+			if opts.Progress != nil {
+				opts.Progress(stats)
+			}
+			return nil
+		})
+	}
+
+	var batch []storage.Document
+	readErr := src.Read(func(doc storage.Document) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		stats.Read++
+		reason := validate(doc, seen)
+		seen[doc.DocumentID] = true
+		skip := reason == "" && opts.Checkpoint != nil && opts.Checkpoint.Done(doc.DocumentID)
+		switch {
+		case reason != "":
+			stats.Failed++
+			log.Printf("Skipping document %s: %s", doc.DocumentID, reason)
+		case skip:
+			stats.Skipped++
+		}
+		mu.Unlock()
+		if reason != "" || skip {
+			return nil
+		}
+
+		batch = append(batch, doc)
+		if len(batch) == batchSize {
+			store(batch)
+			batch = nil
+		}
+		return nil
+	})
+	if readErr == nil && len(batch) > 0 {
+		store(batch)
+	}
+
+	// A failed batch cancels ctx, which stops the read, so its error is the one to report.
+	err := cmp.Or(g.Wait(), readErr)
+	mu.Lock()
+	defer mu.Unlock()
+	return stats, err
+}
+
+// validate returns why doc cannot be stored, or "" if it can. seen holds the IDs read before
+// it. The rules match those of POST /documents:batch.
+func validate(doc storage.Document, seen map[string]bool) string {
+	switch {
+	case strings.TrimSpace(doc.DocumentID) == "":
+		return "the ID is empty"
+	case strings.Contains(doc.DocumentID, "#"):
+		// Chunk IDs are the parent ID followed by "#" and the chunk number.
+		return "the ID contains '#'"
+	case seen[doc.DocumentID]:
+		return "the ID was already used by another document"
+	case strings.TrimSpace(doc.Text) == "":
+		return "the text is empty"
+	}
+	return ""
+}
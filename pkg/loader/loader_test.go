@@ -0,0 +1,205 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	ingest_mocks "github.com/chr1sbest/hybrid-search/pkg/ingest/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// writeFile writes content to name in dir, creating parent directories, and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// readAll returns every document src reads.
+func readAll(t *testing.T, src Source) []storage.Document {
+	t.Helper()
+	var docs []storage.Document
+	assert.NoError(t, src.Read(func(doc storage.Document) error {
+		docs = append(docs, doc)
+		return nil
+	}))
+	return docs
+}
+
+func TestJSONLSource(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "faq.jsonl", `{"title": " Billing ", "text": "Invoices are emailed monthly.", "metadata": {"tag": "billing"}}
+
+{"id": "reset", "text": "Passwords can be reset from settings."}
+`)
+
+	docs := readAll(t, JSONLSource{Path: path})
+
+	assert.Equal(t, []storage.Document{
+		{DocumentID: "faq-1", Title: "Billing", Text: "Invoices are emailed monthly.", Metadata: map[string]string{"tag": "billing"}},
+		{DocumentID: "reset", Text: "Passwords can be reset from settings."},
+	}, docs)
+
+	t.Run("InvalidLine", func(t *testing.T) {
+		path := writeFile(t, t.TempDir(), "bad.jsonl", "{\"text\": \"ok\"}\nnot json\n")
+
+		err := JSONLSource{Path: path}.Read(func(storage.Document) error { return nil })
+
+		assert.ErrorContains(t, err, "bad.jsonl:2:")
+	})
+}
+
+func TestCSVSource(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "articles.csv", "slug,heading,body,tag,team\n"+
+		"billing,Billing,Invoices are emailed monthly.,billing,\n"+
+		"reset,Passwords,\"Passwords can be reset\nfrom settings.\",,accounts\n")
+
+	t.Run("MapsColumns", func(t *testing.T) {
+		docs := readAll(t, CSVSource{Path: path, Columns: Columns{ID: "slug", Title: "heading", Text: "body", Metadata: []string{"tag", "team"}}})
+
+		assert.Equal(t, []storage.Document{
+			{DocumentID: "billing", Title: "Billing", Text: "Invoices are emailed monthly.", Metadata: map[string]string{"tag": "billing"}},
+			{DocumentID: "reset", Title: "Passwords", Text: "Passwords can be reset\nfrom settings.", Metadata: map[string]string{"team": "accounts"}},
+		}, docs)
+	})
+
+	t.Run("DerivesIDs", func(t *testing.T) {
+		docs := readAll(t, CSVSource{Path: path, Columns: Columns{Text: "body"}})
+
+		assert.Equal(t, "articles-1", docs[0].DocumentID)
+		assert.Equal(t, "articles-2", docs[1].DocumentID)
+	})
+
+	t.Run("UnknownColumn", func(t *testing.T) {
+		err := CSVSource{Path: path, Columns: Columns{Text: "content"}}.Read(func(storage.Document) error { return nil })
+
+		assert.EqualError(t, err, path+` has no column "content"`)
+	})
+}
+
+func TestDirSource(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "guides/setup.md", "Intro\n# Getting Started\nInstall it.")
+	writeFile(t, root, "notes.txt", "Plain notes.")
+	writeFile(t, root, "c#/intro.markdown", "No heading.")
+	writeFile(t, root, "image.png", "binary")
+	writeFile(t, root, ".git/HEAD.txt", "ignored")
+
+	docs := readAll(t, DirSource{Root: root})
+
+	assert.Equal(t, []storage.Document{
+		{DocumentID: "c%23/intro.markdown", Title: "intro", Text: "No heading.", Metadata: map[string]string{"path": "c#/intro.markdown"}},
+		{DocumentID: "guides/setup.md", Title: "Getting Started", Text: "Intro\n# Getting Started\nInstall it.", Metadata: map[string]string{"path": "guides/setup.md"}},
+		{DocumentID: "notes.txt", Title: "notes", Text: "Plain notes.", Metadata: map[string]string{"path": "notes.txt"}},
+	}, docs)
+}
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "load.checkpoint")
+	checkpoint, err := OpenCheckpoint(path)
+	assert.NoError(t, err)
+	assert.NoError(t, checkpoint.Mark([]string{"a", "line\nbreak"}))
+	assert.NoError(t, checkpoint.Close())
+
+	// Simulate a crash partway through writing an ID.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	assert.NoError(t, err)
+	_, _ = f.WriteString(`"parti`)
+	f.Close()
+
+	checkpoint, err = OpenCheckpoint(path)
+	assert.NoError(t, err)
+	assert.NoError(t, checkpoint.Mark([]string{"b"}))
+	assert.NoError(t, checkpoint.Close())
+
+	checkpoint, err = OpenCheckpoint(path)
+	assert.NoError(t, err)
+	defer checkpoint.Close()
+	assert.Equal(t, 3, checkpoint.Len())
+	assert.True(t, checkpoint.Done("line\nbreak"))
+	assert.True(t, checkpoint.Done("b"))
+	assert.False(t, checkpoint.Done("parti"))
+}
+
+// docsSource is a Source that reads a fixed list of documents.
+type docsSource []storage.Document
+
+func (s docsSource) Read(fn func(doc storage.Document) error) error {
+	for _, doc := range s {
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestLoad(t *testing.T) {
+	src := docsSource{
+		{DocumentID: "a", Text: "Invoices are emailed monthly."},
+		{DocumentID: "b", Text: "Passwords can be reset from settings."},
+		{DocumentID: "c", Text: "Exports are available as CSV."},
+		{DocumentID: "a", Text: "A duplicate."},
+		{DocumentID: "d", Text: " "},
+	}
+
+	t.Run("StoresAndResumes", func(t *testing.T) {
+		// 1. Arrange
+		checkpoint, err := OpenCheckpoint(filepath.Join(t.TempDir(), "load.checkpoint"))
+		assert.NoError(t, err)
+		defer checkpoint.Close()
+		assert.NoError(t, checkpoint.Mark([]string{"a"}))
+
+		mockIngest := new(ingest_mocks.Service)
+		mockIngest.On("Ingest", mock.Anything, []storage.Document{src[1], src[2]}).Return([]ingest.Result{
+			{DocumentID: "b", Status: ingest.StatusCreated},
+			{DocumentID: "c", Status: ingest.StatusFailed, Error: "unavailable"},
+		})
+		var progress []Stats
+
+		// 2. Act
+		stats, err := Load(context.Background(), src, mockIngest, Options{
+			BatchSize:   2,
+			Concurrency: 2,
+			Checkpoint:  checkpoint,
+			Progress:    func(s Stats) { progress = append(progress, s) },
+		})
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Equal(t, Stats{Read: 5, Skipped: 1, Created: 1, Failed: 3}, stats)
+		assert.Equal(t, []Stats{stats}, progress)
+		assert.True(t, checkpoint.Done("b"))
+		assert.False(t, checkpoint.Done("c"))
+		mockIngest.AssertExpectations(t)
+	})
+
+	t.Run("BatchesConcurrently", func(t *testing.T) {
+		mockIngest := new(ingest_mocks.Service)
+		mockIngest.On("Ingest", mock.Anything, mock.Anything).Return(func(_ context.Context, docs []storage.Document) []ingest.Result {
+			results := make([]ingest.Result, len(docs))
+			for i, doc := range docs {
+				results[i] = ingest.Result{DocumentID: doc.DocumentID, Status: ingest.StatusUpdated}
+			}
+			return results
+		})
+
+		stats, err := Load(context.Background(), src[:3], mockIngest, Options{BatchSize: 1, Concurrency: 3})
+
+		assert.NoError(t, err)
+		assert.Equal(t, Stats{Read: 3, Updated: 3}, stats)
+		mockIngest.AssertNumberOfCalls(t, "Ingest", 3)
+	})
+
+	t.Run("ReadError", func(t *testing.T) {
+		_, err := Load(context.Background(), JSONLSource{Path: filepath.Join(t.TempDir(), "missing.jsonl")}, new(ingest_mocks.Service), Options{})
+
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	})
+}
@@ -0,0 +1,256 @@
+package loader
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/chr1sbest/hybrid-search/api"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// Source reads documents for loading.
+type Source interface {
+	// Read calls fn with each document, always in the same order, until every document has
+	// been read or fn returns an error, which is then returned. Document IDs are stable
+	// across reads of the same input, so a load can be resumed and repeated.
+	Read(fn func(doc storage.Document) error) error
+}
+
+// JSONLSource reads a file with one JSON StoreRequest per line. A line may also set "id";
+// otherwise the document's ID is the file name and line number, such as "faq-12". Blank
+// lines are skipped.
+type JSONLSource struct {
+	Path string
+}
+
+// jsonlRecord is a line of a JSONL file.
+type jsonlRecord struct {
+	ID string `json:"id"`
+	api.StoreRequest
+}
+
+// maxJSONLLine is the longest line a JSONL file may have.
+const maxJSONLLine = 64 << 20
+
+// Read implements the Source interface.
+func (s JSONLSource) Read(fn func(doc storage.Document) error) error {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLLine)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var record jsonlRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("%s:%d: %w", s.Path, line, err)
+		}
+
+		doc := storage.Document{DocumentID: record.ID, Text: record.Text}
+		if doc.DocumentID == "" {
+			doc.DocumentID = derivedID(s.Path, line)
+		}
+		if record.Title != nil {
+			doc.Title = strings.TrimSpace(*record.Title)
+		}
+		if record.Metadata != nil {
+			doc.Metadata = *record.Metadata
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading %s: %w", s.Path, err)
+	}
+	return nil
+}
+
+// Columns maps the columns of a CSV file, by header name, to document fields.
+type Columns struct {
+	// Text is the column holding the document's text. It is required.
+	Text string
+	// ID is the column holding the document's ID. Without one, the ID is the file name and
+	// row number, such as "faq-12".
+	ID string
+	// Title is the column holding the document's title, if any.
+	Title string
+	// Metadata lists the columns stored as metadata, under their header names. Empty values
+	// are left out.
+	Metadata []string
+}
+
+// CSVSource reads a CSV file whose first row names its columns.
+type CSVSource struct {
+	Path    string
+	Columns Columns
+}
+
+// Read implements the Source interface.
+func (s CSVSource) Read(fn func(doc storage.Document) error) error {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("error reading the header of %s: %w", s.Path, err)
+	}
+	index := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		if i := slices.Index(header, name); i >= 0 {
+			return i, nil
+		}
+		return -1, fmt.Errorf("%s has no column %q", s.Path, name)
+	}
+
+	if s.Columns.Text == "" {
+		return errors.New("a text column is required")
+	}
+	textCol, err := index(s.Columns.Text)
+	if err != nil {
+		return err
+	}
+	idCol, err := index(s.Columns.ID)
+	if err != nil {
+		return err
+	}
+	titleCol, err := index(s.Columns.Title)
+	if err != nil {
+		return err
+	}
+	metadataCols := make([]int, len(s.Columns.Metadata))
+	for i, name := range s.Columns.Metadata {
+		if metadataCols[i], err = index(name); err != nil {
+			return err
+		}
+	}
+
+	for row := 1; ; row++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", s.Path, err)
+		}
+
+		doc := storage.Document{Text: record[textCol]}
+		if idCol >= 0 {
+			doc.DocumentID = record[idCol]
+		} else {
+			doc.DocumentID = derivedID(s.Path, row)
+		}
+		if titleCol >= 0 {
+			doc.Title = strings.TrimSpace(record[titleCol])
+		}
+		for i, col := range metadataCols {
+			if record[col] == "" {
+				continue
+			}
+			if doc.Metadata == nil {
+				doc.Metadata = make(map[string]string)
+			}
+			doc.Metadata[s.Columns.Metadata[i]] = record[col]
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+}
+
+// DirSource reads the text and Markdown files under a directory, in lexical order. Hidden
+// files and directories are skipped. A document's ID is its path relative to Root, with
+// forward slashes, and its path is also stored as the "path" metadata. Markdown documents
+// are titled with their first top-level heading; others with their file name.
+type DirSource struct {
+	Root string
+}
+
+// dirExtensions are the extensions of the files DirSource reads, and whether they are
+// Markdown.
+var dirExtensions = map[string]bool{".txt": false, ".text": false, ".md": true, ".markdown": true}
+
+// Read implements the Source interface.
+func (s DirSource) Read(fn func(doc storage.Document) error) error {
+	return filepath.WalkDir(s.Root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(entry.Name(), ".") && path != s.Root {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		markdown, ok := dirExtensions[strings.ToLower(filepath.Ext(path))]
+		if entry.IsDir() || !ok {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.Root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		doc := storage.Document{
+			DocumentID: sanitizeID(rel),
+			Text:       string(data),
+			Metadata:   map[string]string{"path": rel},
+		}
+		if markdown {
+			doc.Title = markdownTitle(doc.Text)
+		}
+		if doc.Title == "" {
+			doc.Title = strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		}
+		return fn(doc)
+	})
+}
+
+// markdownTitle returns the text of the first top-level heading in text, or "" if it has
+// none.
+func markdownTitle(text string) string {
+	for line := range strings.Lines(text) {
+		if title, ok := strings.CutPrefix(line, "# "); ok {
+			return strings.TrimSpace(title)
+		}
+	}
+	return ""
+}
+
+// derivedID returns the ID of the document at position n of the file at path.
+func derivedID(path string, n int) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return sanitizeID(name + "-" + strconv.Itoa(n))
+}
+
+// sanitizeID escapes "#" in id, since chunk IDs are the parent ID followed by "#".
+func sanitizeID(id string) string {
+	return strings.ReplaceAll(id, "#", "%23")
+}
@@ -0,0 +1,117 @@
+package outbox
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Journal durably records the entries of an outbox.
+type Journal interface {
+	// Save adds or replaces an entry. When it returns, the entry survives a crash.
+	Save(entry Entry) error
+	// Delete removes an entry. Deleting an entry that doesn't exist is not an error.
+	Delete(id string) error
+	// Load returns every saved entry.
+	Load() ([]Entry, error)
+}
+
+// FileJournal is a Journal that keeps each entry as a JSON file in a directory.
+type FileJournal struct {
+	dir string
+}
+
+// NewFileJournal creates a FileJournal in dir, creating the directory if needed.
+func NewFileJournal(dir string) (*FileJournal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+	return &FileJournal{dir: dir}, nil
+}
+
+// Save implements the Journal interface. The entry is written and synced under a temporary
+// name, renamed into place, and the directory synced, so a crash leaves either the old entry
+// or the new one.
+func (j *FileJournal) Save(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error marshalling outbox entry %s: %w", entry.ID, err)
+	}
+
+	tmp, err := os.CreateTemp(j.dir, "entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error saving outbox entry %s: %w", entry.ID, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error saving outbox entry %s: %w", entry.ID, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error syncing outbox entry %s: %w", entry.ID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error saving outbox entry %s: %w", entry.ID, err)
+	}
+	if err := os.Rename(tmp.Name(), j.path(entry.ID)); err != nil {
+		return fmt.Errorf("error saving outbox entry %s: %w", entry.ID, err)
+	}
+	return j.syncDir()
+}
+
+// Delete implements the Journal interface.
+func (j *FileJournal) Delete(id string) error {
+	if err := os.Remove(j.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error deleting outbox entry %s: %w", id, err)
+	}
+	return nil
+}
+
+// Load implements the Journal interface.
+func (j *FileJournal) Load() ([]Entry, error) {
+	files, err := os.ReadDir(j.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox directory: %w", err)
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(j.dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading outbox file %s: %w", file.Name(), err)
+		}
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("error parsing outbox file %s: %w", file.Name(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// path returns the file of an entry. It is named after a hash of the ID, since document IDs
+// may contain characters that aren't valid in file names.
+func (j *FileJournal) path(id string) string {
+	return filepath.Join(j.dir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(id))))
+}
+
+// syncDir flushes the directory, so that a rename survives a crash.
+func (j *FileJournal) syncDir() error {
+	dir, err := os.Open(j.dir)
+	if err != nil {
+		return fmt.Errorf("error syncing outbox directory: %w", err)
+	}
+	defer dir.Close()
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("error syncing outbox directory: %w", err)
+	}
+	return nil
+}
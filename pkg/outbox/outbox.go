@@ -0,0 +1,222 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/retry"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// Target is a store that an outbox entry is written to.
+type Target string
+
+const (
+	// TargetText is the text store, which holds the parent document.
+	TargetText Target = "text"
+	// TargetVector is the vector store, which holds the document's chunks.
+	TargetVector Target = "vector"
+)
+
+// Entry is the intent to store a document in every store. It stays in the outbox until each
+// store has acknowledged the write.
+type Entry struct {
+	// ID is the ID of the document.
+	ID       string           `json:"id"`
+	Document storage.Document `json:"document"`
+	// Pending lists the stores that haven't acknowledged the document yet.
+	Pending   []Target  `json:"pending"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// NextAttempt is when the background applier next retries the entry.
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+// Writer writes a document to one store at a time. ingest.Pipeline implements it.
+type Writer interface {
+	// Index writes the document to the text store.
+	Index(ctx context.Context, doc storage.Document) error
+	// WriteChunks chunks and embeds the document and writes its chunks to the vector store.
+	WriteChunks(ctx context.Context, doc storage.Document) (int, error)
+}
+
+// Outbox records documents in a journal before they are written to the text and vector
+// stores, and retries the writes until both stores acknowledge them. Writes are idempotent:
+// the document and its chunks have fixed IDs, so writing an entry twice overwrites the same
+// records. It is safe for concurrent use.
+type Outbox struct {
+	journal Journal
+	writer  Writer
+	backoff retry.Policy
+
+	mu      sync.Mutex
+	entries map[string]*Entry
+	// applying holds the IDs of entries being written, so an entry is never applied twice at
+	// once.
+	applying map[string]bool
+}
+
+// New creates an Outbox that writes documents with writer, and loads the entries left in the
+// journal. Failed entries are retried
+// after a wait given by backoff, which grows with each attempt; its MaxAttempts is ignored, as
+// entries are retried until they succeed.
+func New(journal Journal, writer Writer, backoff retry.Policy) (*Outbox, error) {
+	saved, err := journal.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	o := &Outbox{
+		journal:  journal,
+		writer:   writer,
+		backoff:  backoff,
+		entries:  make(map[string]*Entry, len(saved)),
+		applying: make(map[string]bool),
+	}
+	for _, entry := range saved {
+		o.entries[entry.ID] = &entry
+	}
+	return o, nil
+}
+
+// Record saves the intent to store doc in every store. A pending entry for the same document
+// is replaced, so the latest version is the one written.
+func (o *Outbox) Record(doc storage.Document) error {
+	now := time.Now().UTC()
+	entry := &Entry{
+		ID:          doc.DocumentID,
+		Document:    doc,
+		Pending:     []Target{TargetText, TargetVector},
+		CreatedAt:   now,
+		NextAttempt: now.Add(o.backoff.Backoff(1)),
+	}
+	// The journal is written under the lock, so that Apply can't delete the new entry's file
+	// after finishing the one it replaces.
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.journal.Save(*entry); err != nil {
+		return err
+	}
+	o.entries[entry.ID] = entry
+	return nil
+}
+
+// Apply writes an entry to the stores that haven't acknowledged it, and returns the ones that
+// are still pending. The entry is removed once nothing is pending; otherwise it is scheduled
+// for another attempt. An entry that is already being applied is left alone.
+func (o *Outbox) Apply(ctx context.Context, id string) ([]Target, error) {
+	o.mu.Lock()
+	entry, ok := o.entries[id]
+	if !ok {
+		o.mu.Unlock()
+		return nil, nil
+	}
+	snapshot := *entry
+	snapshot.Pending = slices.Clone(entry.Pending)
+	if o.applying[id] {
+		o.mu.Unlock()
+		return snapshot.Pending, nil
+	}
+	o.applying[id] = true
+	o.mu.Unlock()
+	defer func() {
+		o.mu.Lock()
+		delete(o.applying, id)
+		o.mu.Unlock()
+	}()
+
+	var pending []Target
+	var errs []error
+	for _, target := range snapshot.Pending {
+		if err := o.write(ctx, target, snapshot.Document); err != nil {
+			pending = append(pending, target)
+			errs = append(errs, fmt.Errorf("%s store: %w", target, err))
+		}
+	}
+	err := errors.Join(errs...)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if current := o.entries[id]; current != entry {
+		// The document was recorded again while this version was written. The new entry
+		// still has every store pending.
+		return pending, err
+	}
+	if len(pending) == 0 {
+		delete(o.entries, id)
+		if err := o.journal.Delete(id); err != nil {
+			log.Printf("Failed to delete applied outbox entry %s: %v", id, err)
+		}
+		return nil, nil
+	}
+
+	entry.Pending = pending
+	entry.Attempts++
+	entry.LastError = err.Error()
+	entry.NextAttempt = time.Now().UTC().Add(o.backoff.Backoff(entry.Attempts))
+	if err := o.journal.Save(*entry); err != nil {
+		log.Printf("Failed to save outbox entry %s: %v", id, err)
+	}
+	return slices.Clone(pending), err
+}
+
+// Len returns the number of entries waiting to be written.
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.entries)
+}
+
+// Run retries due entries every interval until ctx is cancelled. Errors are logged.
+func (o *Outbox) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.applyDue(ctx)
+		}
+	}
+}
+
+// applyDue applies every entry whose next attempt is due.
+func (o *Outbox) applyDue(ctx context.Context) {
+	now := time.Now()
+	o.mu.Lock()
+	var due []string
+	for id, entry := range o.entries {
+		if !entry.NextAttempt.After(now) && !o.applying[id] {
+			due = append(due, id)
+		}
+	}
+	o.mu.Unlock()
+
+	for _, id := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if pending, err := o.Apply(ctx, id); err != nil {
+			log.Printf("Outbox entry %s still pending for %v: %v", id, pending, err)
+		}
+	}
+}
+
+// write stores a document in one target.
+func (o *Outbox) write(ctx context.Context, target Target, doc storage.Document) error {
+	switch target {
+	case TargetText:
+		return o.writer.Index(ctx, doc)
+	case TargetVector:
+		_, err := o.writer.WriteChunks(ctx, doc)
+		return err
+	}
+	return fmt.Errorf("unknown target %q", target)
+}
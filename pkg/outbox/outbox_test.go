@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/chunker"
+	embedding_mocks "github.com/chr1sbest/hybrid-search/pkg/embeddings/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/ingest"
+	"github.com/chr1sbest/hybrid-search/pkg/retry"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	storage_mocks "github.com/chr1sbest/hybrid-search/pkg/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testDoc = storage.Document{DocumentID: "p1", Text: "Invoices are emailed monthly.", Metadata: map[string]string{"tag": "billing"}}
+
+var indexed = []storage.IndexResult{{DocumentID: "p1", Created: true}}
+
+func newTestOutbox(t *testing.T, journal Journal) (*Outbox, *embedding_mocks.EmbeddingClient, *storage_mocks.VectorStore, *storage_mocks.TextStore) {
+	t.Helper()
+	mockEmbed := new(embedding_mocks.EmbeddingClient)
+	mockVector := new(storage_mocks.VectorStore)
+	mockText := new(storage_mocks.TextStore)
+	pipeline := ingest.NewPipeline(chunker.NewChunker(512, 50), mockEmbed, mockVector, mockText, 10, 1)
+	o, err := New(journal, pipeline, retry.Policy{})
+	assert.NoError(t, err)
+	return o, mockEmbed, mockVector, mockText
+}
+
+func TestOutbox(t *testing.T) {
+	t.Run("AppliesToEveryStore", func(t *testing.T) {
+		// 1. Arrange
+		journal, err := NewFileJournal(t.TempDir())
+		assert.NoError(t, err)
+		o, mockEmbed, mockVector, mockText := newTestOutbox(t, journal)
+		mockText.On("IndexMany", mock.Anything, []storage.Document{testDoc}).Return(indexed, nil)
+		mockEmbed.On("CreateEmbedding", mock.Anything, testDoc.Text).Return([]float32{0.1}, nil)
+		mockVector.On("UpsertMany", mock.Anything, []storage.Document{
+			{DocumentID: "p1#0", ParentDocumentID: "p1", Text: testDoc.Text, Metadata: testDoc.Metadata},
+		}, [][]float32{{0.1}}).Return(nil)
+
+		// 2. Act
+		assert.NoError(t, o.Record(testDoc))
+		pending, err := o.Apply(context.Background(), "p1")
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Empty(t, pending)
+		assert.Equal(t, 0, o.Len())
+		saved, _ := journal.Load()
+		assert.Empty(t, saved)
+		mockVector.AssertExpectations(t)
+	})
+
+	t.Run("RetriesUnacknowledgedStores", func(t *testing.T) {
+		// 1. Arrange
+		journal, err := NewFileJournal(t.TempDir())
+		assert.NoError(t, err)
+		o, mockEmbed, mockVector, mockText := newTestOutbox(t, journal)
+		mockText.On("IndexMany", mock.Anything, []storage.Document{testDoc}).Return(indexed, nil).Once()
+		mockEmbed.On("CreateEmbedding", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+		mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("unavailable")).Once()
+		mockVector.On("UpsertMany", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		assert.NoError(t, o.Record(testDoc))
+
+		// 2. Act
+		pending, err := o.Apply(context.Background(), "p1")
+
+		// 3. Assert
+		assert.EqualError(t, err, "vector store: 1 of 1 chunks failed: chunk p1#0: failed to write chunk: unavailable")
+		assert.Equal(t, []Target{TargetVector}, pending)
+		saved, _ := journal.Load()
+		assert.Len(t, saved, 1)
+		assert.Equal(t, []Target{TargetVector}, saved[0].Pending)
+		assert.Equal(t, 1, saved[0].Attempts)
+
+		// The background applier only writes to the store that hasn't acknowledged.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go o.Run(ctx, time.Millisecond)
+		assert.Eventually(t, func() bool { return o.Len() == 0 }, time.Second, 5*time.Millisecond)
+		mockText.AssertNumberOfCalls(t, "IndexMany", 1)
+		mockVector.AssertNumberOfCalls(t, "UpsertMany", 2)
+	})
+
+	t.Run("ResumesFromJournal", func(t *testing.T) {
+		// 1. Arrange
+		journal, err := NewFileJournal(t.TempDir())
+		assert.NoError(t, err)
+		assert.NoError(t, journal.Save(Entry{ID: "p1", Document: testDoc, Pending: []Target{TargetText}}))
+
+		// 2. Act
+		o, _, _, mockText := newTestOutbox(t, journal)
+		mockText.On("IndexMany", mock.Anything, []storage.Document{testDoc}).Return(indexed, nil)
+		pending, err := o.Apply(context.Background(), "p1")
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Empty(t, pending)
+		mockText.AssertExpectations(t)
+	})
+}
+
+func TestFileJournal(t *testing.T) {
+	journal, err := NewFileJournal(t.TempDir())
+	assert.NoError(t, err)
+	entry := Entry{ID: "docs/a b", Document: testDoc, Pending: []Target{TargetText, TargetVector}, CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	assert.NoError(t, journal.Save(entry))
+	entry.Pending = []Target{TargetVector}
+	assert.NoError(t, journal.Save(entry))
+	saved, err := journal.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []Entry{entry}, saved)
+
+	assert.NoError(t, journal.Delete(entry.ID))
+	assert.NoError(t, journal.Delete(entry.ID))
+	saved, err = journal.Load()
+	assert.NoError(t, err)
+	assert.Empty(t, saved)
+}
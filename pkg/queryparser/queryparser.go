@@ -0,0 +1,418 @@
+package queryparser
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// fieldName matches the name part of a field filter such as "tag:billing".
+var fieldName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// Node is a node in a parsed query.
+type Node interface {
+	isNode()
+}
+
+// Term is free text: one or more adjacent words that are matched loosely, like a plain query.
+type Term struct {
+	Text string
+}
+
+// Phrase is quoted text that must appear exactly, in order.
+type Phrase struct {
+	Text string
+}
+
+// Field restricts results to documents whose field has exactly the given value, as in "tag:billing".
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Not excludes documents that match Node, as in "-draft" or "NOT draft".
+type Not struct {
+	Node Node
+}
+
+// And matches documents that match all of Nodes.
+type And struct {
+	Nodes []Node
+}
+
+// Or matches documents that match any of Nodes.
+type Or struct {
+	Nodes []Node
+}
+
+func (Term) isNode()   {}
+func (Phrase) isNode() {}
+func (Field) isNode()  {}
+func (Not) isNode()    {}
+func (And) isNode()    {}
+func (Or) isNode()     {}
+
+// Parse parses a search box query into an AST. The syntax is:
+//
+//	word            free text; adjacent words form a single Term
+//	"some phrase"   an exact phrase
+//	field:value     a field filter; the value may be quoted
+//	-x, NOT x       excludes x
+//	a AND b, a OR b boolean operators; AND binds tighter than OR, and is implied between terms
+//	( ... )         grouping
+//
+// Operators are only recognised in upper case. Parse never fails: unbalanced quotes and
+// parentheses are closed at the end of the input, and dangling operators are ignored.
+// It returns nil for an empty query.
+func Parse(input string) Node {
+	p := &parser{tokens: tokenize(input)}
+	var nodes []Node
+	for !p.done() {
+		// A stray closing parenthesis has nothing to close; skip it and keep going.
+		if p.peek().kind == tokenRParen {
+			p.pos++
+			continue
+		}
+		if node := p.parseOr(); node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	return and(nodes)
+}
+
+// FreeText returns the text of every term and phrase that is not excluded, in query order.
+// Field filters and excluded text are left out. This is the part of a query that is
+// meaningful to embed.
+func FreeText(node Node) string {
+	var parts []string
+	var walk func(Node)
+	walk = func(n Node) {
+		switch n := n.(type) {
+		case Term:
+			parts = append(parts, n.Text)
+		case Phrase:
+			parts = append(parts, n.Text)
+		case And:
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case Or:
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+	return strings.Join(parts, " ")
+}
+
+// Restricts reports whether the query has a phrase, a field filter or an exclusion, which
+// Match may reject a document for.
+func Restricts(node Node) bool {
+	switch n := node.(type) {
+	case Phrase, Field, Not:
+		return true
+	case And:
+		return slices.ContainsFunc(n.Nodes, Restricts)
+	case Or:
+		return slices.ContainsFunc(n.Nodes, Restricts)
+	}
+	return false
+}
+
+// Match reports whether a document satisfies the query's phrases, field filters and
+// exclusions, given its text and a lookup of its field values. Free text only ranks documents,
+// so a Term always matches, except when it is excluded: then a document containing any of its
+// words is rejected, as a lexical search would. Words are compared case-insensitively and
+// field values exactly. A nil query matches everything.
+func Match(node Node, text string, field func(name string) (string, bool)) bool {
+	m := matcher{text: " " + strings.Join(words(text), " ") + " ", field: field}
+	return node == nil || m.matches(node)
+}
+
+// matcher evaluates a query against one document.
+type matcher struct {
+	// text is the document's words, lower case, each surrounded by a space.
+	text  string
+	field func(name string) (string, bool)
+}
+
+// matches reports whether the document satisfies node, with free text always matching.
+func (m matcher) matches(node Node) bool {
+	switch n := node.(type) {
+	case Term:
+		return true
+	case Not:
+		return !m.contains(n.Node)
+	case And:
+		for _, child := range n.Nodes {
+			if !m.matches(child) {
+				return false
+			}
+		}
+		return true
+	case Or:
+		return slices.ContainsFunc(n.Nodes, m.matches)
+	}
+	return m.contains(node)
+}
+
+// contains reports whether the document lexically matches node: a Term when it has any of
+// the Term's words, and a phrase when it has the phrase's words in order.
+func (m matcher) contains(node Node) bool {
+	switch n := node.(type) {
+	case Term:
+		return slices.ContainsFunc(words(n.Text), func(word string) bool {
+			return strings.Contains(m.text, " "+word+" ")
+		})
+	case Phrase:
+		phrase := words(n.Text)
+		return len(phrase) > 0 && strings.Contains(m.text, " "+strings.Join(phrase, " ")+" ")
+	case Field:
+		value, ok := m.field(n.Name)
+		return ok && value == n.Value
+	case Not:
+		return !m.contains(n.Node)
+	case And:
+		for _, child := range n.Nodes {
+			if !m.contains(child) {
+				return false
+			}
+		}
+		return true
+	case Or:
+		return slices.ContainsFunc(n.Nodes, m.contains)
+	}
+	return false
+}
+
+// words splits text into lower case words, dropping punctuation.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenPhrase
+	tokenField
+	tokenMinus
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	// text is the word or phrase, or the field value.
+	text string
+	// field is the field name of a tokenField.
+	field string
+}
+
+// tokenize splits the input into tokens. A "-" only negates when it is attached to the token
+// that follows it, so hyphenated words and a lone dash are left alone.
+func tokenize(input string) []token {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen})
+			i++
+		case r == '"':
+			text, next := readQuoted(runes, i)
+			if text != "" {
+				tokens = append(tokens, token{kind: tokenPhrase, text: text})
+			}
+			i = next
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != ')':
+			tokens = append(tokens, token{kind: tokenMinus})
+			i++
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+				i++
+			}
+			word := string(runes[start:i])
+			if word == "-" {
+				continue
+			}
+
+			// A field filter may have a quoted value, as in title:"release notes".
+			if name, ok := strings.CutSuffix(word, ":"); ok && fieldName.MatchString(name) && i < len(runes) && runes[i] == '"' {
+				value, next := readQuoted(runes, i)
+				i = next
+				if value != "" {
+					tokens = append(tokens, token{kind: tokenField, field: name, text: value})
+				}
+				continue
+			}
+			tokens = append(tokens, wordToken(word))
+		}
+	}
+	return tokens
+}
+
+// readQuoted reads a quoted string starting at the opening quote at runes[start]. It returns the
+// normalised text and the index after the closing quote, or the end of the input if unclosed.
+func readQuoted(runes []rune, start int) (string, int) {
+	end := start + 1
+	for end < len(runes) && runes[end] != '"' {
+		end++
+	}
+	text := strings.Join(strings.Fields(string(runes[start+1:end])), " ")
+	return text, min(end+1, len(runes))
+}
+
+// wordToken classifies a bare word as an operator, a field filter or a plain word.
+func wordToken(word string) token {
+	switch word {
+	case "AND", "&&":
+		return token{kind: tokenAnd}
+	case "OR", "||":
+		return token{kind: tokenOr}
+	case "NOT":
+		return token{kind: tokenNot}
+	}
+	// URLs such as "http://example.com" are words, not filters.
+	if name, value, ok := strings.Cut(word, ":"); ok && value != "" && !strings.HasPrefix(value, "/") && fieldName.MatchString(name) {
+		return token{kind: tokenField, field: name, text: value}
+	}
+	return token{kind: tokenWord, text: word}
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// parseOr parses: and ("OR" and)*
+func (p *parser) parseOr() Node {
+	nodes := []Node{}
+	if node := p.parseAnd(); node != nil {
+		nodes = append(nodes, node)
+	}
+	for !p.done() && p.peek().kind == tokenOr {
+		p.pos++
+		if node := p.parseAnd(); node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	return or(nodes)
+}
+
+// parseAnd parses a run of unary expressions joined by "AND" or by adjacency. Adjacent
+// words are merged into one Term; an explicit AND keeps them as separate required terms.
+func (p *parser) parseAnd() Node {
+	var nodes []Node
+	explicit := false
+	for !p.done() {
+		switch p.peek().kind {
+		case tokenOr, tokenRParen:
+			return and(nodes)
+		case tokenAnd:
+			p.pos++
+			explicit = true
+			continue
+		}
+
+		node := p.parseUnary()
+		if node == nil {
+			continue
+		}
+		if term, ok := node.(Term); ok && !explicit && len(nodes) > 0 {
+			if prev, ok := nodes[len(nodes)-1].(Term); ok {
+				nodes[len(nodes)-1] = Term{Text: prev.Text + " " + term.Text}
+				continue
+			}
+		}
+		nodes = append(nodes, node)
+		explicit = false
+	}
+	return and(nodes)
+}
+
+// parseUnary parses: ("-" | "NOT") unary | primary
+func (p *parser) parseUnary() Node {
+	switch p.peek().kind {
+	case tokenMinus, tokenNot:
+		p.pos++
+		if p.done() {
+			return nil
+		}
+		// Leave operators and closing parentheses for the caller.
+		if kind := p.peek().kind; kind == tokenAnd || kind == tokenOr || kind == tokenRParen {
+			return nil
+		}
+		if node := p.parseUnary(); node != nil {
+			return Not{Node: node}
+		}
+		return nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses a word, phrase, field filter or parenthesised group.
+func (p *parser) parsePrimary() Node {
+	tok := p.peek()
+	p.pos++
+	switch tok.kind {
+	case tokenWord:
+		return Term{Text: tok.text}
+	case tokenPhrase:
+		return Phrase{Text: tok.text}
+	case tokenField:
+		return Field{Name: tok.field, Value: tok.text}
+	case tokenLParen:
+		node := p.parseOr()
+		if !p.done() && p.peek().kind == tokenRParen {
+			p.pos++
+		}
+		return node
+	}
+	// Operators with nothing to apply to are ignored.
+	return nil
+}
+
+// and builds an And node, collapsing it when there are fewer than two operands.
+func and(nodes []Node) Node {
+	switch len(nodes) {
+	case 0:
+		return nil
+	case 1:
+		return nodes[0]
+	}
+	return And{Nodes: nodes}
+}
+
+// or builds an Or node, collapsing it when there are fewer than two operands.
+func or(nodes []Node) Node {
+	switch len(nodes) {
+	case 0:
+		return nil
+	case 1:
+		return nodes[0]
+	}
+	return Or{Nodes: nodes}
+}
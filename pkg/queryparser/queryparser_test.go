@@ -0,0 +1,106 @@
+package queryparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Node
+	}{
+		{name: "Empty", input: "   ", want: nil},
+		{name: "PlainWordsFormOneTerm", input: "reset my  password", want: Term{Text: "reset my password"}},
+		{
+			name:  "PhraseExcludeAndFilter",
+			input: `"exact phrase" -exclude tag:billing`,
+			want: And{Nodes: []Node{
+				Phrase{Text: "exact phrase"},
+				Not{Node: Term{Text: "exclude"}},
+				Field{Name: "tag", Value: "billing"},
+			}},
+		},
+		{
+			name:  "ExplicitAndKeepsTermsSeparate",
+			input: "invoice AND refund",
+			want:  And{Nodes: []Node{Term{Text: "invoice"}, Term{Text: "refund"}}},
+		},
+		{
+			name:  "OrBindsLooserThanAnd",
+			input: "invoice refund OR billing tag:finance",
+			want: Or{Nodes: []Node{
+				Term{Text: "invoice refund"},
+				And{Nodes: []Node{Term{Text: "billing"}, Field{Name: "tag", Value: "finance"}}},
+			}},
+		},
+		{
+			name:  "GroupsAndNot",
+			input: `NOT (draft OR "work in progress") report`,
+			want: And{Nodes: []Node{
+				Not{Node: Or{Nodes: []Node{Term{Text: "draft"}, Phrase{Text: "work in progress"}}}},
+				Term{Text: "report"},
+			}},
+		},
+		{name: "QuotedFieldValue", input: `title:"release notes"`, want: Field{Name: "title", Value: "release notes"}},
+		{name: "LowercaseOperatorsAreWords", input: "salt and pepper or not", want: Term{Text: "salt and pepper or not"}},
+		{name: "HyphenatedWordsAndLoneDash", input: "e-mail - setup", want: Term{Text: "e-mail setup"}},
+		{name: "URLIsNotAFilter", input: "http://example.com", want: Term{Text: "http://example.com"}},
+		{name: "UnclosedQuote", input: `"open ended`, want: Phrase{Text: "open ended"}},
+		{name: "UnbalancedParentheses", input: "(a OR b", want: Or{Nodes: []Node{Term{Text: "a"}, Term{Text: "b"}}}},
+		{name: "StrayClosingParenthesis", input: "a ) b", want: And{Nodes: []Node{Term{Text: "a"}, Term{Text: "b"}}}},
+		{name: "DanglingOperators", input: "AND a OR NOT", want: Term{Text: "a"}},
+		{name: "NotBeforeClosingParenthesis", input: "(a NOT) b", want: Term{Text: "a b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.input))
+		})
+	}
+}
+
+func TestFreeText(t *testing.T) {
+	assert.Equal(t, "exact phrase reset password", FreeText(Parse(`"exact phrase" -exclude tag:billing reset password`)))
+	assert.Equal(t, "a b", FreeText(Parse("(a OR b) NOT c")))
+	assert.Equal(t, "", FreeText(Parse("tag:billing -draft")))
+	assert.Equal(t, "", FreeText(nil))
+}
+
+func TestMatch(t *testing.T) {
+	text := "Invoices are emailed on the first day of each billing cycle."
+	fields := map[string]string{"tag": "billing", "lang": "en"}
+	field := func(name string) (string, bool) {
+		value, ok := fields[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "", want: true},
+		{query: "refund policy", want: true},
+		{query: `"billing cycle"`, want: true},
+		{query: `"cycle billing"`, want: false},
+		{query: "tag:billing", want: true},
+		{query: "tag:Billing", want: false},
+		{query: "owner:finance", want: false},
+		{query: "invoices -refund", want: true},
+		{query: "invoices -EMAILED", want: false},
+		{query: `invoices -"first day"`, want: false},
+		{query: "invoices NOT tag:billing", want: false},
+		{query: "tag:finance OR lang:en", want: true},
+		{query: "-(refund OR draft) tag:billing", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.want, Match(Parse(tt.query), text, field))
+		})
+	}
+
+	assert.False(t, Restricts(Parse("refund policy")))
+	assert.True(t, Restricts(Parse("refund -draft")))
+	assert.True(t, Restricts(Parse(`(a OR "b c")`)))
+}
@@ -0,0 +1,95 @@
+package ranking
+
+import "math"
+
+// MaximalMarginalRelevance re-orders results to trade relevance off against redundancy.
+// At each step it picks the remaining result that maximises
+//
+//	lambda * relevance(d) - (1 - lambda) * max sim(d, s) over already selected s
+//
+// where relevance falls linearly from 1 for the first result to 0 for the last, and sim is
+// the cosine similarity of the documents' embeddings. Taking relevance from the incoming
+// order, rather than from scores, lets MMR follow fusion and reranking alike. vectors must be
+// aligned with results; a nil vector is treated as dissimilar to everything. A lambda of 1
+// keeps the incoming order, lower values favour diversity.
+func MaximalMarginalRelevance(results []Result, vectors [][]float32, lambda float64) []Result {
+	if len(results) < 2 {
+		return results
+	}
+
+	relevance := make([]float64, len(results))
+	for i := range results {
+		relevance[i] = 1 - float64(i)/float64(len(results)-1)
+	}
+
+	// maxSim tracks, for every candidate, its highest similarity to anything selected so far.
+	maxSim := make([]float64, len(results))
+	selected := make([]bool, len(results))
+	reordered := make([]Result, 0, len(results))
+
+	for len(reordered) < len(results) {
+		best, bestScore := -1, math.Inf(-1)
+		// Candidates are visited in order, so equal MMR scores keep that order.
+		for i := range results {
+			if selected[i] {
+				continue
+			}
+			score := lambda*relevance[i] - (1-lambda)*maxSim[i]
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		selected[best] = true
+		reordered = append(reordered, results[best])
+		for i := range results {
+			if !selected[i] {
+				maxSim[i] = math.Max(maxSim[i], CosineSimilarity(vectors[i], vectors[best]))
+			}
+		}
+	}
+	return reordered
+}
+
+// LimitPerParent keeps at most max results for each parent document, preserving order.
+// Chunks are grouped by their parent ID, and a parent document counts towards its own group.
+// A max of zero or less disables the limit.
+func LimitPerParent(results []Result, max int) []Result {
+	if max <= 0 {
+		return results
+	}
+
+	counts := make(map[string]int)
+	var limited []Result
+	for _, result := range results {
+		parentID := result.Document.ParentDocumentID
+		if parentID == "" {
+			parentID = result.Document.DocumentID
+		}
+		if counts[parentID] >= max {
+			continue
+		}
+		counts[parentID]++
+		limited = append(limited, result)
+	}
+	return limited
+}
+
+// CosineSimilarity returns the cosine similarity of two vectors, or 0 if either is
+// missing, zero, or their lengths differ.
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,62 @@
+package ranking
+
+import (
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func chunkResult(id, parentID string, score float64) Result {
+	return Result{Document: storage.Document{DocumentID: id, ParentDocumentID: parentID}, Score: score}
+}
+
+func TestMaximalMarginalRelevance(t *testing.T) {
+	// A and B are near-duplicates; C is less relevant but covers something else.
+	results := []Result{
+		chunkResult("A", "p1", 0.9),
+		chunkResult("B", "p1", 0.8),
+		chunkResult("C", "p2", 0.7),
+	}
+	vectors := [][]float32{
+		{1, 0},
+		{0.99, 0.01},
+		{0, 1},
+	}
+
+	t.Run("LambdaOneKeepsFusedOrder", func(t *testing.T) {
+		assert.Equal(t, []string{"A", "B", "C"}, ids(MaximalMarginalRelevance(results, vectors, 1)))
+	})
+
+	t.Run("LowerLambdaPromotesDiverseResults", func(t *testing.T) {
+		assert.Equal(t, []string{"A", "C", "B"}, ids(MaximalMarginalRelevance(results, vectors, 0.5)))
+	})
+
+	t.Run("MissingVectorsKeepFusedOrder", func(t *testing.T) {
+		assert.Equal(t, []string{"A", "B", "C"}, ids(MaximalMarginalRelevance(results, make([][]float32, 3), 0.5)))
+	})
+
+	t.Run("KeepsEveryResult", func(t *testing.T) {
+		assert.Len(t, MaximalMarginalRelevance(results, vectors, 0), 3)
+		assert.Empty(t, MaximalMarginalRelevance(nil, nil, 0.5))
+	})
+}
+
+func TestLimitPerParent(t *testing.T) {
+	results := []Result{
+		chunkResult("p1-c1", "p1", 0.9),
+		chunkResult("p1-c2", "p1", 0.8),
+		chunkResult("p1", "", 0.7),
+		chunkResult("p2-c1", "p2", 0.6),
+		chunkResult("p1-c3", "p1", 0.5),
+	}
+
+	t.Run("CapsEachParent", func(t *testing.T) {
+		assert.Equal(t, []string{"p1-c1", "p2-c1"}, ids(LimitPerParent(results, 1)))
+		assert.Equal(t, []string{"p1-c1", "p1-c2", "p2-c1"}, ids(LimitPerParent(results, 2)))
+	})
+
+	t.Run("ZeroDisablesTheCap", func(t *testing.T) {
+		assert.Equal(t, results, LimitPerParent(results, 0))
+	})
+}
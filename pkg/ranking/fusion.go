@@ -0,0 +1,355 @@
+package ranking
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// Strategy names a fusion algorithm.
+type Strategy string
+
+// Supported fusion strategies.
+const (
+	// StrategyRRF is weighted Reciprocal Rank Fusion. It only looks at ranks.
+	StrategyRRF Strategy = "rrf"
+	// StrategyMinMax is a convex combination of min-max normalized scores.
+	StrategyMinMax Strategy = "minmax"
+	// StrategyZScore is a convex combination of z-score normalized scores.
+	StrategyZScore Strategy = "zscore"
+	// StrategyDBSF is distribution-based score fusion: scores are normalized against
+	// mean ± 3 standard deviations of their list before being summed.
+	StrategyDBSF Strategy = "dbsf"
+	// StrategyCombSUM sums the min-max normalized scores of each document.
+	StrategyCombSUM Strategy = "combsum"
+	// StrategyCombMNZ is CombSUM multiplied by the number of lists containing the document.
+	StrategyCombMNZ Strategy = "combmnz"
+	// StrategyBorda awards each document points based on how many candidates it outranks.
+	StrategyBorda Strategy = "borda"
+)
+
+// Strategies lists every supported fusion strategy.
+var Strategies = []Strategy{
+	StrategyRRF, StrategyMinMax, StrategyZScore, StrategyDBSF, StrategyCombSUM, StrategyCombMNZ, StrategyBorda,
+}
+
+// Fuser combines ranked result lists from several retrievers into a single ranking.
+//
+// Results are ordered by fused score, highest first. Ties are broken deterministically by,
+// in order: the best (lowest) rank the document reached in any list, the priority of the
+// list it reached that rank in (lists passed earlier take priority), and finally document
+// ID, which guarantees a total order.
+type Fuser interface {
+	Fuse(cfg FusionConfig, lists ...ResultList) []Result
+}
+
+// NewFuser returns the Fuser implementing the given strategy.
+// An empty strategy selects RRF.
+func NewFuser(strategy Strategy) (Fuser, error) {
+	switch strategy {
+	case "", StrategyRRF:
+		return rrfFuser{}, nil
+	case StrategyMinMax:
+		return convexFuser{normalize: minMaxNormalize}, nil
+	case StrategyZScore:
+		return convexFuser{normalize: zScoreNormalize}, nil
+	case StrategyDBSF:
+		return dbsfFuser{}, nil
+	case StrategyCombSUM:
+		return combFuser{}, nil
+	case StrategyCombMNZ:
+		return combFuser{mnz: true}, nil
+	case StrategyBorda:
+		return bordaFuser{}, nil
+	default:
+		return nil, fmt.Errorf("unknown fusion strategy %q", strategy)
+	}
+}
+
+// Fuse combines the result lists with the strategy selected in cfg.
+func Fuse(cfg FusionConfig, lists ...ResultList) ([]Result, error) {
+	fuser, err := NewFuser(cfg.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	return fuser.Fuse(cfg, lists...), nil
+}
+
+// rrfFuser implements weighted Reciprocal Rank Fusion.
+type rrfFuser struct{}
+
+func (rrfFuser) Fuse(cfg FusionConfig, lists ...ResultList) []Result {
+	k := cfg.k()
+	acc := newAccumulator()
+	for l, list := range lists {
+		weight := cfg.Weight(list.Retriever)
+		for i, result := range list.Results {
+			rank := i + 1
+			acc.add(l, list.Retriever, rank, result, weight/(k+float64(rank)))
+		}
+	}
+	return acc.ranked()
+}
+
+// convexFuser implements a weighted convex combination of normalized scores.
+// Weights are rescaled to sum to 1, and documents missing from a list contribute nothing for it.
+type convexFuser struct {
+	normalize func([]storage.SearchResult) []float64
+}
+
+func (f convexFuser) Fuse(cfg FusionConfig, lists ...ResultList) []Result {
+	var total float64
+	for _, list := range lists {
+		total += cfg.Weight(list.Retriever)
+	}
+
+	acc := newAccumulator()
+	for l, list := range lists {
+		weight := 0.0
+		if total > 0 {
+			weight = cfg.Weight(list.Retriever) / total
+		}
+		for i, score := range f.normalize(list.Results) {
+			acc.add(l, list.Retriever, i+1, list.Results[i], weight*score)
+		}
+	}
+	return acc.ranked()
+}
+
+// dbsfFuser implements distribution-based score fusion.
+type dbsfFuser struct{}
+
+func (dbsfFuser) Fuse(cfg FusionConfig, lists ...ResultList) []Result {
+	acc := newAccumulator()
+	for l, list := range lists {
+		weight := cfg.Weight(list.Retriever)
+		for i, score := range dbsfNormalize(list.Results) {
+			acc.add(l, list.Retriever, i+1, list.Results[i], weight*score)
+		}
+	}
+	return acc.ranked()
+}
+
+// combFuser implements CombSUM and CombMNZ over min-max normalized scores.
+type combFuser struct {
+	mnz bool
+}
+
+func (f combFuser) Fuse(cfg FusionConfig, lists ...ResultList) []Result {
+	acc := newAccumulator()
+	hits := make(map[string]int)
+	for l, list := range lists {
+		weight := cfg.Weight(list.Retriever)
+		seen := make(map[string]bool)
+		for i, score := range minMaxNormalize(list.Results) {
+			id := list.Results[i].Document.DocumentID
+			acc.add(l, list.Retriever, i+1, list.Results[i], weight*score)
+			if !seen[id] {
+				seen[id] = true
+				hits[id]++
+			}
+		}
+	}
+	if f.mnz {
+		for id, hitCount := range hits {
+			acc.scale(id, float64(hitCount))
+		}
+	}
+	return acc.ranked()
+}
+
+// bordaFuser implements weighted Borda count. With N distinct candidates across all lists,
+// the document at rank r of a list earns N - r + 1 points from it; unranked documents earn none.
+type bordaFuser struct{}
+
+func (bordaFuser) Fuse(cfg FusionConfig, lists ...ResultList) []Result {
+	candidates := make(map[string]bool)
+	for _, list := range lists {
+		for _, result := range list.Results {
+			candidates[result.Document.DocumentID] = true
+		}
+	}
+	n := float64(len(candidates))
+
+	acc := newAccumulator()
+	for l, list := range lists {
+		weight := cfg.Weight(list.Retriever)
+		for i, result := range list.Results {
+			rank := i + 1
+			acc.add(l, list.Retriever, rank, result, weight*(n-float64(rank)+1))
+		}
+	}
+	return acc.ranked()
+}
+
+// accumulator sums per-document scores, keeps one copy of each document and
+// records every retriever's contribution.
+type accumulator struct {
+	// entries maps document IDs to their fused results.
+	entries map[string]*entry
+}
+
+// entry is a fused result along with the bookkeeping used to break score ties.
+type entry struct {
+	Result
+	// bestRank is the lowest rank the document reached in any list.
+	bestRank int
+	// priority is the index of the first list in which the document reached bestRank.
+	priority int
+}
+
+func newAccumulator() *accumulator {
+	return &accumulator{entries: make(map[string]*entry)}
+}
+
+// add records that the list at index listIdx returned result at the given rank.
+func (a *accumulator) add(listIdx int, retriever string, rank int, result storage.SearchResult, contribution float64) {
+	fused, ok := a.entries[result.Document.DocumentID]
+	if !ok {
+		fused = &entry{Result: Result{Document: result.Document}, bestRank: rank, priority: listIdx}
+		a.entries[result.Document.DocumentID] = fused
+	}
+	// If the stored version has no text and this one does, keep this one.
+	if fused.Document.Text == "" && result.Document.Text != "" {
+		fused.Document = result.Document
+	}
+	if len(fused.Highlights) == 0 {
+		fused.Highlights = result.Highlights
+	}
+	if rank < fused.bestRank {
+		fused.bestRank = rank
+		fused.priority = listIdx
+	}
+
+	fused.Score += contribution
+	fused.Explanation = append(fused.Explanation, Contribution{
+		Retriever:    retriever,
+		Rank:         rank,
+		Score:        result.Score,
+		Contribution: contribution,
+	})
+}
+
+// scale multiplies a document's fused score, and every contribution to it, by factor.
+func (a *accumulator) scale(id string, factor float64) {
+	fused, ok := a.entries[id]
+	if !ok {
+		return
+	}
+	fused.Score *= factor
+	for i := range fused.Explanation {
+		fused.Explanation[i].Contribution *= factor
+	}
+}
+
+// ranked returns the accumulated results sorted by fused score in descending order,
+// breaking ties as documented on Fuser.
+func (a *accumulator) ranked() []Result {
+	entries := make([]*entry, 0, len(a.entries))
+	for _, e := range a.entries {
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].before(entries[j])
+	})
+
+	rankedResults := make([]Result, len(entries))
+	for i, e := range entries {
+		rankedResults[i] = e.Result
+	}
+	return rankedResults
+}
+
+// before reports whether e should be ranked ahead of other.
+func (e *entry) before(other *entry) bool {
+	if e.Score != other.Score {
+		return e.Score > other.Score
+	}
+	if e.bestRank != other.bestRank {
+		return e.bestRank < other.bestRank
+	}
+	if e.priority != other.priority {
+		return e.priority < other.priority
+	}
+	return e.Document.DocumentID < other.Document.DocumentID
+}
+
+// minMaxNormalize rescales scores to [0, 1]. If every score is equal, they all map to 1.
+func minMaxNormalize(results []storage.SearchResult) []float64 {
+	normalized := make([]float64, len(results))
+	if len(results) == 0 {
+		return normalized
+	}
+
+	lo, hi := results[0].Score, results[0].Score
+	for _, result := range results {
+		lo = math.Min(lo, result.Score)
+		hi = math.Max(hi, result.Score)
+	}
+	for i, result := range results {
+		if hi == lo {
+			normalized[i] = 1
+			continue
+		}
+		normalized[i] = (result.Score - lo) / (hi - lo)
+	}
+	return normalized
+}
+
+// zScoreNormalize converts scores to standard scores, shifted so that the lowest is 0.
+// Documents missing from a list contribute 0 for it, so without the shift they would beat
+// every document retrieved with a below-mean score. If every score is equal, they all map to 0.
+func zScoreNormalize(results []storage.SearchResult) []float64 {
+	normalized := make([]float64, len(results))
+	mean, std := meanStdDev(results)
+	if std == 0 {
+		return normalized
+	}
+	lowest := math.Inf(1)
+	for i, result := range results {
+		normalized[i] = (result.Score - mean) / std
+		lowest = math.Min(lowest, normalized[i])
+	}
+	for i := range normalized {
+		normalized[i] -= lowest
+	}
+	return normalized
+}
+
+// dbsfNormalize rescales scores to [0, 1] using mean ± 3 standard deviations as the bounds,
+// clamping outliers. If every score is equal, they all map to 1.
+func dbsfNormalize(results []storage.SearchResult) []float64 {
+	normalized := make([]float64, len(results))
+	mean, std := meanStdDev(results)
+	for i, result := range results {
+		if std == 0 {
+			normalized[i] = 1
+			continue
+		}
+		lo := mean - 3*std
+		normalized[i] = math.Max(0, math.Min(1, (result.Score-lo)/(6*std)))
+	}
+	return normalized
+}
+
+// meanStdDev returns the mean and population standard deviation of the scores.
+func meanStdDev(results []storage.SearchResult) (float64, float64) {
+	if len(results) == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, result := range results {
+		sum += result.Score
+	}
+	mean := sum / float64(len(results))
+
+	var variance float64
+	for _, result := range results {
+		variance += (result.Score - mean) * (result.Score - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(results)))
+}
@@ -0,0 +1,217 @@
+package ranking
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// fusionInput is a randomly generated set of result lists. IDs are drawn from a small
+// pool and scores from a handful of values so that overlaps and score ties are common.
+type fusionInput struct {
+	Lists []ResultList
+}
+
+// Generate implements quick.Generator.
+func (fusionInput) Generate(r *rand.Rand, size int) reflect.Value {
+	retrievers := []string{RetrieverSemantic, RetrieverLexical, "other"}
+	scores := []float64{0.1, 0.5, 0.5, 1, 2}
+
+	var in fusionInput
+	numLists := 1 + r.Intn(len(retrievers))
+	for l := 0; l < numLists; l++ {
+		var results []storage.SearchResult
+		for _, idx := range r.Perm(6)[:r.Intn(7)] {
+			results = append(results, result(fmt.Sprintf("doc-%d", idx), scores[r.Intn(len(scores))]))
+		}
+		// Retrievers return their results ordered by score.
+		sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+		in.Lists = append(in.Lists, ResultList{Retriever: retrievers[l], Results: results})
+	}
+	return reflect.ValueOf(in)
+}
+
+// tieBreakKey recomputes, from the input lists, the values used to break score ties.
+func tieBreakKey(in fusionInput, id string) (bestRank, priority int) {
+	bestRank, priority = -1, -1
+	for l, list := range in.Lists {
+		for i, result := range list.Results {
+			if result.Document.DocumentID != id {
+				continue
+			}
+			if bestRank == -1 || i+1 < bestRank {
+				bestRank, priority = i+1, l
+			}
+		}
+	}
+	return bestRank, priority
+}
+
+func checkProperty(t *testing.T, property interface{}) {
+	t.Helper()
+	if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFusionProperties(t *testing.T) {
+	for _, strategy := range Strategies {
+		fuser, err := NewFuser(strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := FusionConfig{Strategy: strategy}
+
+		t.Run(string(strategy), func(t *testing.T) {
+			t.Run("EveryDocumentExactlyOnce", func(t *testing.T) {
+				checkProperty(t, func(in fusionInput) bool {
+					want := make(map[string]bool)
+					for _, list := range in.Lists {
+						for _, result := range list.Results {
+							want[result.Document.DocumentID] = true
+						}
+					}
+
+					got := make(map[string]bool)
+					for _, result := range fuser.Fuse(cfg, in.Lists...) {
+						if got[result.Document.DocumentID] {
+							return false
+						}
+						got[result.Document.DocumentID] = true
+					}
+					return reflect.DeepEqual(want, got)
+				})
+			})
+
+			t.Run("Deterministic", func(t *testing.T) {
+				checkProperty(t, func(in fusionInput) bool {
+					first := fuser.Fuse(cfg, in.Lists...)
+					for i := 0; i < 5; i++ {
+						if !reflect.DeepEqual(first, fuser.Fuse(cfg, in.Lists...)) {
+							return false
+						}
+					}
+					return true
+				})
+			})
+
+			t.Run("OrderedByScoreThenTieBreak", func(t *testing.T) {
+				checkProperty(t, func(in fusionInput) bool {
+					results := fuser.Fuse(cfg, in.Lists...)
+					for i := 1; i < len(results); i++ {
+						prev, cur := results[i-1], results[i]
+						if prev.Score != cur.Score {
+							if prev.Score < cur.Score {
+								return false
+							}
+							continue
+						}
+						prevRank, prevPriority := tieBreakKey(in, prev.Document.DocumentID)
+						curRank, curPriority := tieBreakKey(in, cur.Document.DocumentID)
+						switch {
+						case prevRank != curRank:
+							if prevRank > curRank {
+								return false
+							}
+						case prevPriority != curPriority:
+							if prevPriority > curPriority {
+								return false
+							}
+						case prev.Document.DocumentID > cur.Document.DocumentID:
+							return false
+						}
+					}
+					return true
+				})
+			})
+
+			t.Run("ExplanationMatchesInput", func(t *testing.T) {
+				checkProperty(t, func(in fusionInput) bool {
+					for _, result := range fuser.Fuse(cfg, in.Lists...) {
+						var sum float64
+						for _, contribution := range result.Explanation {
+							sum += contribution.Contribution
+						}
+						if diff := sum - result.Score; diff > 1e-9 || diff < -1e-9 {
+							return false
+						}
+
+						var want []Contribution
+						for _, list := range in.Lists {
+							for i, r := range list.Results {
+								if r.Document.DocumentID == result.Document.DocumentID {
+									want = append(want, Contribution{Retriever: list.Retriever, Rank: i + 1, Score: r.Score})
+								}
+							}
+						}
+						if len(want) != len(result.Explanation) {
+							return false
+						}
+						for i, contribution := range result.Explanation {
+							contribution.Contribution = 0
+							if contribution != want[i] {
+								return false
+							}
+						}
+					}
+					return true
+				})
+			})
+
+			t.Run("UnanimousTopResultWins", func(t *testing.T) {
+				checkProperty(t, func(in fusionInput) bool {
+					// Put the same document on top of every list.
+					for l := range in.Lists {
+						top := result("unanimous", 10)
+						in.Lists[l].Results = append([]storage.SearchResult{top}, in.Lists[l].Results...)
+					}
+					results := fuser.Fuse(cfg, in.Lists...)
+					return len(results) > 0 && results[0].Document.DocumentID == "unanimous"
+				})
+			})
+
+			t.Run("RetrievedNeverScoresBelowMissing", func(t *testing.T) {
+				checkProperty(t, func(in fusionInput) bool {
+					if len(in.Lists) < 2 {
+						return true
+					}
+					// Both documents end the second list with the same, lowest score, but only
+					// "retrieved" is also returned, last and with the lowest score, by the first.
+					in.Lists[0].Results = append(in.Lists[0].Results, result("retrieved", 0))
+					in.Lists[1].Results = append(in.Lists[1].Results, result("missing", 0), result("retrieved", 0))
+
+					scores := make(map[string]float64)
+					for _, result := range fuser.Fuse(cfg, in.Lists...) {
+						scores[result.Document.DocumentID] = result.Score
+					}
+					return scores["retrieved"] >= scores["missing"]
+				})
+			})
+
+			t.Run("ScoresIndependentOfListOrder", func(t *testing.T) {
+				checkProperty(t, func(in fusionInput) bool {
+					scores := make(map[string]float64)
+					for _, result := range fuser.Fuse(cfg, in.Lists...) {
+						scores[result.Document.DocumentID] = result.Score
+					}
+
+					reversed := make([]ResultList, len(in.Lists))
+					for i, list := range in.Lists {
+						reversed[len(in.Lists)-1-i] = list
+					}
+					for _, result := range fuser.Fuse(cfg, reversed...) {
+						if diff := scores[result.Document.DocumentID] - result.Score; diff > 1e-9 || diff < -1e-9 {
+							return false
+						}
+					}
+					return true
+				})
+			})
+		})
+	}
+}
@@ -1,47 +1,114 @@
 package ranking
 
 import (
-	"sort"
-
 	"github.com/chr1sbest/hybrid-search/pkg/storage"
 )
 
-// ReciprocalRankFusion combines multiple sets of search results using the RRF algorithm.
-// It returns a single, re-ranked list of documents.
-func ReciprocalRankFusion(resultsSets ...[]storage.SearchResult) []storage.Document {
-	const k = 60.0 // RRF constant
-
-	// scores maps document IDs to their RRF scores.
-	scores := make(map[string]float64)
-	// docs maps document IDs to the actual Document object to avoid duplicates.
-	docs := make(map[string]storage.Document)
-
-	for _, results := range resultsSets {
-		for i, result := range results {
-			rank := i + 1
-			score := 1.0 / (k + float64(rank))
-			docID := result.Document.DocumentID
-
-			scores[docID] += score
-			// If we haven't seen this document, or if the stored version has no text
-			// and this one does, store it.
-			existingDoc, ok := docs[docID]
-			if !ok || (existingDoc.Text == "" && result.Document.Text != "") {
-				docs[docID] = result.Document
-			}
-		}
+// Retriever names identify which retriever produced a result list.
+// They are used as keys for per-retriever fusion weights.
+const (
+	RetrieverLexical  = "lexical"
+	RetrieverSemantic = "semantic"
+)
+
+// DefaultK is the RRF constant used when no other value is configured.
+const DefaultK = 60.0
+
+// ResultList is a ranked set of search results tagged with the retriever that produced it.
+type ResultList struct {
+	Retriever string
+	Results   []storage.SearchResult
+}
+
+// Result is a single document in a fused ranking.
+type Result struct {
+	Document storage.Document
+	// Score is the fused score the ranking is ordered by.
+	Score float64
+	// Explanation holds one entry per result list the document appeared in, in list order.
+	Explanation []Contribution
+	// RerankScore is the relevance score assigned by a reranker, if one scored this result.
+	RerankScore *float64
+	// Highlights are fragments of the document text with query matches marked, if requested.
+	Highlights []string
+}
+
+// Contribution describes what a single retriever added to a document's fused score.
+type Contribution struct {
+	Retriever string
+	// Rank is the document's 1-based position in the retriever's result list.
+	Rank int
+	// Score is the raw score the retriever assigned to the document.
+	Score float64
+	// Contribution is the amount this retriever added to the fused score.
+	Contribution float64
+}
+
+// FusionConfig controls how result lists are combined.
+// An empty Strategy falls back to RRF, a zero K falls back to DefaultK, and retrievers
+// without an entry in Weights get a weight of 1.
+type FusionConfig struct {
+	Strategy Strategy
+	K        float64
+	Weights  map[string]float64
+}
+
+// DefaultFusionConfig returns the classic, unweighted RRF configuration.
+func DefaultFusionConfig() FusionConfig {
+	return FusionConfig{Strategy: StrategyRRF, K: DefaultK}
+}
+
+// Merge returns a copy of c with every value set in override applied on top of it.
+// It is used to layer per-request settings over the server defaults.
+func (c FusionConfig) Merge(override FusionConfig) FusionConfig {
+	merged := FusionConfig{Strategy: c.Strategy, K: c.K, Weights: make(map[string]float64, len(c.Weights)+len(override.Weights))}
+	if override.Strategy != "" {
+		merged.Strategy = override.Strategy
+	}
+	if override.K > 0 {
+		merged.K = override.K
+	}
+	for retriever, weight := range c.Weights {
+		merged.Weights[retriever] = weight
 	}
+	for retriever, weight := range override.Weights {
+		merged.Weights[retriever] = weight
+	}
+	return merged
+}
 
-	// Convert the map of documents to a slice for sorting.
-	var rankedDocs []storage.Document
-	for _, doc := range docs {
-		rankedDocs = append(rankedDocs, doc)
+// Weight returns the fusion weight for the given retriever.
+func (c FusionConfig) Weight(retriever string) float64 {
+	if weight, ok := c.Weights[retriever]; ok {
+		return weight
 	}
+	return 1.0
+}
 
-	// Sort the documents by their RRF score in descending order.
-	sort.Slice(rankedDocs, func(i, j int) bool {
-		return scores[rankedDocs[i].DocumentID] > scores[rankedDocs[j].DocumentID]
-	})
+func (c FusionConfig) k() float64 {
+	if c.K > 0 {
+		return c.K
+	}
+	return DefaultK
+}
+
+// ReciprocalRankFusion combines multiple sets of search results using the RRF algorithm.
+// Every set is weighted equally and the default k is used.
+// It returns a single, re-ranked list of fused results.
+func ReciprocalRankFusion(resultsSets ...[]storage.SearchResult) []Result {
+	lists := make([]ResultList, len(resultsSets))
+	for i, results := range resultsSets {
+		lists[i] = ResultList{Results: results}
+	}
+	return WeightedReciprocalRankFusion(DefaultFusionConfig(), lists...)
+}
 
-	return rankedDocs
+// WeightedReciprocalRankFusion combines result lists using RRF, scaling each list's
+// contribution by its retriever weight:
+//
+//	score(d) = Σ weight_i / (k + rank_i(d))
+//
+// It returns a single, re-ranked list of fused results.
+func WeightedReciprocalRankFusion(cfg FusionConfig, lists ...ResultList) []Result {
+	return rrfFuser{}.Fuse(cfg, lists...)
 }
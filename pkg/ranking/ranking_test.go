@@ -0,0 +1,213 @@
+package ranking
+
+import (
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func result(id string, score float64) storage.SearchResult {
+	return storage.SearchResult{Document: storage.Document{DocumentID: id}, Score: score}
+}
+
+func ids(results []Result) []string {
+	out := make([]string, len(results))
+	for i, result := range results {
+		out[i] = result.Document.DocumentID
+	}
+	return out
+}
+
+func TestFusers(t *testing.T) {
+	// The lexical retriever is very confident about A, while the semantic retriever
+	// barely separates C and B.
+	lists := []ResultList{
+		{Retriever: RetrieverLexical, Results: []storage.SearchResult{
+			result("A", 12), result("B", 4), result("C", 3), result("E", 1),
+		}},
+		{Retriever: RetrieverSemantic, Results: []storage.SearchResult{
+			result("C", 0.82), result("B", 0.80), result("D", 0.35),
+		}},
+	}
+	semanticHeavy := map[string]float64{RetrieverSemantic: 3}
+
+	tests := []struct {
+		name     string
+		strategy Strategy
+		weights  map[string]float64
+		// wantTop is the expected prefix of the ranking. Documents that tie are left out.
+		wantTop []string
+	}{
+		{name: "RRF", strategy: StrategyRRF, wantTop: []string{"C", "B", "A", "D", "E"}},
+		{name: "RRFWeighted", strategy: StrategyRRF, weights: semanticHeavy, wantTop: []string{"C", "B", "D", "A", "E"}},
+		{name: "MinMax", strategy: StrategyMinMax, wantTop: []string{"B", "C", "A"}},
+		{name: "MinMaxWeighted", strategy: StrategyMinMax, weights: semanticHeavy, wantTop: []string{"C", "B", "A"}},
+		{name: "ZScore", strategy: StrategyZScore, wantTop: []string{"B", "C", "A"}},
+		{name: "ZScoreWeighted", strategy: StrategyZScore, weights: semanticHeavy, wantTop: []string{"C", "B", "A"}},
+		{name: "DBSF", strategy: StrategyDBSF, wantTop: []string{"B", "C", "A", "E", "D"}},
+		{name: "DBSFWeighted", strategy: StrategyDBSF, weights: semanticHeavy, wantTop: []string{"C", "B", "D", "A", "E"}},
+		{name: "CombSUM", strategy: StrategyCombSUM, wantTop: []string{"B", "C", "A"}},
+		{name: "CombMNZ", strategy: StrategyCombMNZ, wantTop: []string{"B", "C", "A"}},
+		{name: "CombMNZWeighted", strategy: StrategyCombMNZ, weights: semanticHeavy, wantTop: []string{"C", "B", "A"}},
+		{name: "BordaWeighted", strategy: StrategyBorda, weights: semanticHeavy, wantTop: []string{"C", "B", "D", "A", "E"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Fuse(FusionConfig{Strategy: tt.strategy, Weights: tt.weights}, lists...)
+
+			assert.NoError(t, err)
+			assert.Len(t, results, 5, "Every distinct document should be returned once")
+			assert.Equal(t, tt.wantTop, ids(results)[:len(tt.wantTop)])
+		})
+	}
+}
+
+func TestFusers_EdgeCases(t *testing.T) {
+	for _, strategy := range Strategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			fuser, err := NewFuser(strategy)
+			assert.NoError(t, err)
+
+			t.Run("NoResults", func(t *testing.T) {
+				assert.Empty(t, fuser.Fuse(DefaultFusionConfig()))
+				assert.Empty(t, fuser.Fuse(DefaultFusionConfig(), ResultList{Retriever: RetrieverLexical}))
+			})
+
+			t.Run("SingleResult", func(t *testing.T) {
+				results := fuser.Fuse(DefaultFusionConfig(), ResultList{
+					Retriever: RetrieverLexical,
+					Results:   []storage.SearchResult{result("A", 1)},
+				})
+				assert.Equal(t, []string{"A"}, ids(results))
+			})
+
+			t.Run("PrefersDocumentWithText", func(t *testing.T) {
+				results := fuser.Fuse(DefaultFusionConfig(),
+					ResultList{Retriever: RetrieverSemantic, Results: []storage.SearchResult{result("A", 1)}},
+					ResultList{Retriever: RetrieverLexical, Results: []storage.SearchResult{
+						{Document: storage.Document{DocumentID: "A", Text: "text"}, Score: 1},
+					}},
+				)
+				assert.Len(t, results, 1)
+				assert.Equal(t, "text", results[0].Document.Text)
+			})
+
+			t.Run("KeepsHighlights", func(t *testing.T) {
+				results := fuser.Fuse(DefaultFusionConfig(),
+					ResultList{Retriever: RetrieverSemantic, Results: []storage.SearchResult{result("A", 1)}},
+					ResultList{Retriever: RetrieverLexical, Results: []storage.SearchResult{
+						{Document: storage.Document{DocumentID: "A"}, Score: 1, Highlights: []string{"<em>a</em>"}},
+					}},
+				)
+				assert.Equal(t, []string{"<em>a</em>"}, results[0].Highlights)
+			})
+		})
+	}
+}
+
+func TestFusers_Explanation(t *testing.T) {
+	lists := []ResultList{
+		{Retriever: RetrieverLexical, Results: []storage.SearchResult{result("A", 3), result("B", 2)}},
+		{Retriever: RetrieverSemantic, Results: []storage.SearchResult{result("B", 0.9)}},
+	}
+
+	t.Run("RRF", func(t *testing.T) {
+		results := WeightedReciprocalRankFusion(FusionConfig{K: 60, Weights: map[string]float64{RetrieverSemantic: 2}}, lists...)
+
+		assert.Equal(t, []string{"B", "A"}, ids(results))
+		assert.InDelta(t, 1.0/62+2.0/61, results[0].Score, 1e-12)
+		assert.Equal(t, []Contribution{
+			{Retriever: RetrieverLexical, Rank: 2, Score: 2, Contribution: 1.0 / 62},
+			{Retriever: RetrieverSemantic, Rank: 1, Score: 0.9, Contribution: 2.0 / 61},
+		}, results[0].Explanation)
+	})
+
+	for _, strategy := range Strategies {
+		t.Run("ContributionsSumToScore/"+string(strategy), func(t *testing.T) {
+			results, err := Fuse(FusionConfig{Strategy: strategy}, lists...)
+			assert.NoError(t, err)
+			for _, result := range results {
+				var sum float64
+				for _, contribution := range result.Explanation {
+					sum += contribution.Contribution
+				}
+				assert.InDelta(t, result.Score, sum, 1e-9)
+			}
+		})
+	}
+}
+
+func TestFusers_TieBreaking(t *testing.T) {
+	t.Run("BestRankThenPriorityThenID", func(t *testing.T) {
+		// Both documents get the same RRF score of 1/(k+1) + 1/(k+2).
+		lists := []ResultList{
+			{Retriever: RetrieverSemantic, Results: []storage.SearchResult{result("D", 1), result("C", 1)}},
+			{Retriever: RetrieverLexical, Results: []storage.SearchResult{result("C", 1), result("D", 1)}},
+		}
+		// C and D tie on score and best rank, but D is ranked first by the higher-priority list.
+		assert.Equal(t, []string{"D", "C"}, ids(ReciprocalRankFusion(lists[0].Results, lists[1].Results)))
+	})
+
+	t.Run("BestRankWins", func(t *testing.T) {
+		// With equal-weight Borda every document scores 4 points. C and A both reach rank 1,
+		// C in the higher-priority list, while B never does better than 2nd.
+		lists := []ResultList{
+			{Retriever: RetrieverSemantic, Results: []storage.SearchResult{result("C", 1), result("B", 1), result("A", 1)}},
+			{Retriever: RetrieverLexical, Results: []storage.SearchResult{result("A", 1), result("B", 1), result("C", 1)}},
+		}
+		results, err := Fuse(FusionConfig{Strategy: StrategyBorda}, lists...)
+		assert.NoError(t, err)
+		for _, result := range results {
+			assert.Equal(t, 4.0, result.Score)
+		}
+		assert.Equal(t, []string{"C", "A", "B"}, ids(results))
+	})
+
+	t.Run("IDBreaksRemainingTies", func(t *testing.T) {
+		// Retrievers never rank two documents at the same position, so this last
+		// tie-break is exercised directly.
+		a := &entry{Result: Result{Document: storage.Document{DocumentID: "a"}, Score: 1}, bestRank: 1}
+		b := &entry{Result: Result{Document: storage.Document{DocumentID: "b"}, Score: 1}, bestRank: 1}
+		assert.True(t, a.before(b))
+		assert.False(t, b.before(a))
+	})
+}
+
+func TestNewFuser_UnknownStrategy(t *testing.T) {
+	_, err := NewFuser("nope")
+	assert.Error(t, err)
+
+	_, err = Fuse(FusionConfig{Strategy: "nope"})
+	assert.Error(t, err)
+}
+
+func TestFusionConfig_Merge(t *testing.T) {
+	defaults := FusionConfig{
+		Strategy: StrategyRRF,
+		K:        60,
+		Weights:  map[string]float64{RetrieverLexical: 1, RetrieverSemantic: 2},
+	}
+
+	t.Run("EmptyOverrideKeepsDefaults", func(t *testing.T) {
+		assert.Equal(t, defaults, defaults.Merge(FusionConfig{}))
+	})
+
+	t.Run("OverrideWins", func(t *testing.T) {
+		merged := defaults.Merge(FusionConfig{
+			Strategy: StrategyBorda,
+			K:        10,
+			Weights:  map[string]float64{RetrieverSemantic: 0},
+		})
+		assert.Equal(t, StrategyBorda, merged.Strategy)
+		assert.Equal(t, 10.0, merged.K)
+		assert.Equal(t, 1.0, merged.Weight(RetrieverLexical))
+		assert.Equal(t, 0.0, merged.Weight(RetrieverSemantic))
+		assert.Equal(t, 2.0, defaults.Weight(RetrieverSemantic), "Merge must not modify the defaults")
+	})
+
+	t.Run("UnknownRetrieverDefaultsToOne", func(t *testing.T) {
+		assert.Equal(t, 1.0, defaults.Weight("other"))
+	})
+}
@@ -0,0 +1,164 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// Protocol selects the request format spoken by a /rerank server.
+type Protocol string
+
+const (
+	// ProtocolTEI is the format used by Hugging Face Text Embeddings Inference:
+	// {"query", "texts"} in, [{"index", "score"}] out.
+	ProtocolTEI Protocol = "tei"
+	// ProtocolCohere is the format used by Cohere and compatible servers:
+	// {"model", "query", "documents", "top_n"} in, {"results": [{"index", "relevance_score"}]} out.
+	ProtocolCohere Protocol = "cohere"
+)
+
+// HTTPReranker calls a cross-encoder served behind a /rerank HTTP endpoint.
+// It implements the Reranker interface.
+type HTTPReranker struct {
+	client   *http.Client
+	endpoint string
+	protocol Protocol
+	model    string
+	apiKey   string
+}
+
+// NewHTTPReranker creates a client for the /rerank endpoint under baseURL.
+// model and apiKey are optional; TEI servers ignore the model.
+func NewHTTPReranker(baseURL string, protocol Protocol, model, apiKey string) (*HTTPReranker, error) {
+	if protocol != ProtocolTEI && protocol != ProtocolCohere {
+		return nil, fmt.Errorf("unknown rerank protocol %q", protocol)
+	}
+	return &HTTPReranker{
+		client:   http.DefaultClient,
+		endpoint: strings.TrimSuffix(baseURL, "/") + "/rerank",
+		protocol: protocol,
+		model:    model,
+		apiKey:   apiKey,
+	}, nil
+}
+
+type teiRequest struct {
+	Query string   `json:"query"`
+	Texts []string `json:"texts"`
+}
+
+type cohereRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+// rankedIndex is a single scored document in a /rerank response. TEI reports "score"
+// while Cohere reports "relevance_score".
+type rankedIndex struct {
+	Index          int      `json:"index"`
+	Score          *float64 `json:"score"`
+	RelevanceScore *float64 `json:"relevance_score"`
+}
+
+// Rerank sends the documents to the /rerank endpoint and maps the scores back to their order.
+func (r *HTTPReranker) Rerank(ctx context.Context, query string, docs []storage.Document) ([]float64, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.Text
+	}
+
+	var payload interface{} = teiRequest{Query: query, Texts: texts}
+	if r.protocol == ProtocolCohere {
+		payload = cohereRequest{Model: r.model, Query: query, Documents: texts, TopN: len(texts)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling rerank request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating rerank request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling rerank endpoint: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("rerank endpoint returned %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+
+	ranked, err := decodeRanked(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(docs))
+	scored := make([]bool, len(docs))
+	for _, item := range ranked {
+		if item.Index < 0 || item.Index >= len(docs) {
+			return nil, fmt.Errorf("rerank response index %d out of range", item.Index)
+		}
+		switch {
+		case item.Score != nil:
+			scores[item.Index] = *item.Score
+		case item.RelevanceScore != nil:
+			scores[item.Index] = *item.RelevanceScore
+		default:
+			return nil, fmt.Errorf("rerank response for index %d has no score", item.Index)
+		}
+		scored[item.Index] = true
+	}
+	for i, ok := range scored {
+		if !ok {
+			return nil, fmt.Errorf("rerank response is missing a score for index %d", i)
+		}
+	}
+	return scores, nil
+}
+
+// decodeRanked accepts both the bare array returned by TEI and the {"results": [...]}
+// object returned by Cohere-compatible servers.
+func decodeRanked(body io.Reader) ([]rankedIndex, error) {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rerank response: %w", err)
+	}
+
+	var ranked []rankedIndex
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &ranked); err != nil {
+			return nil, fmt.Errorf("error parsing rerank response: %w", err)
+		}
+		return ranked, nil
+	}
+
+	var wrapped struct {
+		Results []rankedIndex `json:"results"`
+	}
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		return nil, fmt.Errorf("error parsing rerank response: %w", err)
+	}
+	return wrapped.Results, nil
+}
@@ -0,0 +1,156 @@
+package rerank
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/cache"
+	"github.com/chr1sbest/hybrid-search/pkg/llm"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+const listwiseSystemPrompt = `You are an expert search relevance judge.
+You will be given a search query and a numbered list of passages.
+Rank the passages from most to least relevant to the query.
+Reply with the passage numbers only, most relevant first, in the form [2] > [1] > [3].
+Include every passage exactly once and do not explain your answer.`
+
+// minPassageTokens is the smallest share of the budget a passage is truncated to,
+// so every candidate stays recognisable however many there are.
+const minPassageTokens = 32
+
+var (
+	bracketedID = regexp.MustCompile(`\[(\d+)\]`)
+	bareID      = regexp.MustCompile(`\d+`)
+)
+
+// LLMReranker asks a chat model to order the candidate passages in a single prompt
+// (listwise reranking). Rankings are cached by query and passages.
+// It implements the Reranker interface.
+type LLMReranker struct {
+	chat      llm.ChatClient
+	maxTokens int
+	cache     *cache.LRU[string, []float64]
+}
+
+// NewLLMReranker creates a listwise reranker. maxTokens is the approximate prompt budget
+// shared by the query and the passages; longer inputs are truncated to fit. Up to
+// cacheSize rankings are cached for cacheTTL.
+func NewLLMReranker(chat llm.ChatClient, maxTokens, cacheSize int, cacheTTL time.Duration) *LLMReranker {
+	return &LLMReranker{
+		chat:      chat,
+		maxTokens: maxTokens,
+		cache:     cache.NewLRU[string, []float64](cacheSize, cacheTTL),
+	}
+}
+
+// Rerank scores documents by the position the model ranked them at: the first gets 1 and
+// scores fall linearly towards 0. Documents the model leaves out keep their input order
+// after the ranked ones. It returns an error if no usable ranking can be parsed.
+func (r *LLMReranker) Rerank(ctx context.Context, query string, docs []storage.Document) ([]float64, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	key := cacheKey(query, docs)
+	if scores, ok := r.cache.Get(key); ok {
+		return scores, nil
+	}
+
+	prompt, sent := r.prompt(query, docs)
+	reply, err := r.chat.Complete(ctx, []llm.Message{
+		{Role: llm.RoleSystem, Content: listwiseSystemPrompt},
+		{Role: llm.RoleUser, Content: prompt},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error calling chat model: %w", err)
+	}
+
+	order, err := parseRanking(reply, sent)
+	if err != nil {
+		return nil, err
+	}
+	// Documents left out of the prompt keep their input order after the ranked ones.
+	for i := sent; i < len(docs); i++ {
+		order = append(order, i)
+	}
+
+	scores := make([]float64, len(docs))
+	for position, idx := range order {
+		scores[idx] = 1 - float64(position)/float64(len(docs))
+	}
+	r.cache.Add(key, scores)
+	return scores, nil
+}
+
+// prompt builds the user message, truncating the query and passages to the token budget.
+// Passages that would not fit even at minPassageTokens are left out from the end, though the
+// first is always sent. It returns the message and the number of passages in it.
+func (r *LLMReranker) prompt(query string, docs []storage.Document) (string, int) {
+	queryBudget := r.maxTokens / 4
+	available := r.maxTokens - min(llm.EstimateTokens(query), queryBudget)
+	n := min(len(docs), max(available/minPassageTokens, 1))
+	passageBudget := max(available/n, minPassageTokens)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Query: %s\n\nPassages:\n", llm.TruncateToTokens(query, queryBudget))
+	for i, doc := range docs[:n] {
+		text := strings.Join(strings.Fields(doc.Text), " ")
+		fmt.Fprintf(&b, "[%d] %s\n", i+1, llm.TruncateToTokens(text, passageBudget))
+	}
+	b.WriteString("\nRanking:")
+	return b.String(), n
+}
+
+// parseRanking extracts 0-based document indexes from the model's reply, in ranked order.
+// Bracketed numbers such as "[3]" are preferred; bare numbers are accepted if there are none.
+// Out-of-range and repeated numbers are ignored, and unranked documents are appended in order.
+func parseRanking(reply string, n int) ([]int, error) {
+	var ids []string
+	for _, match := range bracketedID.FindAllStringSubmatch(reply, -1) {
+		ids = append(ids, match[1])
+	}
+	if len(ids) == 0 {
+		ids = bareID.FindAllString(reply, -1)
+	}
+
+	seen := make([]bool, n)
+	var order []int
+	for _, id := range ids {
+		num, err := strconv.Atoi(id)
+		if err != nil || num < 1 || num > n || seen[num-1] {
+			continue
+		}
+		seen[num-1] = true
+		order = append(order, num-1)
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("could not parse a ranking from model reply %q", reply)
+	}
+
+	for i, ok := range seen {
+		if !ok {
+			order = append(order, i)
+		}
+	}
+	return order, nil
+}
+
+// cacheKey identifies a ranking request by its query and the exact passages sent.
+func cacheKey(query string, docs []storage.Document) string {
+	h := sha256.New()
+	h.Write([]byte(query))
+	for _, doc := range docs {
+		h.Write([]byte{0})
+		h.Write([]byte(doc.DocumentID))
+		h.Write([]byte{0})
+		h.Write([]byte(doc.Text))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
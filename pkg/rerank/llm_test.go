@@ -0,0 +1,133 @@
+package rerank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/llm"
+	llm_mocks "github.com/chr1sbest/hybrid-search/pkg/llm/mocks"
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLLMReranker(t *testing.T) {
+	docs := []storage.Document{
+		{DocumentID: "doc-1", Text: "How to reset a password."},
+		{DocumentID: "doc-2", Text: "Billing cycles and invoices."},
+		{DocumentID: "doc-3", Text: "Downloading past invoices."},
+	}
+
+	t.Run("ScoresByRankedPosition", func(t *testing.T) {
+		mockChat := new(llm_mocks.ChatClient)
+		mockChat.On("Complete", mock.Anything, mock.Anything).Return("[3] > [2] > [1]", nil).Once()
+
+		reranker := NewLLMReranker(mockChat, 1000, 10, time.Minute)
+		scores, err := reranker.Rerank(context.Background(), "invoices", docs)
+
+		assert.NoError(t, err)
+		assert.InDeltaSlice(t, []float64{1.0 / 3, 2.0 / 3, 1}, scores, 1e-9)
+
+		// A second identical request is served from the cache.
+		cached, err := reranker.Rerank(context.Background(), "invoices", docs)
+		assert.NoError(t, err)
+		assert.Equal(t, scores, cached)
+		mockChat.AssertExpectations(t)
+	})
+
+	t.Run("SendsNumberedPassages", func(t *testing.T) {
+		mockChat := new(llm_mocks.ChatClient)
+		mockChat.On("Complete", mock.Anything, mock.MatchedBy(func(messages []llm.Message) bool {
+			return len(messages) == 2 &&
+				messages[0].Role == llm.RoleSystem &&
+				strings.Contains(messages[1].Content, "Query: invoices") &&
+				strings.Contains(messages[1].Content, "[2] Billing cycles and invoices.")
+		})).Return("[1]", nil)
+
+		_, err := NewLLMReranker(mockChat, 1000, 0, 0).Rerank(context.Background(), "invoices", docs)
+		assert.NoError(t, err)
+		mockChat.AssertExpectations(t)
+	})
+
+	t.Run("TruncatesToBudget", func(t *testing.T) {
+		long := []storage.Document{{DocumentID: "long", Text: strings.Repeat("invoice ", 2000)}}
+
+		mockChat := new(llm_mocks.ChatClient)
+		mockChat.On("Complete", mock.Anything, mock.MatchedBy(func(messages []llm.Message) bool {
+			return llm.EstimateTokens(messages[1].Content) < 200
+		})).Return("[1]", nil)
+
+		_, err := NewLLMReranker(mockChat, 100, 0, 0).Rerank(context.Background(), "invoices", long)
+		assert.NoError(t, err)
+		mockChat.AssertExpectations(t)
+	})
+
+	t.Run("DropsPassagesPastBudget", func(t *testing.T) {
+		// 1. Arrange: an 80 token budget holds the query and two passages at the minimum size.
+		var many []storage.Document
+		for i := range 5 {
+			many = append(many, storage.Document{DocumentID: fmt.Sprintf("doc-%d", i+1), Text: strings.Repeat("invoice ", 200)})
+		}
+		mockChat := new(llm_mocks.ChatClient)
+		mockChat.On("Complete", mock.Anything, mock.MatchedBy(func(messages []llm.Message) bool {
+			return llm.EstimateTokens(messages[1].Content) < 100 &&
+				strings.Contains(messages[1].Content, "[2] ") && !strings.Contains(messages[1].Content, "[3] ")
+		})).Return("[2] > [1]", nil)
+
+		// 2. Act
+		scores, err := NewLLMReranker(mockChat, 80, 0, 0).Rerank(context.Background(), "invoices", many)
+
+		// 3. Assert: the passages left out keep their order below the ranked ones.
+		assert.NoError(t, err)
+		assert.InDeltaSlice(t, []float64{0.8, 1, 0.6, 0.4, 0.2}, scores, 1e-9)
+		mockChat.AssertExpectations(t)
+	})
+
+	t.Run("ModelError", func(t *testing.T) {
+		mockChat := new(llm_mocks.ChatClient)
+		mockChat.On("Complete", mock.Anything, mock.Anything).Return("", errors.New("unavailable"))
+
+		_, err := NewLLMReranker(mockChat, 1000, 0, 0).Rerank(context.Background(), "invoices", docs)
+		assert.Error(t, err)
+	})
+
+	t.Run("UnparseableReplyIsNotCached", func(t *testing.T) {
+		mockChat := new(llm_mocks.ChatClient)
+		mockChat.On("Complete", mock.Anything, mock.Anything).Return("I cannot rank these.", nil).Twice()
+
+		reranker := NewLLMReranker(mockChat, 1000, 10, time.Minute)
+		for i := 0; i < 2; i++ {
+			_, err := reranker.Rerank(context.Background(), "invoices", docs)
+			assert.Error(t, err)
+		}
+		mockChat.AssertExpectations(t)
+	})
+}
+
+func TestParseRanking(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  []int
+	}{
+		{name: "Bracketed", reply: "[2] > [3] > [1]", want: []int{1, 2, 0}},
+		{name: "BareNumbers", reply: "2, 3, 1", want: []int{1, 2, 0}},
+		{name: "PrefersBracketsOverProse", reply: "Of the 3 passages: [3] > [1] > [2]", want: []int{2, 0, 1}},
+		{name: "MissingAppendedInOrder", reply: "[3]", want: []int{2, 0, 1}},
+		{name: "DuplicatesAndOutOfRangeIgnored", reply: "[2] > [2] > [9] > [0] > [1]", want: []int{1, 0, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRanking(tt.reply, 3)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := parseRanking("none of them", 3)
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package rerank
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// Reranker scores candidate documents against a query so the most relevant can be moved to the top.
+// This allows for a pluggable reranking model, such as a cross-encoder.
+type Reranker interface {
+	// Rerank returns one relevance score per document, aligned with docs. Higher is more relevant.
+	Rerank(ctx context.Context, query string, docs []storage.Document) ([]float64, error)
+}
+
+// LexicalReranker is a deterministic Reranker that scores documents by the fraction of
+// distinct query terms they contain. It needs no model, which makes it useful for tests
+// and as a cheap baseline.
+type LexicalReranker struct{}
+
+// NewLexicalReranker creates a new LexicalReranker.
+func NewLexicalReranker() *LexicalReranker {
+	return &LexicalReranker{}
+}
+
+// Rerank scores each document by its query term overlap, between 0 and 1.
+func (r *LexicalReranker) Rerank(ctx context.Context, query string, docs []storage.Document) ([]float64, error) {
+	queryTerms := termSet(query)
+	scores := make([]float64, len(docs))
+	if len(queryTerms) == 0 {
+		return scores, nil
+	}
+
+	for i, doc := range docs {
+		docTerms := termSet(doc.Text)
+		var matched int
+		for term := range queryTerms {
+			if docTerms[term] {
+				matched++
+			}
+		}
+		scores[i] = float64(matched) / float64(len(queryTerms))
+	}
+	return scores, nil
+}
+
+// termSet returns the distinct lower-cased alphanumeric terms in text.
+func termSet(text string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, term := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		terms[term] = true
+	}
+	return terms
+}
@@ -0,0 +1,112 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+var testDocs = []storage.Document{
+	{DocumentID: "doc-1", Text: "How to reset a password."},
+	{DocumentID: "doc-2", Text: "Billing cycles and invoices."},
+}
+
+func TestLexicalReranker(t *testing.T) {
+	reranker := NewLexicalReranker()
+
+	scores, err := reranker.Rerank(context.Background(), "billing invoices?", testDocs)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0, 1}, scores)
+
+	scores, err = reranker.Rerank(context.Background(), "Reset billing", testDocs)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.5, 0.5}, scores)
+
+	scores, err = reranker.Rerank(context.Background(), "  ", testDocs)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0, 0}, scores)
+}
+
+func TestHTTPReranker(t *testing.T) {
+	t.Run("TEI", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/rerank", r.URL.Path)
+
+			var req teiRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "billing", req.Query)
+			assert.Equal(t, []string{testDocs[0].Text, testDocs[1].Text}, req.Texts)
+
+			// TEI returns results sorted by score, not by input order.
+			w.Write([]byte(`[{"index": 1, "score": 0.98}, {"index": 0, "score": 0.02}]`))
+		}))
+		defer server.Close()
+
+		reranker, err := NewHTTPReranker(server.URL, ProtocolTEI, "", "")
+		assert.NoError(t, err)
+
+		scores, err := reranker.Rerank(context.Background(), "billing", testDocs)
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{0.02, 0.98}, scores)
+	})
+
+	t.Run("Cohere", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/rerank", r.URL.Path)
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+			var req cohereRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "rerank-model", req.Model)
+			assert.Equal(t, 2, req.TopN)
+			assert.Len(t, req.Documents, 2)
+
+			w.Write([]byte(`{"results": [{"index": 0, "relevance_score": 0.7}, {"index": 1, "relevance_score": 0.1}]}`))
+		}))
+		defer server.Close()
+
+		reranker, err := NewHTTPReranker(server.URL+"/v1/", ProtocolCohere, "rerank-model", "secret")
+		assert.NoError(t, err)
+
+		scores, err := reranker.Rerank(context.Background(), "password", testDocs)
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{0.7, 0.1}, scores)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			status int
+			body   string
+		}{
+			{name: "ServerError", status: http.StatusInternalServerError, body: `model not loaded`},
+			{name: "MalformedBody", status: http.StatusOK, body: `not json`},
+			{name: "IndexOutOfRange", status: http.StatusOK, body: `[{"index": 5, "score": 1}]`},
+			{name: "MissingScore", status: http.StatusOK, body: `[{"index": 0, "score": 1}]`},
+			{name: "NoScoreField", status: http.StatusOK, body: `[{"index": 0}, {"index": 1}]`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.body))
+				}))
+				defer server.Close()
+
+				reranker, _ := NewHTTPReranker(server.URL, ProtocolTEI, "", "")
+				_, err := reranker.Rerank(context.Background(), "q", testDocs)
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("UnknownProtocol", func(t *testing.T) {
+		_, err := NewHTTPReranker("http://localhost", "grpc", "", "")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,54 @@
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy describes how often, and how patiently, an operation is retried.
+type Policy struct {
+	// MaxAttempts is the number of times the operation is tried, including the first. Values
+	// below 1 mean a single attempt.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry. It doubles with each retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts. Zero means no cap.
+	MaxBackoff time.Duration
+}
+
+// Do calls fn until it succeeds, the attempts run out, or ctx is cancelled, and returns the
+// last error.
+func (p Policy) Do(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts {
+			return err
+		}
+
+		timer := time.NewTimer(p.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Backoff returns how long to wait after the given failed attempt, counting from 1. Waits
+// are randomized between half and all of the backoff, so that clients failing together don't
+// retry together.
+func (p Policy) Backoff(attempt int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || wait < p.MaxBackoff); i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 {
+		wait = min(wait, p.MaxBackoff)
+	}
+	if wait <= 0 {
+		return 0
+	}
+	return wait/2 + rand.N(wait/2+1)
+}
@@ -0,0 +1,74 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Do(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	t.Run("RetriesUntilSuccess", func(t *testing.T) {
+		calls := 0
+		err := policy.Do(context.Background(), func() error {
+			calls++
+			if calls < 3 {
+				return errors.New("unavailable")
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("ReturnsLastError", func(t *testing.T) {
+		calls := 0
+		err := policy.Do(context.Background(), func() error {
+			calls++
+			return errors.New("unavailable")
+		})
+
+		assert.EqualError(t, err, "unavailable")
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("SingleAttempt", func(t *testing.T) {
+		calls := 0
+		err := Policy{}.Do(context.Background(), func() error {
+			calls++
+			return errors.New("unavailable")
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("StopsWhenCancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := Policy{MaxAttempts: 5, InitialBackoff: time.Hour}.Do(ctx, func() error {
+			calls++
+			cancel()
+			return errors.New("unavailable")
+		})
+
+		assert.EqualError(t, err, "unavailable")
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestPolicy_Backoff(t *testing.T) {
+	policy := Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 10: time.Second} {
+		wait := policy.Backoff(attempt)
+		assert.GreaterOrEqual(t, wait, want/2, "attempt %d", attempt)
+		assert.LessOrEqual(t, wait, want, "attempt %d", attempt)
+	}
+	assert.Zero(t, Policy{}.Backoff(3))
+}
@@ -0,0 +1,150 @@
+package rewrite
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/cache"
+	"github.com/chr1sbest/hybrid-search/pkg/llm"
+)
+
+const multiQuerySystemPrompt = `You rewrite search queries to improve document retrieval.
+Given a query, write %d alternative versions of it that ask for the same information using different wording,
+synonyms or a more specific phrasing. Reply with one query per line and nothing else.`
+
+const hydeSystemPrompt = `You write passages that answer search queries.
+Given a query, write a short, factual passage of two to four sentences that would answer it, in the style
+of a reference document. Reply with the passage only.`
+
+// hydeMaxTokens bounds the hypothetical document so an overlong reply cannot dominate retrieval.
+const hydeMaxTokens = 500
+
+// listMarker matches the numbering or bullet a model may put in front of each rewritten query.
+var listMarker = regexp.MustCompile(`^\s*(?:\d+[.):]|[-*•])\s*`)
+
+// Variant is an alternative form of a query to retrieve with.
+type Variant struct {
+	Text string
+	// SemanticOnly restricts the variant to the semantic retriever. Hypothetical documents
+	// are long and make poor keyword queries.
+	SemanticOnly bool
+}
+
+// Rewriter is the interface for any pre-retrieval query rewriter.
+// This allows for pluggable rewriting strategies.
+type Rewriter interface {
+	// Rewrite returns variants of query to search with in addition to the original.
+	Rewrite(ctx context.Context, query string) ([]Variant, error)
+}
+
+// MultiQueryRewriter asks a chat model for paraphrases of the query.
+// It implements the Rewriter interface.
+type MultiQueryRewriter struct {
+	chat  llm.ChatClient
+	n     int
+	cache *cache.LRU[string, []Variant]
+}
+
+// NewMultiQueryRewriter creates a rewriter that produces up to n paraphrases per query.
+// Up to cacheSize rewrites are cached for cacheTTL.
+func NewMultiQueryRewriter(chat llm.ChatClient, n, cacheSize int, cacheTTL time.Duration) *MultiQueryRewriter {
+	return &MultiQueryRewriter{
+		chat:  chat,
+		n:     n,
+		cache: cache.NewLRU[string, []Variant](cacheSize, cacheTTL),
+	}
+}
+
+// Rewrite implements the Rewriter interface. Paraphrases that repeat the query or each other
+// are dropped. It returns an error if the reply contains no usable paraphrase.
+func (r *MultiQueryRewriter) Rewrite(ctx context.Context, query string) ([]Variant, error) {
+	if variants, ok := r.cache.Get(query); ok {
+		return variants, nil
+	}
+
+	reply, err := r.chat.Complete(ctx, []llm.Message{
+		{Role: llm.RoleSystem, Content: fmt.Sprintf(multiQuerySystemPrompt, r.n)},
+		{Role: llm.RoleUser, Content: query},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error calling chat model: %w", err)
+	}
+
+	variants := parseQueries(reply, query, r.n)
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("could not parse any queries from model reply %q", reply)
+	}
+	r.cache.Add(query, variants)
+	return variants, nil
+}
+
+// parseQueries reads one query per line from reply, stripping list markers and quotes.
+// Duplicates, including of the original query, are skipped, and at most n are returned.
+func parseQueries(reply, original string, n int) []Variant {
+	seen := map[string]bool{normalize(original): true}
+	var variants []Variant
+	for _, line := range strings.Split(reply, "\n") {
+		text := listMarker.ReplaceAllString(line, "")
+		text = strings.Trim(strings.TrimSpace(text), `"'`)
+		key := normalize(text)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		variants = append(variants, Variant{Text: text})
+		if len(variants) == n {
+			break
+		}
+	}
+	return variants
+}
+
+func normalize(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+// HyDERewriter asks a chat model for a hypothetical document that answers the query
+// (Hypothetical Document Embeddings). The document is embedded and searched in place of the
+// short query, which tends to sit closer to real answers in embedding space.
+// It implements the Rewriter interface.
+type HyDERewriter struct {
+	chat  llm.ChatClient
+	cache *cache.LRU[string, []Variant]
+}
+
+// NewHyDERewriter creates a HyDE rewriter. Up to cacheSize documents are cached for cacheTTL.
+func NewHyDERewriter(chat llm.ChatClient, cacheSize int, cacheTTL time.Duration) *HyDERewriter {
+	return &HyDERewriter{
+		chat:  chat,
+		cache: cache.NewLRU[string, []Variant](cacheSize, cacheTTL),
+	}
+}
+
+// Rewrite implements the Rewriter interface. The hypothetical document is only used for
+// semantic retrieval.
+func (r *HyDERewriter) Rewrite(ctx context.Context, query string) ([]Variant, error) {
+	if variants, ok := r.cache.Get(query); ok {
+		return variants, nil
+	}
+
+	reply, err := r.chat.Complete(ctx, []llm.Message{
+		{Role: llm.RoleSystem, Content: hydeSystemPrompt},
+		{Role: llm.RoleUser, Content: query},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error calling chat model: %w", err)
+	}
+
+	passage := strings.TrimSpace(reply)
+	if passage == "" {
+		return nil, fmt.Errorf("chat model returned an empty document")
+	}
+	passage = llm.TruncateToTokens(passage, hydeMaxTokens)
+
+	variants := []Variant{{Text: passage, SemanticOnly: true}}
+	r.cache.Add(query, variants)
+	return variants, nil
+}
@@ -0,0 +1,68 @@
+package rewrite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	llm_mocks "github.com/chr1sbest/hybrid-search/pkg/llm/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMultiQueryRewriter(t *testing.T) {
+	t.Run("ParsesAndCachesParaphrases", func(t *testing.T) {
+		mockChat := new(llm_mocks.ChatClient)
+		mockChat.On("Complete", mock.Anything, mock.Anything).
+			Return("1. How do I reset my password?\n2) \"Forgot password steps\"\n\n- reset password\n- Change account password", nil).
+			Once()
+
+		rewriter := NewMultiQueryRewriter(mockChat, 3, 10, time.Minute)
+		variants, err := rewriter.Rewrite(context.Background(), "reset password")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Variant{
+			{Text: "How do I reset my password?"},
+			{Text: "Forgot password steps"},
+			{Text: "Change account password"},
+		}, variants)
+
+		cached, err := rewriter.Rewrite(context.Background(), "reset password")
+		assert.NoError(t, err)
+		assert.Equal(t, variants, cached)
+		mockChat.AssertExpectations(t)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		mockChat := new(llm_mocks.ChatClient)
+		mockChat.On("Complete", mock.Anything, mock.Anything).Return("", errors.New("unavailable")).Once()
+		mockChat.On("Complete", mock.Anything, mock.Anything).Return("reset password\n\n", nil).Once()
+
+		rewriter := NewMultiQueryRewriter(mockChat, 3, 10, time.Minute)
+		_, err := rewriter.Rewrite(context.Background(), "reset password")
+		assert.Error(t, err)
+		_, err = rewriter.Rewrite(context.Background(), "reset password")
+		assert.Error(t, err, "A reply that only repeats the query is unusable")
+	})
+}
+
+func TestHyDERewriter(t *testing.T) {
+	mockChat := new(llm_mocks.ChatClient)
+	mockChat.On("Complete", mock.Anything, mock.Anything).
+		Return("  Passwords can be reset from the account settings page.  ", nil).
+		Once()
+
+	rewriter := NewHyDERewriter(mockChat, 10, time.Minute)
+	for i := 0; i < 2; i++ {
+		variants, err := rewriter.Rewrite(context.Background(), "reset password")
+		assert.NoError(t, err)
+		assert.Equal(t, []Variant{{Text: "Passwords can be reset from the account settings page.", SemanticOnly: true}}, variants)
+	}
+	mockChat.AssertExpectations(t)
+
+	emptyChat := new(llm_mocks.ChatClient)
+	emptyChat.On("Complete", mock.Anything, mock.Anything).Return(" ", nil)
+	_, err := NewHyDERewriter(emptyChat, 0, 0).Rewrite(context.Background(), "q")
+	assert.Error(t, err)
+}
@@ -5,9 +5,8 @@ package mocks
 import (
 	context "context"
 
+	search "github.com/chr1sbest/hybrid-search/pkg/search"
 	mock "github.com/stretchr/testify/mock"
-
-	storage "github.com/chr1sbest/hybrid-search/pkg/storage"
 )
 
 // Service is an autogenerated mock type for the Service type
@@ -15,29 +14,29 @@ type Service struct {
 	mock.Mock
 }
 
-// Search provides a mock function with given fields: ctx, query, topK
-func (_m *Service) Search(ctx context.Context, query string, topK int) ([]storage.Document, error) {
-	ret := _m.Called(ctx, query, topK)
+// Search provides a mock function with given fields: ctx, query, opts
+func (_m *Service) Search(ctx context.Context, query string, opts search.Options) (*search.Response, error) {
+	ret := _m.Called(ctx, query, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Search")
 	}
 
-	var r0 []storage.Document
+	var r0 *search.Response
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]storage.Document, error)); ok {
-		return rf(ctx, query, topK)
+	if rf, ok := ret.Get(0).(func(context.Context, string, search.Options) (*search.Response, error)); ok {
+		return rf(ctx, query, opts)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, int) []storage.Document); ok {
-		r0 = rf(ctx, query, topK)
+	if rf, ok := ret.Get(0).(func(context.Context, string, search.Options) *search.Response); ok {
+		r0 = rf(ctx, query, opts)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]storage.Document)
+			r0 = ret.Get(0).(*search.Response)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
-		r1 = rf(ctx, query, topK)
+	if rf, ok := ret.Get(1).(func(context.Context, string, search.Options) error); ok {
+		r1 = rf(ctx, query, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
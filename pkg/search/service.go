@@ -2,17 +2,96 @@ package search
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"time"
 
+	"github.com/chr1sbest/hybrid-search/pkg/chunker"
 	"github.com/chr1sbest/hybrid-search/pkg/embeddings"
+	"github.com/chr1sbest/hybrid-search/pkg/queryparser"
 	"github.com/chr1sbest/hybrid-search/pkg/ranking"
+	"github.com/chr1sbest/hybrid-search/pkg/rerank"
+	"github.com/chr1sbest/hybrid-search/pkg/rewrite"
+	"github.com/chr1sbest/hybrid-search/pkg/snippet"
 	"github.com/chr1sbest/hybrid-search/pkg/storage"
 	"golang.org/x/sync/errgroup"
 )
 
+// embeddingConcurrency bounds the number of concurrent embedding requests made for a single search.
+const embeddingConcurrency = 4
+
+// maxSnippetSentences bounds the number of sentences of a document scored to find its snippet.
+const maxSnippetSentences = 30
+
+// NoReranker can be set as Options.Reranker to skip reranking for a request.
+const NoReranker = "none"
+
+// NoRewriter can be set as Options.Rewriter to skip query rewriting for a request.
+const NoRewriter = "none"
+
+// ErrUnknownReranker is returned when a request selects a reranker that has not been registered.
+var ErrUnknownReranker = errors.New("unknown reranker")
+
+// ErrUnknownRewriter is returned when a request selects a rewriter that has not been registered.
+var ErrUnknownRewriter = errors.New("unknown rewriter")
+
+// ErrNoVectors is returned when a request asks for MMR diversification but neither the vector
+// store nor the embedding client can provide vectors for the results.
+var ErrNoVectors = errors.New("mmr_lambda needs document vectors, and none are available")
+
+// DefaultHighlight holds the highlight settings used for any a request leaves unset.
+var DefaultHighlight = storage.Highlight{PreTag: "<em>", PostTag: "</em>", FragmentSize: 150, NumberOfFragments: 3}
+
+// restrictedOverfetch is how many times topK semantic hits are requested for a query with
+// phrases, field filters or exclusions, since hits that don't satisfy them are dropped.
+const restrictedOverfetch = 3
+
+// maxSuggestions is the number of spelling suggestions looked up for a query.
+const maxSuggestions = 3
+
 // Service defines the interface for search operations.
 type Service interface {
-	Search(ctx context.Context, query string, topK int) ([]storage.Document, error)
+	Search(ctx context.Context, query string, opts Options) (*Response, error)
+}
+
+// Response is the outcome of a search.
+type Response struct {
+	// Results are the ranked results, for CorrectedQuery if it is set.
+	Results []ranking.Result
+	// Suggestions are spelling corrections of the query, best first. They are only looked up
+	// when the query has too few lexical matches.
+	Suggestions []string
+	// CorrectedQuery is set when Options.Autocorrect replaced the results with those of the
+	// top suggestion.
+	CorrectedQuery string
+}
+
+// Options holds the per-request search settings.
+type Options struct {
+	// TopK is the number of results requested from each retriever.
+	TopK int
+	// Fusion overrides the service's default fusion settings. Unset values keep the defaults.
+	Fusion ranking.FusionConfig
+	// MaxPerParent caps how many results may share a parent document. Zero means no cap.
+	MaxPerParent int
+	// MMRLambda enables Maximal Marginal Relevance diversification when set. 1 keeps the
+	// fused order and lower values favour diversity.
+	MMRLambda *float64
+	// Reranker selects a registered reranker by name. Empty uses the default reranker,
+	// if any, and NoReranker disables reranking.
+	Reranker string
+	// Rewriter selects a registered query rewriter by name. Empty uses the default rewriter,
+	// if any, and NoRewriter disables rewriting.
+	Rewriter string
+	// Autocorrect re-runs a search whose query has too few lexical matches with the top
+	// spelling suggestion, and keeps whichever has more.
+	Autocorrect bool
+	// Highlight enables highlighted fragments in the results when set. Unset values keep the
+	// service's defaults.
+	Highlight *storage.Highlight
 }
 
 // SearchService orchestrates hybrid search operations.
@@ -21,49 +100,564 @@ type SearchService struct {
 	embeddingClient embeddings.EmbeddingClient
 	vectorStore     storage.VectorStore
 	textStore       storage.TextStore
+	fusion          ranking.FusionConfig
+	rerankers       map[string]rerankStage
+	defaultReranker string
+	rewriters       map[string]rewriteStage
+	defaultRewriter string
+	// suggestBelow is the lexical result count under which spelling suggestions are looked up.
+	suggestBelow int
+	highlight    storage.Highlight
+}
+
+// rerankStage is a registered reranker along with how it is applied.
+type rerankStage struct {
+	reranker rerank.Reranker
+	// topN is the number of fused results the reranker scores.
+	topN int
+	// timeout bounds the reranker call; on expiry the fused order is kept.
+	timeout time.Duration
+}
+
+// rewriteStage is a registered query rewriter along with how it is applied.
+type rewriteStage struct {
+	rewriter rewrite.Rewriter
+	// timeout bounds the rewriter call; on expiry only the original query is searched.
+	timeout time.Duration
+}
+
+// Option configures optional SearchService behaviour.
+type Option func(*SearchService)
+
+// WithFusionConfig sets the server-wide default fusion settings.
+func WithFusionConfig(cfg ranking.FusionConfig) Option {
+	return func(s *SearchService) {
+		s.fusion = cfg
+	}
+}
+
+// WithReranker registers a reranker under name. It scores the top topN fused results, and
+// falls back to the fused order if it fails or takes longer than timeout.
+func WithReranker(name string, reranker rerank.Reranker, topN int, timeout time.Duration) Option {
+	return func(s *SearchService) {
+		s.rerankers[name] = rerankStage{reranker: reranker, topN: topN, timeout: timeout}
+	}
+}
+
+// WithDefaultReranker selects the registered reranker used when a request does not pick one.
+func WithDefaultReranker(name string) Option {
+	return func(s *SearchService) {
+		s.defaultReranker = name
+	}
+}
+
+// WithRewriter registers a query rewriter under name. If it fails or takes longer than
+// timeout, only the original query is searched.
+func WithRewriter(name string, rewriter rewrite.Rewriter, timeout time.Duration) Option {
+	return func(s *SearchService) {
+		s.rewriters[name] = rewriteStage{rewriter: rewriter, timeout: timeout}
+	}
+}
+
+// WithDefaultRewriter selects the registered rewriter used when a request does not pick one.
+func WithDefaultRewriter(name string) Option {
+	return func(s *SearchService) {
+		s.defaultRewriter = name
+	}
+}
+
+// WithSpellingSuggestions looks up spelling suggestions for searches whose query matches
+// fewer than minResults documents in the text store, which must implement
+// storage.SpellSuggester.
+func WithSpellingSuggestions(minResults int) Option {
+	return func(s *SearchService) {
+		s.suggestBelow = minResults
+	}
+}
+
+// WithHighlightDefaults sets the highlight settings used for any a request leaves unset.
+// Unset values keep DefaultHighlight.
+func WithHighlightDefaults(highlight storage.Highlight) Option {
+	return func(s *SearchService) {
+		s.highlight = mergeHighlight(s.highlight, highlight)
+	}
 }
 
 // NewSearchService creates a new SearchService.
-func NewSearchService(embeddingClient embeddings.EmbeddingClient, vectorStore storage.VectorStore, textStore storage.TextStore) *SearchService {
-	return &SearchService{
+func NewSearchService(embeddingClient embeddings.EmbeddingClient, vectorStore storage.VectorStore, textStore storage.TextStore, opts ...Option) *SearchService {
+	s := &SearchService{
 		embeddingClient: embeddingClient,
 		vectorStore:     vectorStore,
 		textStore:       textStore,
+		fusion:          ranking.DefaultFusionConfig(),
+		highlight:       DefaultHighlight,
+		rerankers:       make(map[string]rerankStage),
+		rewriters:       make(map[string]rewriteStage),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// Search performs a hybrid search across the vector and text stores and re-ranks the results.
-func (s *SearchService) Search(ctx context.Context, query string, topK int) ([]storage.Document, error) {
-	// 1. Create the vector embedding for the query.
-	queryVector, err := s.embeddingClient.CreateEmbedding(ctx, query)
+// Search performs a hybrid search and, if the query has too few lexical matches, looks up
+// spelling suggestions for it. With Options.Autocorrect, the top suggestion is searched too.
+// Matches are counted in the lexical results only, since semantic search returns its topK
+// nearest documents however badly the query is misspelled.
+func (s *SearchService) Search(ctx context.Context, query string, opts Options) (*Response, error) {
+	results, lexicalHits, err := s.search(ctx, query, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create query embedding: %w", err)
+		return nil, err
 	}
 
-	// 2. Concurrently search the vector and text stores.
-	var vectorResults []storage.SearchResult
-	var textResults []storage.SearchResult
+	resp := &Response{Results: results}
+	if lexicalHits >= s.suggestBelow {
+		return resp, nil
+	}
+	resp.Suggestions = s.suggest(ctx, query)
+	if !opts.Autocorrect || len(resp.Suggestions) == 0 {
+		return resp, nil
+	}
 
-	g, ctx := errgroup.WithContext(ctx)
+	corrected, correctedHits, err := s.search(ctx, resp.Suggestions[0], opts)
+	if err != nil {
+		log.Printf("Search for corrected query %q failed, keeping original results: %v", resp.Suggestions[0], err)
+		return resp, nil
+	}
+	if correctedHits > lexicalHits {
+		resp.Results = corrected
+		resp.CorrectedQuery = resp.Suggestions[0]
+	}
+	return resp, nil
+}
 
-	g.Go(func() error {
-		var err error
-		vectorResults, err = s.vectorStore.Query(ctx, query, queryVector, topK)
-		return err
-	})
+// search performs a hybrid search across the vector and text stores and re-ranks the results.
+// Each result carries its fused score and the contribution of every retriever that returned it.
+// When a query rewriter is selected, every variant of the query is searched as well and all
+// result lists are fused with RRF, since scores from different queries are not comparable.
+// With highlighting, lexical results are highlighted by the text store and the rest get
+// query-biased snippets. It also returns the number of lexical results for the original query.
+func (s *SearchService) search(ctx context.Context, query string, opts Options) ([]ranking.Result, int, error) {
+	var highlight *storage.Highlight
+	if opts.Highlight != nil {
+		merged := mergeHighlight(s.highlight, *opts.Highlight)
+		highlight = &merged
+	}
 
-	g.Go(func() error {
-		var err error
-		textResults, err = s.textStore.Search(ctx, query, topK)
-		return err
-	})
+	// Unknown stages are rejected before any store is queried.
+	reranker, rerankOK := s.rerankStage(opts.Reranker)
+	if !rerankOK && opts.Reranker != "" && opts.Reranker != NoReranker {
+		return nil, 0, fmt.Errorf("%w: %q", ErrUnknownReranker, opts.Reranker)
+	}
+
+	// 1. Optionally expand the query into variants.
+	variants := []rewrite.Variant{{Text: query}}
+	if stage, ok := s.rewriteStage(opts.Rewriter); ok {
+		variants = append(variants, s.rewrite(ctx, stage, query)...)
+	} else if opts.Rewriter != "" && opts.Rewriter != NoRewriter {
+		return nil, 0, fmt.Errorf("%w: %q", ErrUnknownRewriter, opts.Rewriter)
+	}
+
+	// 2. Concurrently search the vector and text stores with every variant.
+	lists, err := s.retrieve(ctx, variants, opts.TopK, highlight)
+	if err != nil {
+		return nil, 0, err
+	}
+	// The original query is never semantic-only, so its lexical list follows its semantic one.
+	lexicalHits := len(lists[1].Results)
+
+	// Combine and re-rank the results using the configured fusion strategy.
+	fusion := s.fusion.Merge(opts.Fusion)
+	if len(variants) > 1 {
+		fusion.Strategy = ranking.StrategyRRF
+	}
+	rankedResults, err := ranking.Fuse(fusion, lists...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fuse results: %w", err)
+	}
+
+	// 3. Optionally rerank and diversify the fused results.
+	rankedResults = ranking.LimitPerParent(rankedResults, opts.MaxPerParent)
+	if rerankOK {
+		rankedResults = s.rerank(ctx, reranker, query, rankedResults)
+	}
+	if opts.MMRLambda != nil {
+		vectors, err := s.embedResults(ctx, rankedResults)
+		if err != nil {
+			return nil, 0, err
+		}
+		rankedResults = ranking.MaximalMarginalRelevance(rankedResults, vectors, *opts.MMRLambda)
+	}
+	if highlight != nil {
+		s.addSnippets(ctx, query, rankedResults, *highlight)
+	}
+
+	return rankedResults, lexicalHits, nil
+}
+
+// retrieve searches both stores with each variant. The text store receives the full query
+// syntax, while the vector store is given only the free text, embedded first. Semantic hits
+// are then checked against the phrases, field filters and exclusions of the original query,
+// the first variant, and more are requested to make up for those dropped. It returns a
+// semantic and, unless the variant is semantic-only, a lexical list per variant, in variant
+// order. A variant with no free text, such as one made only of filters, has an empty
+// semantic list.
+func (s *SearchService) retrieve(ctx context.Context, variants []rewrite.Variant, topK int, highlight *storage.Highlight) ([]ranking.ResultList, error) {
+	vectorResults := make([][]storage.SearchResult, len(variants))
+	textResults := make([][]storage.SearchResult, len(variants))
+
+	restrictions := queryparser.Parse(variants[0].Text)
+	semanticK := topK
+	if queryparser.Restricts(restrictions) {
+		semanticK = topK * restrictedOverfetch
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, variant := range variants {
+		freeText := queryparser.FreeText(queryparser.Parse(variant.Text))
+		if freeText != "" {
+			g.Go(func() error {
+				vector, err := s.embeddingClient.CreateEmbedding(gctx, freeText)
+				if err != nil {
+					return fmt.Errorf("failed to create query embedding: %w", err)
+				}
+				results, err := s.vectorStore.Query(gctx, freeText, vector, semanticK)
+				if err != nil {
+					return err
+				}
+				vectorResults[i] = matchingResults(restrictions, results, topK)
+				return nil
+			})
+		}
+
+		if variant.SemanticOnly {
+			continue
+		}
+		g.Go(func() error {
+			var err error
+			if highlighter, ok := s.textStore.(storage.HighlightSearcher); ok && highlight != nil {
+				textResults[i], err = highlighter.SearchWithHighlights(gctx, variant.Text, topK, *highlight)
+			} else {
+				textResults[i], err = s.textStore.Search(gctx, variant.Text, topK)
+			}
+			return err
+		})
+	}
 
 	if err := g.Wait(); err != nil {
 		return nil, err
 	}
 
-	// Combine and re-rank the results using RRF
-	rankedDocs := ranking.ReciprocalRankFusion(vectorResults, textResults)
+	var lists []ranking.ResultList
+	for i, variant := range variants {
+		lists = append(lists, ranking.ResultList{Retriever: ranking.RetrieverSemantic, Results: vectorResults[i]})
+		if !variant.SemanticOnly {
+			lists = append(lists, ranking.ResultList{Retriever: ranking.RetrieverLexical, Results: textResults[i]})
+		}
+	}
+	return lists, nil
+}
+
+// matchingResults returns up to topK of the results that satisfy the query's phrases, field
+// filters and exclusions, in order.
+func matchingResults(node queryparser.Node, results []storage.SearchResult, topK int) []storage.SearchResult {
+	matching := make([]storage.SearchResult, 0, min(len(results), topK))
+	for _, result := range results {
+		if len(matching) == topK {
+			break
+		}
+		if queryparser.Match(node, result.Document.Text, documentField(result.Document)) {
+			matching = append(matching, result)
+		}
+	}
+	return matching
+}
+
+// documentField looks up the value of a query field filter on doc, as the text store would:
+// document_id refers to the parent document, as the text store holds parents, and any other
+// name that isn't a document field to a metadata key.
+func documentField(doc storage.Document) func(name string) (string, bool) {
+	return func(name string) (string, bool) {
+		switch name {
+		case "document_id":
+			if doc.ParentDocumentID != "" {
+				return doc.ParentDocumentID, true
+			}
+			return doc.DocumentID, true
+		case "parent_document_id":
+			return doc.ParentDocumentID, doc.ParentDocumentID != ""
+		}
+		value, ok := doc.Metadata[name]
+		return value, ok
+	}
+}
+
+// addSnippets gives every result the text store did not highlight, such as a semantic hit, a
+// snippet built around its best sentence, with the query's words marked. Sentences are scored
+// by the share of the query's words they contain. Only when no sentence of a result shares a
+// word with the query, as happens for semantic hits, are its sentences compared with the query
+// in embedding space, and only if the embedding client returns vectors. Embedding errors are
+// logged, and affected snippets fall back to the start of the text.
+func (s *SearchService) addSnippets(ctx context.Context, query string, results []ranking.Result, highlight storage.Highlight) {
+	freeText := queryparser.FreeText(queryparser.Parse(query))
+	terms := snippet.Terms(freeText)
 
-	return rankedDocs, nil
+	sentences := make([][]string, len(results))
+	scores := make([][]float64, len(results))
+	var unmatched []int
+	for i, result := range results {
+		if len(result.Highlights) > 0 || result.Document.Text == "" {
+			continue
+		}
+		sentences[i] = snippet.Sentences(result.Document.Text)
+		if len(sentences[i]) > maxSnippetSentences {
+			sentences[i] = sentences[i][:maxSnippetSentences]
+		}
+		scores[i] = make([]float64, len(sentences[i]))
+		for j, sentence := range sentences[i] {
+			scores[i][j] = snippet.Overlap(sentence, terms)
+		}
+		if len(sentences[i]) > 1 && slices.Max(scores[i]) == 0 {
+			unmatched = append(unmatched, i)
+		}
+	}
+	if len(unmatched) > 0 && freeText != "" {
+		s.scoreSentences(ctx, freeText, unmatched, sentences, scores)
+	}
+
+	for i := range results {
+		if sentences[i] == nil {
+			continue
+		}
+		text := snippet.Best(sentences[i], scores[i], highlight.FragmentSize)
+		results[i].Highlights = []string{snippet.Mark(text, terms, highlight.PreTag, highlight.PostTag)}
+	}
+}
+
+// scoreSentences sets the scores of the sentences of the results at indexes to their cosine
+// similarity with the query. It does nothing if the embedding client returns no vector for the
+// query, and logs embedding errors.
+func (s *SearchService) scoreSentences(ctx context.Context, freeText string, indexes []int, sentences [][]string, scores [][]float64) {
+	queryVector, err := s.embeddingClient.CreateEmbedding(ctx, freeText)
+	if err != nil {
+		log.Printf("Failed to embed query for snippets: %v", err)
+		return
+	}
+	if len(queryVector) == 0 {
+		return
+	}
+
+	var g errgroup.Group
+	g.SetLimit(embeddingConcurrency)
+	for _, i := range indexes {
+		for j, sentence := range sentences[i] {
+			g.Go(func() error {
+				vector, err := s.embeddingClient.CreateEmbedding(ctx, sentence)
+				if err != nil {
+					return fmt.Errorf("failed to embed sentence: %w", err)
+				}
+				scores[i][j] = ranking.CosineSimilarity(queryVector, vector)
+				return nil
+			})
+		}
+	}
+	if err := g.Wait(); err != nil {
+		log.Printf("Failed to score snippets: %v", err)
+	}
+}
+
+// mergeHighlight returns base with every setting that is set in override replaced.
+func mergeHighlight(base, override storage.Highlight) storage.Highlight {
+	if override.PreTag != "" {
+		base.PreTag = override.PreTag
+	}
+	if override.PostTag != "" {
+		base.PostTag = override.PostTag
+	}
+	if override.FragmentSize > 0 {
+		base.FragmentSize = override.FragmentSize
+	}
+	if override.NumberOfFragments > 0 {
+		base.NumberOfFragments = override.NumberOfFragments
+	}
+	return base
+}
+
+// suggest returns spelling suggestions for query from the text store. Suggestions are only
+// made for plain free-text queries, since corrections would not preserve query syntax.
+// Lookup errors are logged and yield no suggestions.
+func (s *SearchService) suggest(ctx context.Context, query string) []string {
+	suggester, ok := s.textStore.(storage.SpellSuggester)
+	if !ok {
+		return nil
+	}
+	if _, plain := queryparser.Parse(query).(queryparser.Term); !plain {
+		return nil
+	}
+
+	suggestions, err := suggester.SuggestSpelling(ctx, query, maxSuggestions)
+	if err != nil {
+		log.Printf("Spelling suggestion failed: %v", err)
+		return nil
+	}
+	return suggestions
+}
+
+// rewriteStage returns the rewriter selected by name, falling back to the default one.
+func (s *SearchService) rewriteStage(name string) (rewriteStage, bool) {
+	switch name {
+	case NoRewriter:
+		return rewriteStage{}, false
+	case "":
+		name = s.defaultRewriter
+	}
+	stage, ok := s.rewriters[name]
+	return stage, ok
+}
+
+// rewrite returns the rewriter's variants of query. If the rewriter fails or times out, it
+// returns none so that only the original query is searched.
+func (s *SearchService) rewrite(ctx context.Context, stage rewriteStage, query string) []rewrite.Variant {
+	ctx, cancel := context.WithTimeout(ctx, stage.timeout)
+	defer cancel()
+
+	variants, err := stage.rewriter.Rewrite(ctx, query)
+	if err != nil {
+		log.Printf("Query rewriting failed, searching the original query only: %v", err)
+		return nil
+	}
+	return variants
+}
+
+// rerankStage returns the reranker selected by name, falling back to the default one.
+func (s *SearchService) rerankStage(name string) (rerankStage, bool) {
+	switch name {
+	case NoReranker:
+		return rerankStage{}, false
+	case "":
+		name = s.defaultReranker
+	}
+	stage, ok := s.rerankers[name]
+	return stage, ok
+}
+
+// rerank re-orders the top results by reranker score. The remaining results keep their
+// fused order below them. If the reranker fails or times out, or the request is cancelled,
+// results are returned unchanged.
+func (s *SearchService) rerank(ctx context.Context, stage rerankStage, query string, results []ranking.Result) []ranking.Result {
+	n := min(stage.topN, len(results))
+	if n == 0 {
+		return results
+	}
+
+	docs := make([]storage.Document, n)
+	for i := range docs {
+		docs[i] = results[i].Document
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, stage.timeout)
+	defer cancel()
+
+	type outcome struct {
+		scores []float64
+		err    error
+	}
+	// Run the reranker in the background so a call that ignores the context still cannot
+	// hold the request past the timeout.
+	done := make(chan outcome, 1)
+	go func() {
+		scores, err := stage.reranker.Rerank(ctx, query, docs)
+		done <- outcome{scores: scores, err: err}
+	}()
+
+	var scores []float64
+	select {
+	case out := <-done:
+		if out.err != nil {
+			log.Printf("Reranking failed, keeping fused order: %v", out.err)
+			return results
+		}
+		scores = out.scores
+	case <-ctx.Done():
+		// The request's own cancellation or deadline is not the reranker's timeout.
+		if err := parent.Err(); err != nil {
+			log.Printf("Request ended during reranking, keeping fused order: %v", err)
+		} else {
+			log.Printf("Reranking timed out after %s, keeping fused order", stage.timeout)
+		}
+		return results
+	}
+	if len(scores) != n {
+		log.Printf("Reranker returned %d scores for %d documents, keeping fused order", len(scores), n)
+		return results
+	}
+
+	reranked := make([]ranking.Result, len(results))
+	copy(reranked, results)
+	for i := range scores {
+		reranked[i].RerankScore = &scores[i]
+	}
+	// A stable sort keeps the fused order for documents the reranker scores equally.
+	head := reranked[:n]
+	sort.SliceStable(head, func(i, j int) bool {
+		return *head[i].RerankScore > *head[j].RerankScore
+	})
+	return reranked
+}
+
+// embedResults returns a vector for every result, in order. Vectors the vector store holds are
+// fetched when it implements storage.VectorFetcher; a parent document, which the vector store
+// doesn't hold, is represented by its first chunk. The rest are embedded, and a result with
+// neither keeps a nil vector, which MMR treats as dissimilar. If no result has a vector,
+// diversification can't work, and ErrNoVectors is returned.
+func (s *SearchService) embedResults(ctx context.Context, results []ranking.Result) ([][]float32, error) {
+	vectors := make([][]float32, len(results))
+
+	if fetcher, ok := s.vectorStore.(storage.VectorFetcher); ok {
+		ids := make([]string, len(results))
+		for i, result := range results {
+			ids[i] = vectorID(result.Document)
+		}
+		fetched, err := fetcher.FetchVectors(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch result vectors: %w", err)
+		}
+		for i, id := range ids {
+			vectors[i] = fetched[id]
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(embeddingConcurrency)
+	for i, result := range results {
+		if vectors[i] != nil {
+			continue
+		}
+		g.Go(func() error {
+			vector, err := s.embeddingClient.CreateEmbedding(gctx, result.Document.Text)
+			if err != nil {
+				return fmt.Errorf("failed to create embedding for document %s: %w", result.Document.DocumentID, err)
+			}
+			vectors[i] = vector
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	if len(results) > 0 && !slices.ContainsFunc(vectors, func(v []float32) bool { return v != nil }) {
+		return nil, ErrNoVectors
+	}
+	return vectors, nil
+}
+
+// vectorID returns the ID under which the vector store holds a vector for doc. Chunks are
+// stored under their own ID, and a parent document is represented by its first chunk.
+func vectorID(doc storage.Document) string {
+	if doc.ParentDocumentID != "" {
+		return doc.DocumentID
+	}
+	return chunker.ChunkID(doc.DocumentID, 0)
 }
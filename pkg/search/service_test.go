@@ -2,8 +2,14 @@ package search
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/chr1sbest/hybrid-search/pkg/ranking"
+	"github.com/chr1sbest/hybrid-search/pkg/rerank"
+	"github.com/chr1sbest/hybrid-search/pkg/rewrite"
 	"github.com/chr1sbest/hybrid-search/pkg/storage"
 	embedding_mocks "github.com/chr1sbest/hybrid-search/pkg/embeddings/mocks"
 	storage_mocks "github.com/chr1sbest/hybrid-search/pkg/storage/mocks"
@@ -43,22 +49,565 @@ func TestSearchService_Search(t *testing.T) {
 	mockTextStore.On("Search", mock.Anything, query, topK).Return(textResults, nil)
 
 	// Execute the method we're testing
-	results, err := service.Search(ctx, query, topK)
+	resp, err := service.Search(ctx, query, Options{TopK: topK})
 
 	// 3. Assert: Check that the results are what we expect
 	assert.NoError(t, err)
-	assert.NotNil(t, results)
+	assert.NotNil(t, resp.Results)
 
 	// Based on Reciprocal Rank Fusion, the shared document should be ranked highest.
 	// RRF(doc-shared-1) = 1/(60+2) + 1/(60+1) = ~0.032
 	// RRF(doc-vec-1) = 1/(60+1) = ~0.016
-	// RRF(doc-text-1) = 1/(60+2) = ~0.016
-	// Therefore, the expected order is doc-shared-1, doc-vec-1, doc-text-1 (or doc-text-1, doc-vec-1)
-	assert.Equal(t, 3, len(results), "Should combine results from both stores")
-	assert.Equal(t, "doc-shared-1", results[0].DocumentID, "The highest-ranked document should be first")
+	// RRF(doc-text-1) = 1/(60+2) = ~0.016, slightly lower than doc-vec-1
+	// Therefore, the expected order is doc-shared-1, doc-vec-1, doc-text-1
+	assert.Equal(t, 3, len(resp.Results), "Should combine results from both stores")
+	assert.Equal(t, "doc-shared-1", resp.Results[0].Document.DocumentID, "The highest-ranked document should be first")
+	assert.Equal(t, "doc-vec-1", resp.Results[1].Document.DocumentID)
+	assert.Equal(t, "doc-text-1", resp.Results[2].Document.DocumentID)
 
 	// Verify that all the expected mock calls were made
 	mockEmbeddingClient.AssertExpectations(t)
 	mockVectorStore.AssertExpectations(t)
 	mockTextStore.AssertExpectations(t)
 }
+
+func TestSearchService_Search_FusionWeights(t *testing.T) {
+	mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+	mockVectorStore := new(storage_mocks.VectorStore)
+	mockTextStore := new(storage_mocks.TextStore)
+
+	// The server default favours the semantic retriever.
+	service := NewSearchService(mockEmbeddingClient, mockVectorStore, mockTextStore, WithFusionConfig(ranking.FusionConfig{
+		K:       60,
+		Weights: map[string]float64{ranking.RetrieverSemantic: 2},
+	}))
+
+	vectorResults := []storage.SearchResult{{Document: storage.Document{DocumentID: "doc-vec-1"}, Score: 0.9}}
+	textResults := []storage.SearchResult{{Document: storage.Document{DocumentID: "doc-text-1"}, Score: 0.9}}
+
+	mockEmbeddingClient.On("CreateEmbedding", mock.Anything, "q").Return(nil, nil)
+	mockVectorStore.On("Query", mock.Anything, "q", mock.Anything, 2).Return(vectorResults, nil)
+	mockTextStore.On("Search", mock.Anything, "q", 2).Return(textResults, nil)
+
+	t.Run("UsesServerDefaults", func(t *testing.T) {
+		resp, err := service.Search(context.Background(), "q", Options{TopK: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, "doc-vec-1", resp.Results[0].Document.DocumentID)
+	})
+
+	t.Run("RequestOverridesDefaults", func(t *testing.T) {
+		resp, err := service.Search(context.Background(), "q", Options{
+			TopK:   2,
+			Fusion: ranking.FusionConfig{Weights: map[string]float64{ranking.RetrieverLexical: 3}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "doc-text-1", resp.Results[0].Document.DocumentID)
+	})
+}
+
+func TestSearchService_Search_Diversification(t *testing.T) {
+	mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+	mockVectorStore := new(storage_mocks.VectorStore)
+	mockTextStore := new(storage_mocks.TextStore)
+
+	service := NewSearchService(mockEmbeddingClient, mockVectorStore, mockTextStore)
+
+	// Two near-identical chunks of the same parent outrank a chunk from another document.
+	vectorResults := []storage.SearchResult{
+		{Document: storage.Document{DocumentID: "p1-c1", ParentDocumentID: "p1", Text: "billing cycle one"}, Score: 0.9},
+		{Document: storage.Document{DocumentID: "p1-c2", ParentDocumentID: "p1", Text: "billing cycle two"}, Score: 0.8},
+		{Document: storage.Document{DocumentID: "p2-c1", ParentDocumentID: "p2", Text: "refund policy"}, Score: 0.7},
+	}
+
+	mockEmbeddingClient.On("CreateEmbedding", mock.Anything, "billing").Return([]float32{1, 0}, nil)
+	mockEmbeddingClient.On("CreateEmbedding", mock.Anything, "billing cycle one").Return([]float32{1, 0}, nil)
+	mockEmbeddingClient.On("CreateEmbedding", mock.Anything, "billing cycle two").Return([]float32{0.99, 0.01}, nil)
+	mockEmbeddingClient.On("CreateEmbedding", mock.Anything, "refund policy").Return([]float32{0, 1}, nil)
+	mockVectorStore.On("Query", mock.Anything, "billing", mock.Anything, 3).Return(vectorResults, nil)
+	mockTextStore.On("Search", mock.Anything, "billing", 3).Return([]storage.SearchResult{}, nil)
+
+	documentIDs := func(results []ranking.Result) []string {
+		var ids []string
+		for _, result := range results {
+			ids = append(ids, result.Document.DocumentID)
+		}
+		return ids
+	}
+
+	t.Run("MaxPerParent", func(t *testing.T) {
+		resp, err := service.Search(context.Background(), "billing", Options{TopK: 3, MaxPerParent: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"p1-c1", "p2-c1"}, documentIDs(resp.Results))
+	})
+
+	t.Run("MMR", func(t *testing.T) {
+		lambda := 0.5
+		resp, err := service.Search(context.Background(), "billing", Options{TopK: 3, MMRLambda: &lambda})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"p1-c1", "p2-c1", "p1-c2"}, documentIDs(resp.Results))
+	})
+
+	mockEmbeddingClient.AssertExpectations(t)
+}
+
+// fetchingVectorStore is a vector store that can return its stored vectors.
+type fetchingVectorStore struct {
+	*storage_mocks.VectorStore
+	*storage_mocks.VectorFetcher
+}
+
+func TestSearchService_Search_DiversificationVectors(t *testing.T) {
+	lambda := 0.5
+	vectorResults := []storage.SearchResult{
+		{Document: storage.Document{DocumentID: "p1#0", ParentDocumentID: "p1", Text: "billing cycle one"}, Score: 0.9},
+		{Document: storage.Document{DocumentID: "p1#1", ParentDocumentID: "p1", Text: "billing cycle two"}, Score: 0.8},
+		{Document: storage.Document{DocumentID: "p1#2", ParentDocumentID: "p1", Text: "billing cycle three"}, Score: 0.7},
+	}
+	textResults := []storage.SearchResult{
+		{Document: storage.Document{DocumentID: "p2", Text: "refund policy"}, Score: 2.1},
+	}
+	// A low lexical weight ranks the other document last in the fused order.
+	fusion := ranking.FusionConfig{Weights: map[string]float64{ranking.RetrieverLexical: 0.5}}
+
+	t.Run("FetchesStoredVectors", func(t *testing.T) {
+		// 1. Arrange: the embedding client defers to the vector store, which holds the vectors.
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.Anything).Return(nil, nil)
+		vectorStore := fetchingVectorStore{new(storage_mocks.VectorStore), new(storage_mocks.VectorFetcher)}
+		vectorStore.VectorStore.On("Query", mock.Anything, "billing", mock.Anything, 3).Return(vectorResults, nil)
+		// The lexical hit is a parent document, represented by its first chunk.
+		vectorStore.VectorFetcher.On("FetchVectors", mock.Anything, []string{"p1#0", "p1#1", "p1#2", "p2#0"}).
+			Return(map[string][]float32{"p1#0": {1, 0}, "p1#1": {0.99, 0.01}, "p1#2": {0.98, 0.02}, "p2#0": {0, 1}}, nil)
+		mockTextStore := new(storage_mocks.TextStore)
+		mockTextStore.On("Search", mock.Anything, "billing", 3).Return(textResults, nil)
+		service := NewSearchService(mockEmbeddingClient, vectorStore, mockTextStore)
+
+		// 2. Act
+		resp, err := service.Search(context.Background(), "billing", Options{TopK: 3, Fusion: fusion, MMRLambda: &lambda})
+
+		// 3. Assert: the near-duplicate chunk drops below the other document.
+		assert.NoError(t, err)
+		var ids []string
+		for _, result := range resp.Results {
+			ids = append(ids, result.Document.DocumentID)
+		}
+		assert.Equal(t, []string{"p1#0", "p2", "p1#1", "p1#2"}, ids)
+		vectorStore.VectorFetcher.AssertExpectations(t)
+	})
+
+	t.Run("NoVectors", func(t *testing.T) {
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.Anything).Return(nil, nil)
+		mockVectorStore := new(storage_mocks.VectorStore)
+		mockVectorStore.On("Query", mock.Anything, "billing", mock.Anything, 3).Return(vectorResults, nil)
+		mockTextStore := new(storage_mocks.TextStore)
+		mockTextStore.On("Search", mock.Anything, "billing", 3).Return(textResults, nil)
+		service := NewSearchService(mockEmbeddingClient, mockVectorStore, mockTextStore)
+
+		_, err := service.Search(context.Background(), "billing", Options{TopK: 3, MMRLambda: &lambda})
+
+		assert.ErrorIs(t, err, ErrNoVectors)
+	})
+}
+
+// blockingReranker ignores its context and never returns until released. onCall, if set, is
+// called when reranking starts.
+type blockingReranker struct {
+	release chan struct{}
+	onCall  func()
+}
+
+func (r *blockingReranker) Rerank(ctx context.Context, query string, docs []storage.Document) ([]float64, error) {
+	if r.onCall != nil {
+		r.onCall()
+	}
+	<-r.release
+	return nil, nil
+}
+
+func TestSearchService_Search_Reranking(t *testing.T) {
+	mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+	mockVectorStore := new(storage_mocks.VectorStore)
+	mockTextStore := new(storage_mocks.TextStore)
+
+	// The fused order is doc-1, doc-2, doc-3, but only doc-2 mentions invoices.
+	vectorResults := []storage.SearchResult{
+		{Document: storage.Document{DocumentID: "doc-1", Text: "reset a password"}, Score: 0.9},
+		{Document: storage.Document{DocumentID: "doc-2", Text: "download invoices"}, Score: 0.8},
+		{Document: storage.Document{DocumentID: "doc-3", Text: "invoices by email"}, Score: 0.7},
+	}
+	mockEmbeddingClient.On("CreateEmbedding", mock.Anything, "invoices").Return(nil, nil)
+	mockVectorStore.On("Query", mock.Anything, "invoices", mock.Anything, 3).Return(vectorResults, nil)
+	mockTextStore.On("Search", mock.Anything, "invoices", 3).Return([]storage.SearchResult{}, nil)
+
+	release := make(chan struct{})
+	defer close(release)
+	service := NewSearchService(mockEmbeddingClient, mockVectorStore, mockTextStore,
+		WithReranker("lexical", rerank.NewLexicalReranker(), 2, time.Second),
+		WithReranker("slow", &blockingReranker{release: release}, 2, 10*time.Millisecond),
+		WithDefaultReranker("lexical"),
+	)
+
+	documentIDs := func(results []ranking.Result) []string {
+		var ids []string
+		for _, result := range results {
+			ids = append(ids, result.Document.DocumentID)
+		}
+		return ids
+	}
+
+	t.Run("ReranksTopNWithDefaultReranker", func(t *testing.T) {
+		resp, err := service.Search(context.Background(), "invoices", Options{TopK: 3})
+		assert.NoError(t, err)
+		// Only the top 2 are reranked, so doc-3 stays last despite matching.
+		assert.Equal(t, []string{"doc-2", "doc-1", "doc-3"}, documentIDs(resp.Results))
+		assert.Equal(t, 1.0, *resp.Results[0].RerankScore)
+		assert.Equal(t, 0.0, *resp.Results[1].RerankScore)
+		assert.Nil(t, resp.Results[2].RerankScore)
+	})
+
+	t.Run("NoneSkipsReranking", func(t *testing.T) {
+		resp, err := service.Search(context.Background(), "invoices", Options{TopK: 3, Reranker: NoReranker})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"doc-1", "doc-2", "doc-3"}, documentIDs(resp.Results))
+	})
+
+	t.Run("TimeoutKeepsFusedOrder", func(t *testing.T) {
+		start := time.Now()
+		resp, err := service.Search(context.Background(), "invoices", Options{TopK: 3, Reranker: "slow"})
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, []string{"doc-1", "doc-2", "doc-3"}, documentIDs(resp.Results))
+		assert.Nil(t, resp.Results[0].RerankScore)
+	})
+
+	t.Run("CancellationKeepsFusedOrder", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		service := NewSearchService(mockEmbeddingClient, mockVectorStore, mockTextStore,
+			WithReranker("slow", &blockingReranker{release: release, onCall: cancel}, 2, time.Minute),
+		)
+
+		start := time.Now()
+		resp, err := service.Search(ctx, "invoices", Options{TopK: 3, Reranker: "slow"})
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, []string{"doc-1", "doc-2", "doc-3"}, documentIDs(resp.Results))
+	})
+
+	t.Run("UnknownReranker", func(t *testing.T) {
+		calls := len(mockVectorStore.Calls)
+		_, err := service.Search(context.Background(), "invoices", Options{TopK: 3, Reranker: "missing"})
+		assert.ErrorIs(t, err, ErrUnknownReranker)
+		// The request is rejected before any store is queried.
+		assert.Len(t, mockVectorStore.Calls, calls)
+	})
+}
+
+// staticRewriter returns fixed variants, or an error if one is set.
+type staticRewriter struct {
+	variants []rewrite.Variant
+	err      error
+}
+
+func (r *staticRewriter) Rewrite(ctx context.Context, query string) ([]rewrite.Variant, error) {
+	return r.variants, r.err
+}
+
+func TestSearchService_Search_Rewriting(t *testing.T) {
+	mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+	mockVectorStore := new(storage_mocks.VectorStore)
+	mockTextStore := new(storage_mocks.TextStore)
+
+	// Each query text finds a different document.
+	results := func(id string) []storage.SearchResult {
+		return []storage.SearchResult{{Document: storage.Document{DocumentID: id}, Score: 0.5}}
+	}
+	mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.Anything).Return(nil, nil)
+	mockVectorStore.On("Query", mock.Anything, "pw", mock.Anything, 3).Return(results("doc-1"), nil)
+	mockTextStore.On("Search", mock.Anything, "pw", 3).Return(results("doc-1"), nil)
+	mockVectorStore.On("Query", mock.Anything, "password reset", mock.Anything, 3).Return(results("doc-2"), nil)
+	mockTextStore.On("Search", mock.Anything, "password reset", 3).Return(results("doc-2"), nil)
+	mockVectorStore.On("Query", mock.Anything, "Passwords are reset in settings.", mock.Anything, 3).Return(results("doc-3"), nil)
+
+	// The server default is a score-based strategy, which rewriting must replace with RRF.
+	service := NewSearchService(mockEmbeddingClient, mockVectorStore, mockTextStore,
+		WithFusionConfig(ranking.FusionConfig{Strategy: ranking.StrategyMinMax}),
+		WithRewriter("multi_query", &staticRewriter{variants: []rewrite.Variant{{Text: "password reset"}}}, time.Second),
+		WithRewriter("hyde", &staticRewriter{variants: []rewrite.Variant{{Text: "Passwords are reset in settings.", SemanticOnly: true}}}, time.Second),
+		WithRewriter("broken", &staticRewriter{err: errors.New("model down")}, time.Second),
+		WithDefaultRewriter("multi_query"),
+	)
+
+	documentIDs := func(results []ranking.Result) []string {
+		var ids []string
+		for _, result := range results {
+			ids = append(ids, result.Document.DocumentID)
+		}
+		return ids
+	}
+
+	t.Run("FusesVariantsWithRRF", func(t *testing.T) {
+		resp, err := service.Search(context.Background(), "pw", Options{TopK: 3})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"doc-1", "doc-2"}, documentIDs(resp.Results))
+		assert.Len(t, resp.Results[0].Explanation, 2)
+		assert.InDelta(t, 1/(ranking.DefaultK+1), resp.Results[0].Explanation[0].Contribution, 1e-9)
+	})
+
+	t.Run("HyDEIsSemanticOnly", func(t *testing.T) {
+		resp, err := service.Search(context.Background(), "pw", Options{TopK: 3, Rewriter: "hyde"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"doc-1", "doc-3"}, documentIDs(resp.Results))
+		assert.Equal(t, ranking.RetrieverSemantic, resp.Results[1].Explanation[0].Retriever)
+		mockTextStore.AssertNotCalled(t, "Search", mock.Anything, "Passwords are reset in settings.", mock.Anything)
+	})
+
+	t.Run("NoneSearchesOriginalOnly", func(t *testing.T) {
+		resp, err := service.Search(context.Background(), "pw", Options{TopK: 3, Rewriter: NoRewriter})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"doc-1"}, documentIDs(resp.Results))
+	})
+
+	t.Run("FailureSearchesOriginalOnly", func(t *testing.T) {
+		resp, err := service.Search(context.Background(), "pw", Options{TopK: 3, Rewriter: "broken"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"doc-1"}, documentIDs(resp.Results))
+	})
+
+	t.Run("UnknownRewriter", func(t *testing.T) {
+		_, err := service.Search(context.Background(), "pw", Options{TopK: 3, Rewriter: "missing"})
+		assert.ErrorIs(t, err, ErrUnknownRewriter)
+	})
+}
+
+func TestSearchService_Search_QuerySyntax(t *testing.T) {
+	t.Run("VectorStoreGetsFreeTextOnly", func(t *testing.T) {
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockVectorStore := new(storage_mocks.VectorStore)
+		mockTextStore := new(storage_mocks.TextStore)
+		query := `"exact phrase" -exclude tag:billing`
+
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, "exact phrase").Return(nil, nil)
+		mockVectorStore.On("Query", mock.Anything, "exact phrase", mock.Anything, 15).Return([]storage.SearchResult{}, nil)
+		mockTextStore.On("Search", mock.Anything, query, 5).Return([]storage.SearchResult{}, nil)
+
+		service := NewSearchService(mockEmbeddingClient, mockVectorStore, mockTextStore)
+		_, err := service.Search(context.Background(), query, Options{TopK: 5})
+
+		assert.NoError(t, err)
+		mockVectorStore.AssertExpectations(t)
+		mockTextStore.AssertExpectations(t)
+	})
+
+	t.Run("SemanticHitsAreFiltered", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockVectorStore := new(storage_mocks.VectorStore)
+		mockTextStore := new(storage_mocks.TextStore)
+		query := `invoices -draft tag:billing`
+		billing := map[string]string{"tag": "billing"}
+
+		vectorResults := []storage.SearchResult{
+			{Document: storage.Document{DocumentID: "a#0", ParentDocumentID: "a", Text: "Invoices are emailed.", Metadata: billing}, Score: 0.9},
+			{Document: storage.Document{DocumentID: "b#0", ParentDocumentID: "b", Text: "Invoices are emailed.", Metadata: map[string]string{"tag": "sales"}}, Score: 0.8},
+			{Document: storage.Document{DocumentID: "c#0", ParentDocumentID: "c", Text: "A draft of the invoices page.", Metadata: billing}, Score: 0.7},
+			{Document: storage.Document{DocumentID: "d#0", ParentDocumentID: "d", Text: "Invoices are emailed."}, Score: 0.6},
+			{Document: storage.Document{DocumentID: "e#0", ParentDocumentID: "e", Text: "Invoice history.", Metadata: billing}, Score: 0.5},
+			{Document: storage.Document{DocumentID: "f#0", ParentDocumentID: "f", Text: "Invoice totals.", Metadata: billing}, Score: 0.4},
+		}
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, "invoices").Return(nil, nil)
+		mockVectorStore.On("Query", mock.Anything, "invoices", mock.Anything, 6).Return(vectorResults, nil)
+		mockTextStore.On("Search", mock.Anything, query, 2).Return([]storage.SearchResult{}, nil)
+		service := NewSearchService(mockEmbeddingClient, mockVectorStore, mockTextStore)
+
+		// 2. Act
+		resp, err := service.Search(context.Background(), query, Options{TopK: 2})
+
+		// 3. Assert: only the first topK hits that satisfy the filter and exclusion are kept.
+		assert.NoError(t, err)
+		var ids []string
+		for _, result := range resp.Results {
+			ids = append(ids, result.Document.DocumentID)
+		}
+		assert.Equal(t, []string{"a#0", "e#0"}, ids)
+	})
+
+	t.Run("FilterOnlyQuerySkipsVectorStore", func(t *testing.T) {
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockVectorStore := new(storage_mocks.VectorStore)
+		mockTextStore := new(storage_mocks.TextStore)
+
+		textResults := []storage.SearchResult{{Document: storage.Document{DocumentID: "doc-1"}, Score: 1}}
+		mockTextStore.On("Search", mock.Anything, "tag:billing", 5).Return(textResults, nil)
+
+		service := NewSearchService(mockEmbeddingClient, mockVectorStore, mockTextStore)
+		resp, err := service.Search(context.Background(), "tag:billing", Options{TopK: 5})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Results, 1)
+		mockEmbeddingClient.AssertNotCalled(t, "CreateEmbedding", mock.Anything, mock.Anything)
+		mockVectorStore.AssertNotCalled(t, "Query", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// spellCheckingTextStore is a text store that can also suggest spelling corrections.
+type spellCheckingTextStore struct {
+	*storage_mocks.TextStore
+	*storage_mocks.SpellSuggester
+}
+
+func TestSearchService_Search_Suggestions(t *testing.T) {
+	newService := func() (*SearchService, *storage_mocks.TextStore, *storage_mocks.SpellSuggester) {
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockVectorStore := new(storage_mocks.VectorStore)
+		mockTextStore := new(storage_mocks.TextStore)
+		mockSuggester := new(storage_mocks.SpellSuggester)
+
+		// Semantic search returns its topK nearest chunks even for a misspelled query.
+		var vectorResults []storage.SearchResult
+		for i := range 5 {
+			vectorResults = append(vectorResults, storage.SearchResult{Document: storage.Document{DocumentID: fmt.Sprintf("chunk-%d", i)}, Score: 0.5})
+		}
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.Anything).Return(nil, nil)
+		mockVectorStore.On("Query", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(vectorResults, nil)
+		mockTextStore.On("Search", mock.Anything, "pasword", 5).Return([]storage.SearchResult{}, nil)
+		mockTextStore.On("Search", mock.Anything, "password", 5).Return([]storage.SearchResult{
+			{Document: storage.Document{DocumentID: "doc-1"}, Score: 1},
+		}, nil)
+
+		textStore := spellCheckingTextStore{TextStore: mockTextStore, SpellSuggester: mockSuggester}
+		service := NewSearchService(mockEmbeddingClient, mockVectorStore, textStore, WithSpellingSuggestions(1))
+		return service, mockTextStore, mockSuggester
+	}
+
+	t.Run("SuggestsWhenTooFewResults", func(t *testing.T) {
+		service, mockTextStore, mockSuggester := newService()
+		mockSuggester.On("SuggestSpelling", mock.Anything, "pasword", maxSuggestions).Return([]string{"password", "passport"}, nil)
+
+		resp, err := service.Search(context.Background(), "pasword", Options{TopK: 5})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Results, 5)
+		assert.Equal(t, []string{"password", "passport"}, resp.Suggestions)
+		assert.Empty(t, resp.CorrectedQuery)
+		mockTextStore.AssertNotCalled(t, "Search", mock.Anything, "password", mock.Anything)
+	})
+
+	t.Run("AutocorrectUsesTopSuggestion", func(t *testing.T) {
+		service, _, mockSuggester := newService()
+		mockSuggester.On("SuggestSpelling", mock.Anything, "pasword", maxSuggestions).Return([]string{"password"}, nil)
+
+		resp, err := service.Search(context.Background(), "pasword", Options{TopK: 5, Autocorrect: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "password", resp.CorrectedQuery)
+		assert.Len(t, resp.Results, 6)
+	})
+
+	t.Run("NoSuggestionsWhenEnoughResults", func(t *testing.T) {
+		service, _, mockSuggester := newService()
+
+		resp, err := service.Search(context.Background(), "password", Options{TopK: 5, Autocorrect: true})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Results, 6)
+		assert.Empty(t, resp.Suggestions)
+		mockSuggester.AssertNotCalled(t, "SuggestSpelling", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("SuggesterErrorIsIgnored", func(t *testing.T) {
+		service, _, mockSuggester := newService()
+		mockSuggester.On("SuggestSpelling", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("suggest failed"))
+
+		resp, err := service.Search(context.Background(), "pasword", Options{TopK: 5, Autocorrect: true})
+
+		assert.NoError(t, err)
+		assert.Empty(t, resp.Suggestions)
+	})
+
+	t.Run("QuerySyntaxIsNotCorrected", func(t *testing.T) {
+		service, mockTextStore, mockSuggester := newService()
+		mockTextStore.On("Search", mock.Anything, `"pasword reset"`, 5).Return([]storage.SearchResult{}, nil)
+
+		resp, err := service.Search(context.Background(), `"pasword reset"`, Options{TopK: 5})
+
+		assert.NoError(t, err)
+		assert.Empty(t, resp.Suggestions)
+		mockSuggester.AssertNotCalled(t, "SuggestSpelling", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// highlightingTextStore is a text store that can also highlight its results.
+type highlightingTextStore struct {
+	*storage_mocks.TextStore
+	*storage_mocks.HighlightSearcher
+}
+
+func TestSearchService_Search_Highlighting(t *testing.T) {
+	semanticDoc := storage.Document{DocumentID: "doc-1", Text: "Passwords are reset in settings. Invoices are emailed monthly."}
+	lexicalDoc := storage.Document{DocumentID: "doc-2", Text: "Pay invoices by card."}
+
+	t.Run("HighlightsEveryResult", func(t *testing.T) {
+		// 1. Arrange
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockVectorStore := new(storage_mocks.VectorStore)
+		mockHighlighter := new(storage_mocks.HighlightSearcher)
+		textStore := highlightingTextStore{TextStore: new(storage_mocks.TextStore), HighlightSearcher: mockHighlighter}
+
+		// No sentence of doc-3 shares a word with the query, so its sentences are embedded.
+		paraphraseDoc := storage.Document{DocumentID: "doc-3", Text: "Passwords are reset in settings. Bills arrive by mail each month."}
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, "invoices emailed").Return([]float32{1, 0}, nil)
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, "Passwords are reset in settings.").Return([]float32{0, 1}, nil)
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, "Bills arrive by mail each month.").Return([]float32{1, 0}, nil)
+		mockVectorStore.On("Query", mock.Anything, "invoices emailed", mock.Anything, 5).Return([]storage.SearchResult{
+			{Document: semanticDoc, Score: 0.9},
+			{Document: paraphraseDoc, Score: 0.8},
+		}, nil)
+
+		// The request's tags override the service defaults; the fragment size comes from them.
+		expected := storage.Highlight{PreTag: "<b>", PostTag: "</b>", FragmentSize: 40, NumberOfFragments: 3}
+		mockHighlighter.On("SearchWithHighlights", mock.Anything, "invoices emailed", 5, expected).Return([]storage.SearchResult{
+			{Document: lexicalDoc, Score: 2, Highlights: []string{"Pay <b>invoices</b> by card."}},
+		}, nil)
+
+		service := NewSearchService(mockEmbeddingClient, mockVectorStore, textStore, WithHighlightDefaults(storage.Highlight{FragmentSize: 40}))
+
+		// 2. Act
+		resp, err := service.Search(context.Background(), "invoices emailed", Options{
+			TopK:      5,
+			Highlight: &storage.Highlight{PreTag: "<b>", PostTag: "</b>"},
+		})
+
+		// 3. Assert
+		assert.NoError(t, err)
+		highlights := make(map[string][]string)
+		for _, result := range resp.Results {
+			highlights[result.Document.DocumentID] = result.Highlights
+		}
+		assert.Equal(t, []string{"<b>Invoices</b> are <b>emailed</b> monthly."}, highlights["doc-1"])
+		assert.Equal(t, []string{"Pay <b>invoices</b> by card."}, highlights["doc-2"])
+		assert.Equal(t, []string{"Bills arrive by mail each month."}, highlights["doc-3"])
+		mockEmbeddingClient.AssertNotCalled(t, "CreateEmbedding", mock.Anything, lexicalDoc.Text)
+		mockEmbeddingClient.AssertNotCalled(t, "CreateEmbedding", mock.Anything, "Invoices are emailed monthly.")
+	})
+
+	t.Run("WithoutVectors", func(t *testing.T) {
+		// With an embedding client that defers to the vector store, the best sentence is still
+		// found by the words it shares with the query.
+		mockEmbeddingClient := new(embedding_mocks.EmbeddingClient)
+		mockEmbeddingClient.On("CreateEmbedding", mock.Anything, mock.Anything).Return(nil, nil)
+		mockVectorStore := new(storage_mocks.VectorStore)
+		mockVectorStore.On("Query", mock.Anything, "invoices emailed", mock.Anything, 5).Return([]storage.SearchResult{{Document: semanticDoc, Score: 0.9}}, nil)
+		mockTextStore := new(storage_mocks.TextStore)
+		mockTextStore.On("Search", mock.Anything, "invoices emailed", 5).Return([]storage.SearchResult{}, nil)
+		service := NewSearchService(mockEmbeddingClient, mockVectorStore, mockTextStore, WithHighlightDefaults(storage.Highlight{FragmentSize: 40}))
+
+		resp, err := service.Search(context.Background(), "invoices emailed", Options{TopK: 5, Highlight: &storage.Highlight{}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"<em>Invoices</em> are <em>emailed</em> monthly."}, resp.Results[0].Highlights)
+		// Only the query is embedded, for the vector store.
+		mockEmbeddingClient.AssertNumberOfCalls(t, "CreateEmbedding", 1)
+	})
+}
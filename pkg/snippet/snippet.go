@@ -0,0 +1,150 @@
+package snippet
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Sentences splits text into sentences at sentence-ending punctuation followed by whitespace,
+// and at line breaks. Surrounding whitespace is trimmed and empty sentences are dropped.
+func Sentences(text string) []string {
+	var sentences []string
+	runes := []rune(text)
+	start := 0
+	for i, r := range runes {
+		end := -1
+		switch {
+		case r == '\n':
+			end = i
+		case (r == '.' || r == '!' || r == '?') && i+1 < len(runes) && unicode.IsSpace(runes[i+1]):
+			end = i + 1
+		}
+		if end < 0 {
+			continue
+		}
+		if sentence := strings.TrimSpace(string(runes[start:end])); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = end
+	}
+	if sentence := strings.TrimSpace(string(runes[start:])); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+	return sentences
+}
+
+// Best builds a snippet of about size characters around the highest-scoring sentence. scores
+// must be aligned with sentences, and ties go to the earlier sentence. The snippet is extended
+// with the following and then the preceding sentences while they fit, and a best sentence
+// longer than size is cut at a word boundary.
+func Best(sentences []string, scores []float64, size int) string {
+	if len(sentences) == 0 {
+		return ""
+	}
+
+	best := 0
+	for i, score := range scores {
+		if score > scores[best] {
+			best = i
+		}
+	}
+
+	snippet := sentences[best]
+	if len([]rune(snippet)) > size {
+		return truncate(snippet, size)
+	}
+	length := len([]rune(snippet))
+	first, last := best, best
+	for {
+		switch {
+		case last+1 < len(sentences) && length+1+len([]rune(sentences[last+1])) <= size:
+			last++
+			length += 1 + len([]rune(sentences[last]))
+		case first > 0 && length+1+len([]rune(sentences[first-1])) <= size:
+			first--
+			length += 1 + len([]rune(sentences[first]))
+		default:
+			return strings.Join(sentences[first:last+1], " ")
+		}
+	}
+}
+
+// truncate cuts text to at most size characters, at the last word boundary if there is one.
+func truncate(text string, size int) string {
+	runes := []rune(text)
+	cut := string(runes[:size])
+	if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > 0 && !unicode.IsSpace(runes[size]) {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut)
+}
+
+// Terms returns the distinct lower-cased words of text.
+func Terms(text string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range strings.FieldsFunc(text, isSeparator) {
+		word = strings.ToLower(word)
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// Overlap scores how well text matches a query by the fraction of the query's terms, as
+// returned by Terms, that occur in it as whole words, ignoring case. It is 0 when there are
+// no terms.
+func Overlap(text string, terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(text, isSeparator) {
+		words[strings.ToLower(word)] = true
+	}
+	matched := 0
+	for _, term := range terms {
+		if words[term] {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(terms))
+}
+
+// Mark wraps every whole-word occurrence of terms in text with pre and post, ignoring case.
+func Mark(text string, terms []string, pre, post string) string {
+	if len(terms) == 0 {
+		return text
+	}
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[strings.ToLower(term)] = true
+	}
+
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if isSeparator(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && !isSeparator(runes[i]) {
+			i++
+		}
+		word := string(runes[start:i])
+		if wanted[strings.ToLower(word)] {
+			b.WriteString(pre + word + post)
+		} else {
+			b.WriteString(word)
+		}
+	}
+	return b.String()
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
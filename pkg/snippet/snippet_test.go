@@ -0,0 +1,59 @@
+package snippet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSentences(t *testing.T) {
+	text := "Invoices are emailed monthly. Version 2.1 is out!  Is it?\nNew line without stop\n\n"
+
+	assert.Equal(t, []string{
+		"Invoices are emailed monthly.",
+		"Version 2.1 is out!",
+		"Is it?",
+		"New line without stop",
+	}, Sentences(text))
+	assert.Empty(t, Sentences("  \n "))
+}
+
+func TestBest(t *testing.T) {
+	sentences := []string{"One.", "Two two.", "Three three three.", "Four."}
+
+	t.Run("ExtendsAroundBestSentence", func(t *testing.T) {
+		// "Three three three." is best; "Four." follows it, then "Two two." fits before.
+		assert.Equal(t, "Two two. Three three three. Four.", Best(sentences, []float64{0, 0.1, 0.9, 0.2}, 33))
+		assert.Equal(t, "Three three three. Four.", Best(sentences, []float64{0, 0.1, 0.9, 0.2}, 30))
+	})
+
+	t.Run("TiesGoToEarlierSentence", func(t *testing.T) {
+		assert.Equal(t, "One.", Best(sentences, []float64{0, 0, 0, 0}, 5))
+	})
+
+	t.Run("TruncatesLongSentence", func(t *testing.T) {
+		assert.Equal(t, "Three three", Best(sentences, []float64{0, 0, 1, 0}, 14))
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.Equal(t, "", Best(nil, nil, 100))
+	})
+}
+
+func TestMark(t *testing.T) {
+	terms := Terms("Reset, PASSWORD")
+
+	assert.Equal(t, []string{"reset", "password"}, terms)
+	assert.Equal(t,
+		"<em>Password</em> resets: <em>reset</em> your <em>password</em>.",
+		Mark("Password resets: reset your password.", terms, "<em>", "</em>"))
+	assert.Equal(t, "unchanged", Mark("unchanged", nil, "<em>", "</em>"))
+}
+
+func TestOverlap(t *testing.T) {
+	terms := Terms("invoices emailed")
+	assert.Equal(t, 1.0, Overlap("Invoices are emailed monthly.", terms))
+	assert.Equal(t, 0.5, Overlap("Pay invoices by card.", terms))
+	assert.Equal(t, 0.0, Overlap("Invoice totals, e-mailed.", terms))
+	assert.Equal(t, 0.0, Overlap("Invoices.", nil))
+}
@@ -6,19 +6,57 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 
+	"github.com/chr1sbest/hybrid-search/pkg/queryparser"
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
 )
 
-// ElasticsearchClient wraps the Elasticsearch client and implements the TextStore interface.
+// synonymAnalyzer is the search-time analyzer that expands query terms with the synonym set.
+const synonymAnalyzer = "synonym_search"
+
+// ElasticsearchClient wraps the Elasticsearch client and implements the TextStore,
+// HighlightSearcher, SynonymStore, SpellSuggester and Autocompleter interfaces.
 type ElasticsearchClient struct {
 	client    *elasticsearch.Client
 	indexName string
+	// synonymSet is the name of the managed synonym set, or empty if synonyms are disabled.
+	synonymSet string
+	// synonymRules seed the synonym set when the client starts.
+	synonymRules []string
+	// fuzziness configures typo tolerance for free-text terms.
+	fuzziness Fuzziness
+	// refresh controls when indexed documents become searchable.
+	refresh RefreshPolicy
+	// bulkMaxDocs and bulkMaxBytes limit the size of each _bulk request.
+	bulkMaxDocs  int
+	bulkMaxBytes int
+}
+
+// ElasticsearchOption configures optional ElasticsearchClient behaviour.
+type ElasticsearchOption func(*ElasticsearchClient)
+
+// WithSynonymSet enables query-time synonym expansion backed by the named Elasticsearch
+// synonym set, which is created or replaced with rules on startup. Only indexes created
+// with this option use the synonyms.
+func WithSynonymSet(name string, rules []string) ElasticsearchOption {
+	return func(c *ElasticsearchClient) {
+		c.synonymSet = name
+		c.synonymRules = rules
+	}
+}
+
+// WithFuzziness enables fuzzy matching of free-text query terms, so that misspelled queries
+// still find documents. Phrases and excluded terms are always matched exactly.
+func WithFuzziness(fuzziness Fuzziness) ElasticsearchOption {
+	return func(c *ElasticsearchClient) {
+		c.fuzziness = fuzziness
+	}
 }
 
 // NewElasticsearchClient creates a new client for Elasticsearch and ensures the index exists.
-func NewElasticsearchClient(address, indexName string) (*ElasticsearchClient, error) {
+func NewElasticsearchClient(address, indexName string, opts ...ElasticsearchOption) (*ElasticsearchClient, error) {
 	cfg := elasticsearch.Config{
 		Addresses: []string{address},
 	}
@@ -38,8 +76,36 @@ func NewElasticsearchClient(address, indexName string) (*ElasticsearchClient, er
 		return nil, fmt.Errorf("elasticsearch error: %s", res.String())
 	}
 
-	client := &ElasticsearchClient{client: es, indexName: indexName}
-	if err := client.createIndexIfNotExists(); err != nil {
+	client := &ElasticsearchClient{
+		client:       es,
+		indexName:    indexName,
+		refresh:      RefreshTrue,
+		bulkMaxDocs:  DefaultBulkMaxDocs,
+		bulkMaxBytes: DefaultBulkMaxBytes,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	if err := client.fuzziness.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := ParseRefreshPolicy(string(client.refresh)); err != nil {
+		return nil, err
+	}
+	if client.bulkMaxDocs < 1 || client.bulkMaxBytes < 1 {
+		return nil, fmt.Errorf("bulk limits must be at least 1")
+	}
+
+	// The synonym set must exist before an index that references it is created.
+	if client.synonymSet != "" {
+		if err := client.UpdateSynonyms(context.Background(), client.synonymRules); err != nil {
+			return nil, err
+		}
+	}
+	if err := client.createIndexIfNotExists(client.indexName, client.indexMapping()); err != nil {
+		return nil, err
+	}
+	if err := client.createIndexIfNotExists(client.suggestIndexName(), suggestIndexMapping()); err != nil {
 		return nil, err
 	}
 
@@ -47,27 +113,24 @@ func NewElasticsearchClient(address, indexName string) (*ElasticsearchClient, er
 	return client, nil
 }
 
-// createIndexIfNotExists checks if the index exists and creates it if it doesn't.
-func (c *ElasticsearchClient) createIndexIfNotExists() error {
-	res, err := c.client.Indices.Exists([]string{c.indexName})
+// createIndexIfNotExists checks if the named index exists and creates it with the given
+// settings and mappings if it doesn't.
+func (c *ElasticsearchClient) createIndexIfNotExists(name string, mapping map[string]interface{}) error {
+	res, err := c.client.Indices.Exists([]string{name})
 	if err != nil {
 		return fmt.Errorf("error checking if index exists: %w", err)
 	}
 
 	if res.StatusCode == 404 {
-		log.Printf("Index '%s' not found, creating...", c.indexName)
-		mapping := `{
-			"mappings": {
-				"properties": {
-					"document_id": {"type": "keyword"},
-					"text": {"type": "text"}
-				}
-			}
-		}`
+		log.Printf("Index '%s' not found, creating...", name)
+		body, err := json.Marshal(mapping)
+		if err != nil {
+			return fmt.Errorf("error encoding index mapping: %w", err)
+		}
 
 		res, err = c.client.Indices.Create(
-			c.indexName,
-			c.client.Indices.Create.WithBody(bytes.NewReader([]byte(mapping))),
+			name,
+			c.client.Indices.Create.WithBody(bytes.NewReader(body)),
 		)
 
 		if err != nil {
@@ -76,10 +139,101 @@ func (c *ElasticsearchClient) createIndexIfNotExists() error {
 		if res.IsError() {
 			return fmt.Errorf("error creating index: %s", res.String())
 		}
-		log.Printf("Index '%s' created.", c.indexName)
+		log.Printf("Index '%s' created.", name)
 	} else {
-		log.Printf("Index '%s' already exists.", c.indexName)
+		log.Printf("Index '%s' already exists.", name)
+	}
+	return nil
+}
+
+// indexMapping returns the settings and mappings used to create the index. With a synonym set,
+// the text field is indexed with the standard analyzer and searched with a synonym_graph
+// analyzer. The filter is updateable, so synonym changes apply without reindexing.
+func (c *ElasticsearchClient) indexMapping() map[string]interface{} {
+	textField := map[string]interface{}{"type": "text"}
+	mapping := map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"document_id":        map[string]interface{}{"type": "keyword"},
+				"parent_document_id": map[string]interface{}{"type": "keyword"},
+				"title":              map[string]interface{}{"type": "text"},
+				"text":               textField,
+				"page_starts":        map[string]interface{}{"type": "integer", "index": false},
+			},
+			// Metadata values are matched exactly by field filters such as "tag:billing".
+			"dynamic_templates": []interface{}{
+				map[string]interface{}{
+					"metadata_keywords": map[string]interface{}{
+						"path_match":         "metadata.*",
+						"match_mapping_type": "string",
+						"mapping":            map[string]interface{}{"type": "keyword"},
+					},
+				},
+			},
+		},
+	}
+	if c.synonymSet == "" {
+		return mapping
+	}
+
+	textField["analyzer"] = "standard"
+	textField["search_analyzer"] = synonymAnalyzer
+	mapping["settings"] = map[string]interface{}{
+		"analysis": map[string]interface{}{
+			"filter": map[string]interface{}{
+				"managed_synonyms": map[string]interface{}{
+					"type":         "synonym_graph",
+					"synonyms_set": c.synonymSet,
+					"updateable":   true,
+				},
+			},
+			"analyzer": map[string]interface{}{
+				synonymAnalyzer: map[string]interface{}{
+					"tokenizer": "standard",
+					"filter":    []string{"lowercase", "managed_synonyms"},
+				},
+			},
+		},
+	}
+	return mapping
+}
+
+// UpdateSynonyms replaces the rules in the client's synonym set. Elasticsearch reloads the
+// search analyzers of every index that uses the set, so the change applies to the next search.
+func (c *ElasticsearchClient) UpdateSynonyms(ctx context.Context, rules []string) error {
+	if c.synonymSet == "" {
+		return fmt.Errorf("no synonym set is configured")
+	}
+
+	type synonymRule struct {
+		Synonyms string `json:"synonyms"`
+	}
+	body := struct {
+		SynonymsSet []synonymRule `json:"synonyms_set"`
+	}{SynonymsSet: make([]synonymRule, len(rules))}
+	for i, rule := range rules {
+		body.SynonymsSet[i] = synonymRule{Synonyms: rule}
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error encoding synonym set: %w", err)
+	}
+
+	res, err := c.client.SynonymsPutSynonym(
+		c.synonymSet,
+		bytes.NewReader(data),
+		c.client.SynonymsPutSynonym.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error updating synonym set: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error updating synonym set %s: %s", c.synonymSet, res.String())
 	}
+	log.Printf("Synonym set '%s' updated with %d rules.", c.synonymSet, len(rules))
 	return nil
 }
 
@@ -94,7 +248,7 @@ func (c *ElasticsearchClient) Index(ctx context.Context, doc Document) error {
 		Index:      c.indexName,
 		DocumentID: doc.DocumentID,
 		Body:       bytes.NewReader(data),
-		Refresh:    "true",
+		Refresh:    c.refresh.param(),
 	}
 
 	res, err := req.Do(ctx, c.client)
@@ -106,19 +260,61 @@ func (c *ElasticsearchClient) Index(ctx context.Context, doc Document) error {
 	if res.IsError() {
 		return fmt.Errorf("error indexing document ID=%s: %s", doc.DocumentID, res.String())
 	}
+
+	if doc.Title != "" {
+		if err := c.indexTitleSuggestion(ctx, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete implements the TextStore interface. The document's title suggestion is removed too.
+func (c *ElasticsearchClient) Delete(ctx context.Context, id string) error {
+	if err := c.deleteByID(ctx, c.indexName, id); err != nil {
+		return err
+	}
+	return c.deleteByID(ctx, c.suggestIndexName(), suggestionSourceTitle+":"+id)
+}
+
+// deleteByID deletes a document from an index, treating a missing document as deleted.
+func (c *ElasticsearchClient) deleteByID(ctx context.Context, index, id string) error {
+	res, err := c.client.Delete(index, id,
+		c.client.Delete.WithContext(ctx),
+		c.client.Delete.WithRefresh(c.refresh.param()),
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting document: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("error deleting document ID=%s from %s: %s", id, index, res.String())
+	}
 	return nil
 }
 
-// Search performs a full-text search on the Elasticsearch index.
+// Search performs a full-text search on the Elasticsearch index. The query text is parsed
+// with the query syntax, so phrases, exclusions, boolean operators and field filters apply.
 func (c *ElasticsearchClient) Search(ctx context.Context, queryText string, topK int) ([]SearchResult, error) {
+	return c.search(ctx, queryText, topK, nil)
+}
+
+// SearchWithHighlights implements the HighlightSearcher interface using the Elasticsearch
+// highlighter on the text field.
+func (c *ElasticsearchClient) SearchWithHighlights(ctx context.Context, queryText string, topK int, highlight Highlight) ([]SearchResult, error) {
+	return c.search(ctx, queryText, topK, &highlight)
+}
+
+// search runs a lexical search, with highlighting if highlight is not nil.
+func (c *ElasticsearchClient) search(ctx context.Context, queryText string, topK int, highlight *Highlight) ([]SearchResult, error) {
 	var buf bytes.Buffer
 	query := map[string]interface{}{
-		"query": map[string]interface{}{
-			"match": map[string]interface{}{
-				"text": queryText,
-			},
-		},
-		"size": topK,
+		"query": buildQuery(queryparser.Parse(queryText), c.fuzziness),
+		"size":  topK,
+	}
+	if highlight != nil {
+		query["highlight"] = highlightParams(*highlight)
 	}
 	if err := json.NewEncoder(&buf).Encode(query); err != nil {
 		return nil, fmt.Errorf("error encoding query: %w", err)
@@ -139,29 +335,73 @@ func (c *ElasticsearchClient) Search(ctx context.Context, queryText string, topK
 		return nil, fmt.Errorf("elasticsearch search error: %s", res.String())
 	}
 
-	var r map[string]interface{}
+	var r struct {
+		Hits struct {
+			Hits []struct {
+				Score     float64  `json:"_score"`
+				Source    Document `json:"_source"`
+				Highlight struct {
+					Text []string `json:"text"`
+				} `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
 	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
 		return nil, fmt.Errorf("error parsing the response body: %w", err)
 	}
 
 	var results []SearchResult
-	hits, found := r["hits"].(map[string]interface{})["hits"].([]interface{})
-	if !found {
-		return results, nil
-	}
-
-	for _, hit := range hits {
-		hitMap := hit.(map[string]interface{})
-		source := hitMap["_source"].(map[string]interface{})
-		doc := Document{
-			DocumentID: source["document_id"].(string),
-			Text:       source["text"].(string),
-		}
+	for _, hit := range r.Hits.Hits {
 		results = append(results, SearchResult{
-			Document: doc,
-			Score:    hitMap["_score"].(float64),
+			Document:   hit.Source,
+			Score:      hit.Score,
+			Highlights: hit.Highlight.Text,
 		})
 	}
 
 	return results, nil
 }
+
+// SuggestSpelling implements the SpellSuggester interface using the Elasticsearch phrase
+// suggester, so corrections are drawn from the vocabulary of the indexed documents.
+func (c *ElasticsearchClient) SuggestSpelling(ctx context.Context, queryText string, size int) ([]string, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(spellingSuggestion(queryText, size)); err != nil {
+		return nil, fmt.Errorf("error encoding suggest request: %w", err)
+	}
+
+	res, err := c.client.Search(
+		c.client.Search.WithContext(ctx),
+		c.client.Search.WithIndex(c.indexName),
+		c.client.Search.WithBody(&buf),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error executing suggest request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("elasticsearch suggest error: %s", res.String())
+	}
+
+	var r struct {
+		Suggest struct {
+			DidYouMean []struct {
+				Options []struct {
+					Text string `json:"text"`
+				} `json:"options"`
+			} `json:"did_you_mean"`
+		} `json:"suggest"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	var suggestions []string
+	for _, entry := range r.Suggest.DidYouMean {
+		for _, option := range entry.Options {
+			suggestions = append(suggestions, option.Text)
+		}
+	}
+	return suggestions, nil
+}
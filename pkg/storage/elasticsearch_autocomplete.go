@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// titleSuggestionWeight is the popularity of a document title. Recorded queries start at their
+// search count, so popular queries rank above titles.
+const titleSuggestionWeight = 1
+
+// Suggestion sources, stored with each entry in the suggest index.
+const (
+	suggestionSourceTitle = "title"
+	suggestionSourceQuery = "query"
+)
+
+// suggestIndexName returns the name of the index that holds autocomplete entries. They are
+// kept apart from documents so they never show up in search results.
+func (c *ElasticsearchClient) suggestIndexName() string {
+	return c.indexName + "-suggest"
+}
+
+// suggestIndexMapping returns the mapping of the suggest index. A completion field is held in
+// memory as a finite state transducer, which keeps prefix lookups fast.
+func suggestIndexMapping() map[string]interface{} {
+	return map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"suggest": map[string]interface{}{"type": "completion", "analyzer": "simple"},
+				"source":  map[string]interface{}{"type": "keyword"},
+			},
+		},
+	}
+}
+
+// completionQuery builds a completion suggester request for prefix.
+func completionQuery(prefix string, size int) map[string]interface{} {
+	return map[string]interface{}{
+		"_source": false,
+		"suggest": map[string]interface{}{
+			"autocomplete": map[string]interface{}{
+				"prefix": prefix,
+				"completion": map[string]interface{}{
+					"field":           "suggest",
+					"size":            size,
+					"skip_duplicates": true,
+				},
+			},
+		},
+	}
+}
+
+// querySuggestionUpdate builds an update that adds count to the weight of a recorded query,
+// creating the entry if it is new.
+func querySuggestionUpdate(query string, count int) map[string]interface{} {
+	return map[string]interface{}{
+		"script": map[string]interface{}{
+			"source": "ctx._source.suggest.weight += params.count",
+			"params": map[string]interface{}{"count": count},
+		},
+		"upsert": map[string]interface{}{
+			"suggest": map[string]interface{}{"input": []string{query}, "weight": count},
+			"source":  suggestionSourceQuery,
+		},
+	}
+}
+
+// titleSuggestion returns the autocomplete entry for a document's title.
+func titleSuggestion(doc Document) map[string]interface{} {
+	return map[string]interface{}{
+		"suggest": map[string]interface{}{"input": []string{doc.Title}, "weight": titleSuggestionWeight},
+		"source":  suggestionSourceTitle,
+	}
+}
+
+// indexTitleSuggestion adds or replaces the autocomplete entry for a document's title.
+func (c *ElasticsearchClient) indexTitleSuggestion(ctx context.Context, doc Document) error {
+	data, err := json.Marshal(titleSuggestion(doc))
+	if err != nil {
+		return fmt.Errorf("error marshalling title suggestion: %w", err)
+	}
+
+	req := esapi.IndexRequest{
+		Index:      c.suggestIndexName(),
+		DocumentID: suggestionSourceTitle + ":" + doc.DocumentID,
+		Body:       bytes.NewReader(data),
+	}
+	res, err := req.Do(ctx, c.client)
+	if err != nil {
+		return fmt.Errorf("error indexing title suggestion: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error indexing title suggestion for document ID=%s: %s", doc.DocumentID, res.String())
+	}
+	return nil
+}
+
+// Autocomplete implements the Autocompleter interface using the completion suggester.
+func (c *ElasticsearchClient) Autocomplete(ctx context.Context, prefix string, size int) ([]string, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(completionQuery(prefix, size)); err != nil {
+		return nil, fmt.Errorf("error encoding completion request: %w", err)
+	}
+
+	res, err := c.client.Search(
+		c.client.Search.WithContext(ctx),
+		c.client.Search.WithIndex(c.suggestIndexName()),
+		c.client.Search.WithBody(&buf),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error executing completion request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("elasticsearch completion error: %s", res.String())
+	}
+
+	var r struct {
+		Suggest struct {
+			Autocomplete []struct {
+				Options []struct {
+					Text string `json:"text"`
+				} `json:"options"`
+			} `json:"autocomplete"`
+		} `json:"suggest"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("error parsing the response body: %w", err)
+	}
+
+	completions := []string{}
+	for _, entry := range r.Suggest.Autocomplete {
+		for _, option := range entry.Options {
+			completions = append(completions, option.Text)
+		}
+	}
+	return completions, nil
+}
+
+// RecordQueries implements the Autocompleter interface. Each query is updated separately, and
+// a failed update does not stop the others.
+func (c *ElasticsearchClient) RecordQueries(ctx context.Context, counts map[string]int) error {
+	var errs []error
+	for query, count := range counts {
+		data, err := json.Marshal(querySuggestionUpdate(query, count))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error encoding update for query %q: %w", query, err))
+			continue
+		}
+
+		res, err := c.client.Update(
+			c.suggestIndexName(),
+			suggestionSourceQuery+":"+query,
+			bytes.NewReader(data),
+			c.client.Update.WithContext(ctx),
+		)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error recording query %q: %w", query, err))
+			continue
+		}
+		if res.IsError() {
+			errs = append(errs, fmt.Errorf("error recording query %q: %s", query, res.String()))
+		}
+		res.Body.Close()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletionQuery(t *testing.T) {
+	got, err := json.Marshal(completionQuery("res", 5))
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"_source": false,
+		"suggest": {"autocomplete": {
+			"prefix": "res",
+			"completion": {"field": "suggest", "size": 5, "skip_duplicates": true}
+		}}
+	}`, string(got))
+}
+
+func TestQuerySuggestionUpdate(t *testing.T) {
+	got, err := json.Marshal(querySuggestionUpdate("reset password", 3))
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"script": {"source": "ctx._source.suggest.weight += params.count", "params": {"count": 3}},
+		"upsert": {"suggest": {"input": ["reset password"], "weight": 3}, "source": "query"}
+	}`, string(got))
+}
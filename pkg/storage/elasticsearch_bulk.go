@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// Default limits on the size of a single _bulk request.
+const (
+	DefaultBulkMaxDocs  = 500
+	DefaultBulkMaxBytes = 5 << 20
+)
+
+// RefreshPolicy controls when indexed documents become visible to search.
+type RefreshPolicy string
+
+const (
+	// RefreshNone leaves documents to the index's periodic refresh. It is the cheapest policy.
+	RefreshNone RefreshPolicy = "none"
+	// RefreshWaitFor waits for the next periodic refresh before returning.
+	RefreshWaitFor RefreshPolicy = "wait_for"
+	// RefreshTrue forces a refresh, so documents are searchable as soon as the call returns.
+	RefreshTrue RefreshPolicy = "true"
+)
+
+// ParseRefreshPolicy parses "none", "wait_for" or "true".
+func ParseRefreshPolicy(value string) (RefreshPolicy, error) {
+	switch policy := RefreshPolicy(value); policy {
+	case RefreshNone, RefreshWaitFor, RefreshTrue:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid refresh policy %q: must be none, wait_for or true", value)
+}
+
+// param returns the value of the Elasticsearch refresh parameter for the policy.
+func (p RefreshPolicy) param() string {
+	if p == RefreshNone {
+		return "false"
+	}
+	return string(p)
+}
+
+// WithRefreshPolicy sets when documents indexed by Index and IndexMany become searchable.
+// The default is RefreshTrue.
+func WithRefreshPolicy(policy RefreshPolicy) ElasticsearchOption {
+	return func(c *ElasticsearchClient) {
+		c.refresh = policy
+	}
+}
+
+// WithBulkLimits sets the maximum number of documents and bytes sent in a single _bulk
+// request by IndexMany. Larger batches are split into several requests.
+func WithBulkLimits(maxDocs, maxBytes int) ElasticsearchOption {
+	return func(c *ElasticsearchClient) {
+		c.bulkMaxDocs = maxDocs
+		c.bulkMaxBytes = maxBytes
+	}
+}
+
+// bulkBatch is the body of one _bulk request, with the action each line pair in it encodes.
+type bulkBatch struct {
+	body    []byte
+	actions []bulkAction
+}
+
+// bulkAction identifies the document an action in a _bulk request belongs to.
+type bulkAction struct {
+	// doc is the index of the document in the IndexMany call.
+	doc int
+	// suggestion marks the action that indexes the document's title suggestion.
+	suggestion bool
+}
+
+// IndexMany implements the TextStore interface using the _bulk API. Documents are sent in
+// batches within the client's bulk limits, and the index is refreshed once, with the last
+// batch, according to the refresh policy. A failed title suggestion is logged and doesn't
+// fail its document. If a request fails outright, the batches before it have been indexed.
+func (c *ElasticsearchClient) IndexMany(ctx context.Context, docs []Document) ([]IndexResult, error) {
+	batches, err := c.bulkBatches(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]IndexResult, len(docs))
+	for i, doc := range docs {
+		results[i].DocumentID = doc.DocumentID
+	}
+	for i, batch := range batches {
+		opts := []func(*esapi.BulkRequest){c.client.Bulk.WithContext(ctx)}
+		if i == len(batches)-1 {
+			opts = append(opts, c.client.Bulk.WithRefresh(c.refresh.param()))
+		}
+
+		res, err := c.client.Bulk(bytes.NewReader(batch.body), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("error executing bulk request %d of %d: %w", i+1, len(batches), err)
+		}
+		if res.IsError() {
+			res.Body.Close()
+			return nil, fmt.Errorf("elasticsearch bulk error in request %d of %d: %s", i+1, len(batches), res.String())
+		}
+		err = parseBulkResponse(res.Body, batch.actions, results)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// bulkBatches encodes index actions for docs, and for their title suggestions, into _bulk
+// request bodies. A new batch is started when the current one would exceed the document or
+// byte limit; a single document larger than the byte limit is sent on its own.
+func (c *ElasticsearchClient) bulkBatches(docs []Document) ([]bulkBatch, error) {
+	var batches []bulkBatch
+	var current bulkBatch
+	count := 0
+	for i, doc := range docs {
+		var entry bytes.Buffer
+		actions := []bulkAction{{doc: i}}
+		if err := writeBulkIndex(&entry, c.indexName, doc.DocumentID, doc); err != nil {
+			return nil, err
+		}
+		if doc.Title != "" {
+			if err := writeBulkIndex(&entry, c.suggestIndexName(), suggestionSourceTitle+":"+doc.DocumentID, titleSuggestion(doc)); err != nil {
+				return nil, err
+			}
+			actions = append(actions, bulkAction{doc: i, suggestion: true})
+		}
+
+		if count > 0 && (count >= c.bulkMaxDocs || len(current.body)+entry.Len() > c.bulkMaxBytes) {
+			batches = append(batches, current)
+			current, count = bulkBatch{}, 0
+		}
+		current.body = append(current.body, entry.Bytes()...)
+		current.actions = append(current.actions, actions...)
+		count++
+	}
+	if count > 0 {
+		batches = append(batches, current)
+	}
+	return batches, nil
+}
+
+// writeBulkIndex writes an index action and its source as two lines of NDJSON.
+func writeBulkIndex(w *bytes.Buffer, index, id string, source interface{}) error {
+	action := map[string]interface{}{"index": map[string]interface{}{"_index": index, "_id": id}}
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(action); err != nil {
+		return fmt.Errorf("error encoding bulk action: %w", err)
+	}
+	if err := enc.Encode(source); err != nil {
+		return fmt.Errorf("error marshalling document ID=%s: %w", id, err)
+	}
+	return nil
+}
+
+// parseBulkResponse records the outcome of every document action of a _bulk response in
+// results. actions describes the request's actions, in order.
+func parseBulkResponse(body io.Reader, actions []bulkAction, results []IndexResult) error {
+	var r struct {
+		Items []map[string]struct {
+			ID     string `json:"_id"`
+			Status int    `json:"status"`
+			Result string `json:"result"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(body).Decode(&r); err != nil {
+		return fmt.Errorf("error parsing the bulk response body: %w", err)
+	}
+	if len(r.Items) != len(actions) {
+		return fmt.Errorf("bulk response has %d items for %d actions", len(r.Items), len(actions))
+	}
+
+	for i, item := range r.Items {
+		action := actions[i]
+		for _, outcome := range item {
+			var err error
+			if outcome.Error != nil {
+				err = &BulkItemError{Status: outcome.Status, Reason: fmt.Sprintf("%s: %s", outcome.Error.Type, outcome.Error.Reason)}
+			}
+			if action.suggestion {
+				if err != nil {
+					log.Printf("Failed to index title suggestion %s: %v", outcome.ID, err)
+				}
+				continue
+			}
+			results[action.doc].Created = outcome.Result == "created"
+			results[action.doc].Err = err
+		}
+	}
+	return nil
+}
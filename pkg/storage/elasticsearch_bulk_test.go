@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkBatches(t *testing.T) {
+	docs := []Document{
+		{DocumentID: "a", Text: "first", Title: "First"},
+		{DocumentID: "b", Text: "second"},
+		{DocumentID: "c", Text: "third"},
+	}
+
+	t.Run("EncodesActionsAndTitleSuggestions", func(t *testing.T) {
+		client := &ElasticsearchClient{indexName: "docs", bulkMaxDocs: 10, bulkMaxBytes: 1 << 20}
+
+		batches, err := client.bulkBatches(docs[:1])
+
+		assert.NoError(t, err)
+		assert.Len(t, batches, 1)
+		lines := strings.Split(strings.TrimSpace(string(batches[0].body)), "\n")
+		assert.Len(t, lines, 4)
+		assert.JSONEq(t, `{"index": {"_index": "docs", "_id": "a"}}`, lines[0])
+		assert.JSONEq(t, `{"document_id": "a", "title": "First", "text": "first"}`, lines[1])
+		assert.JSONEq(t, `{"index": {"_index": "docs-suggest", "_id": "title:a"}}`, lines[2])
+		assert.JSONEq(t, `{"suggest": {"input": ["First"], "weight": 1}, "source": "title"}`, lines[3])
+		assert.Equal(t, []bulkAction{{doc: 0}, {doc: 0, suggestion: true}}, batches[0].actions)
+	})
+
+	t.Run("SplitsOnDocumentLimit", func(t *testing.T) {
+		client := &ElasticsearchClient{indexName: "docs", bulkMaxDocs: 2, bulkMaxBytes: 1 << 20}
+
+		batches, err := client.bulkBatches(docs)
+
+		assert.NoError(t, err)
+		assert.Len(t, batches, 2)
+		assert.Equal(t, []bulkAction{{doc: 0}, {doc: 0, suggestion: true}, {doc: 1}}, batches[0].actions)
+		assert.Equal(t, []bulkAction{{doc: 2}}, batches[1].actions)
+	})
+
+	t.Run("SplitsOnByteLimit", func(t *testing.T) {
+		// Each untitled document takes about 75 bytes, and the titled one is sent alone.
+		client := &ElasticsearchClient{indexName: "docs", bulkMaxDocs: 10, bulkMaxBytes: 100}
+
+		batches, err := client.bulkBatches(docs)
+
+		assert.NoError(t, err)
+		assert.Len(t, batches, 3)
+		for _, batch := range batches[1:] {
+			assert.LessOrEqual(t, len(batch.body), 100)
+		}
+	})
+
+	t.Run("NoDocuments", func(t *testing.T) {
+		client := &ElasticsearchClient{indexName: "docs", bulkMaxDocs: 10, bulkMaxBytes: 100}
+		batches, err := client.bulkBatches(nil)
+		assert.NoError(t, err)
+		assert.Empty(t, batches)
+	})
+}
+
+func TestParseBulkResponse(t *testing.T) {
+	actions := []bulkAction{{doc: 0}, {doc: 0, suggestion: true}, {doc: 1}, {doc: 2}}
+
+	t.Run("RecordsOutcomePerDocument", func(t *testing.T) {
+		body := `{"errors": true, "items": [
+			{"index": {"_id": "a", "status": 201, "result": "created"}},
+			{"index": {"_id": "title:a", "status": 400, "error": {"type": "mapper_parsing_exception", "reason": "bad title"}}},
+			{"index": {"_id": "b", "status": 200, "result": "updated"}},
+			{"index": {"_id": "c", "status": 429, "error": {"type": "es_rejected_execution_exception", "reason": "queue full"}}}
+		]}`
+		results := []IndexResult{{DocumentID: "a"}, {DocumentID: "b"}, {DocumentID: "c"}}
+
+		err := parseBulkResponse(strings.NewReader(body), actions, results)
+
+		// A failed title suggestion doesn't fail its document.
+		assert.NoError(t, err)
+		assert.Equal(t, IndexResult{DocumentID: "a", Created: true}, results[0])
+		assert.Equal(t, IndexResult{DocumentID: "b"}, results[1])
+		assert.Equal(t, &BulkItemError{Status: 429, Reason: "es_rejected_execution_exception: queue full"}, results[2].Err)
+		assert.Equal(t, "status 429: es_rejected_execution_exception: queue full", results[2].Err.Error())
+	})
+
+	t.Run("MismatchedItems", func(t *testing.T) {
+		err := parseBulkResponse(strings.NewReader(`{"items": []}`), actions, make([]IndexResult, 3))
+		assert.Error(t, err)
+	})
+
+	t.Run("MalformedBody", func(t *testing.T) {
+		err := parseBulkResponse(strings.NewReader(`{`), nil, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseRefreshPolicy(t *testing.T) {
+	for _, value := range []string{"none", "wait_for", "true"} {
+		policy, err := ParseRefreshPolicy(value)
+		assert.NoError(t, err)
+		assert.Equal(t, RefreshPolicy(value), policy)
+	}
+	_, err := ParseRefreshPolicy("false")
+	assert.Error(t, err)
+
+	assert.Equal(t, "false", RefreshNone.param())
+	assert.Equal(t, "wait_for", RefreshWaitFor.param())
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/chr1sbest/hybrid-search/pkg/queryparser"
+)
+
+// fuzzinessPattern matches the edit distances Elasticsearch accepts: 0, 1, 2, AUTO or AUTO:low,high.
+var fuzzinessPattern = regexp.MustCompile(`^(?:[012]|AUTO(?::\d+,\d+)?)$`)
+
+// Fuzziness configures typo tolerance for free-text terms in lexical search.
+type Fuzziness struct {
+	// Distance is the maximum edit distance: "0", "1", "2", "AUTO" or "AUTO:low,high", where
+	// AUTO scales with term length. Empty disables fuzzy matching.
+	Distance string
+	// PrefixLength is the number of leading characters that must match exactly.
+	PrefixLength int
+	// MaxExpansions caps the number of terms each fuzzy term expands to. Zero uses the
+	// Elasticsearch default.
+	MaxExpansions int
+}
+
+// Validate reports whether the settings are acceptable to Elasticsearch.
+func (f Fuzziness) Validate() error {
+	if f.Distance != "" && !fuzzinessPattern.MatchString(f.Distance) {
+		return fmt.Errorf("invalid fuzziness %q: must be 0, 1, 2, AUTO or AUTO:low,high", f.Distance)
+	}
+	if f.PrefixLength < 0 {
+		return fmt.Errorf("fuzzy prefix length cannot be negative")
+	}
+	if f.MaxExpansions < 0 {
+		return fmt.Errorf("fuzzy max expansions cannot be negative")
+	}
+	return nil
+}
+
+// documentFields are the Document fields that can be filtered on directly. Any other field
+// name in a query filter refers to a metadata key.
+var documentFields = map[string]bool{
+	"document_id":        true,
+	"parent_document_id": true,
+}
+
+// buildQuery translates a parsed query into the Elasticsearch query DSL. Free text becomes
+// a match query, fuzzy if enabled, phrases match_phrase, field filters non-scoring term
+// filters and exclusions must_not clauses. Phrases and exclusions are always matched exactly.
+func buildQuery(node queryparser.Node, fuzzy Fuzziness) map[string]interface{} {
+	switch n := node.(type) {
+	case nil:
+		return map[string]interface{}{"match_none": map[string]interface{}{}}
+	case queryparser.Term:
+		return map[string]interface{}{"match": map[string]interface{}{"text": matchParams(n.Text, fuzzy)}}
+	case queryparser.Phrase:
+		return map[string]interface{}{"match_phrase": map[string]interface{}{"text": n.Text}}
+	case queryparser.Field:
+		return boolQuery(nil, []interface{}{termFilter(n)}, nil, nil)
+	case queryparser.Not:
+		return boolQuery(nil, nil, []interface{}{clause(n.Node)}, nil)
+	case queryparser.Or:
+		var should []interface{}
+		for _, child := range n.Nodes {
+			should = append(should, buildQuery(child, fuzzy))
+		}
+		return boolQuery(nil, nil, nil, should)
+	case queryparser.And:
+		var must, filter, mustNot []interface{}
+		for _, child := range n.Nodes {
+			switch c := child.(type) {
+			case queryparser.Field:
+				filter = append(filter, termFilter(c))
+			case queryparser.Not:
+				mustNot = append(mustNot, clause(c.Node))
+			default:
+				must = append(must, buildQuery(c, fuzzy))
+			}
+		}
+		return boolQuery(must, filter, mustNot, nil)
+	}
+	return map[string]interface{}{"match_none": map[string]interface{}{}}
+}
+
+// clause translates a node used as a filter or exclusion, where scoring does not matter.
+func clause(node queryparser.Node) interface{} {
+	if field, ok := node.(queryparser.Field); ok {
+		return termFilter(field)
+	}
+	return buildQuery(node, Fuzziness{})
+}
+
+// matchParams returns the match query parameters for text. Without fuzziness this is the
+// bare query text.
+func matchParams(text string, fuzzy Fuzziness) interface{} {
+	if fuzzy.Distance == "" {
+		return text
+	}
+	params := map[string]interface{}{
+		"query":     text,
+		"fuzziness": fuzzy.Distance,
+	}
+	if fuzzy.PrefixLength > 0 {
+		params["prefix_length"] = fuzzy.PrefixLength
+	}
+	if fuzzy.MaxExpansions > 0 {
+		params["max_expansions"] = fuzzy.MaxExpansions
+	}
+	return params
+}
+
+// termFilter matches a field's exact value.
+func termFilter(field queryparser.Field) map[string]interface{} {
+	name := field.Name
+	if !documentFields[name] {
+		name = "metadata." + name
+	}
+	return map[string]interface{}{"term": map[string]interface{}{name: field.Value}}
+}
+
+// boolQuery builds a bool query from the non-empty clause lists.
+func boolQuery(must, filter, mustNot, should []interface{}) map[string]interface{} {
+	clauses := map[string]interface{}{}
+	if len(must) > 0 {
+		clauses["must"] = must
+	}
+	if len(filter) > 0 {
+		clauses["filter"] = filter
+	}
+	if len(mustNot) > 0 {
+		clauses["must_not"] = mustNot
+	}
+	if len(should) > 0 {
+		clauses["should"] = should
+		clauses["minimum_should_match"] = 1
+	}
+	return map[string]interface{}{"bool": clauses}
+}
+
+// highlightParams builds the highlight section of a search request. Only the text field is
+// highlighted, with the matches of the search query.
+func highlightParams(highlight Highlight) map[string]interface{} {
+	return map[string]interface{}{
+		"pre_tags":            []string{highlight.PreTag},
+		"post_tags":           []string{highlight.PostTag},
+		"fragment_size":       highlight.FragmentSize,
+		"number_of_fragments": highlight.NumberOfFragments,
+		"fields": map[string]interface{}{
+			"text": map[string]interface{}{},
+		},
+	}
+}
+
+// spellingSuggestion builds a phrase suggester request for corrections of text, drawn from the
+// terms in the indexed text. Corrections that would not match any document are dropped.
+func spellingSuggestion(text string, size int) map[string]interface{} {
+	return map[string]interface{}{
+		"size": 0,
+		"suggest": map[string]interface{}{
+			"did_you_mean": map[string]interface{}{
+				"text": text,
+				"phrase": map[string]interface{}{
+					"field": "text",
+					"size":  size,
+					"direct_generator": []interface{}{
+						map[string]interface{}{"field": "text", "suggest_mode": "always"},
+					},
+					"collate": map[string]interface{}{
+						"query": map[string]interface{}{
+							"source": map[string]interface{}{
+								"match": map[string]interface{}{
+									"text": map[string]interface{}{"query": "{{suggestion}}", "operator": "and"},
+								},
+							},
+						},
+						"prune": false,
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/chr1sbest/hybrid-search/pkg/queryparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "Empty", input: "", want: `{"match_none": {}}`},
+		{name: "PlainText", input: "reset password", want: `{"match": {"text": "reset password"}}`},
+		{
+			name:  "PhraseExclusionAndFilter",
+			input: `"exact phrase" -exclude tag:billing`,
+			want: `{"bool": {
+				"must": [{"match_phrase": {"text": "exact phrase"}}],
+				"filter": [{"term": {"metadata.tag": "billing"}}],
+				"must_not": [{"match": {"text": "exclude"}}]
+			}}`,
+		},
+		{
+			name:  "OrWithDocumentField",
+			input: "invoice OR parent_document_id:abc",
+			want: `{"bool": {
+				"should": [
+					{"match": {"text": "invoice"}},
+					{"bool": {"filter": [{"term": {"parent_document_id": "abc"}}]}}
+				],
+				"minimum_should_match": 1
+			}}`,
+		},
+		{
+			name:  "ExcludedFilter",
+			input: "report -status:draft",
+			want: `{"bool": {
+				"must": [{"match": {"text": "report"}}],
+				"must_not": [{"term": {"metadata.status": "draft"}}]
+			}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(buildQuery(queryparser.Parse(tt.input), Fuzziness{}))
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestBuildQuery_Fuzziness(t *testing.T) {
+	fuzzy := Fuzziness{Distance: "AUTO", PrefixLength: 1, MaxExpansions: 20}
+
+	got, err := json.Marshal(buildQuery(queryparser.Parse(`pasword resett "exact phrase" -exclde`), fuzzy))
+
+	assert.NoError(t, err)
+	// Only the free-text terms are fuzzy; phrases and exclusions stay exact.
+	assert.JSONEq(t, `{"bool": {
+		"must": [
+			{"match": {"text": {"query": "pasword resett", "fuzziness": "AUTO", "prefix_length": 1, "max_expansions": 20}}},
+			{"match_phrase": {"text": "exact phrase"}}
+		],
+		"must_not": [{"match": {"text": "exclde"}}]
+	}}`, string(got))
+}
+
+func TestFuzziness_Validate(t *testing.T) {
+	for _, distance := range []string{"", "0", "1", "2", "AUTO", "AUTO:3,6"} {
+		assert.NoError(t, Fuzziness{Distance: distance}.Validate(), distance)
+	}
+	for _, distance := range []string{"3", "auto", "AUTO:3", "fuzzy"} {
+		assert.Error(t, Fuzziness{Distance: distance}.Validate(), distance)
+	}
+	assert.Error(t, Fuzziness{Distance: "AUTO", PrefixLength: -1}.Validate())
+	assert.Error(t, Fuzziness{Distance: "AUTO", MaxExpansions: -1}.Validate())
+}
+
+func TestSpellingSuggestion(t *testing.T) {
+	got, err := json.Marshal(spellingSuggestion("pasword reset", 3))
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"size": 0,
+		"suggest": {"did_you_mean": {
+			"text": "pasword reset",
+			"phrase": {
+				"field": "text",
+				"size": 3,
+				"direct_generator": [{"field": "text", "suggest_mode": "always"}],
+				"collate": {
+					"query": {"source": {"match": {"text": {"query": "{{suggestion}}", "operator": "and"}}}},
+					"prune": false
+				}
+			}
+		}}
+	}`, string(got))
+}
+
+func TestHighlightParams(t *testing.T) {
+	got, err := json.Marshal(highlightParams(Highlight{PreTag: "<b>", PostTag: "</b>", FragmentSize: 100, NumberOfFragments: 2}))
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"pre_tags": ["<b>"],
+		"post_tags": ["</b>"],
+		"fragment_size": 100,
+		"number_of_fragments": 2,
+		"fields": {"text": {}}
+	}`, string(got))
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// scanPageSize is the number of documents fetched per page by ScanDocuments.
+const scanPageSize = 500
+
+// scanKeepAlive is how long Elasticsearch keeps a scroll open between pages.
+const scanKeepAlive = time.Minute
+
+// ScanDocuments implements the DocumentScanner interface with the scroll API, so the scan sees
+// the index as it was when it started.
+func (c *ElasticsearchClient) ScanDocuments(ctx context.Context, fn func(docs []Document) error) error {
+	var buf bytes.Buffer
+	query := map[string]interface{}{
+		"size":  scanPageSize,
+		"sort":  []string{"_doc"},
+		"query": map[string]interface{}{"match_all": map[string]interface{}{}},
+	}
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return fmt.Errorf("error encoding scan query: %w", err)
+	}
+
+	res, err := c.client.Search(
+		c.client.Search.WithContext(ctx),
+		c.client.Search.WithIndex(c.indexName),
+		c.client.Search.WithBody(&buf),
+		c.client.Search.WithScroll(scanKeepAlive),
+	)
+	if err != nil {
+		return fmt.Errorf("error starting scan: %w", err)
+	}
+	scrollID, docs, err := readScanPage(res.Body, res.IsError(), res.String)
+	if err != nil {
+		return err
+	}
+	defer c.clearScroll(scrollID)
+
+	for len(docs) > 0 {
+		if err := fn(docs); err != nil {
+			return err
+		}
+
+		res, err := c.client.Scroll(
+			c.client.Scroll.WithContext(ctx),
+			c.client.Scroll.WithScrollID(scrollID),
+			c.client.Scroll.WithScroll(scanKeepAlive),
+		)
+		if err != nil {
+			return fmt.Errorf("error continuing scan: %w", err)
+		}
+		if scrollID, docs, err = readScanPage(res.Body, res.IsError(), res.String); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readScanPage reads and closes a page of scan results.
+func readScanPage(body io.ReadCloser, isError bool, describe func() string) (string, []Document, error) {
+	defer body.Close()
+	if isError {
+		return "", nil, fmt.Errorf("elasticsearch scan error: %s", describe())
+	}
+	return parseScanPage(body)
+}
+
+// parseScanPage returns the scroll ID and documents of a page of scan results.
+func parseScanPage(body io.Reader) (string, []Document, error) {
+	var r struct {
+		ScrollID string `json:"_scroll_id"`
+		Hits     struct {
+			Hits []struct {
+				Source Document `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(body).Decode(&r); err != nil {
+		return "", nil, fmt.Errorf("error parsing the scan response body: %w", err)
+	}
+
+	docs := make([]Document, len(r.Hits.Hits))
+	for i, hit := range r.Hits.Hits {
+		docs[i] = hit.Source
+	}
+	return r.ScrollID, docs, nil
+}
+
+// clearScroll releases a scroll. Scrolls expire on their own, so failures are only logged.
+func (c *ElasticsearchClient) clearScroll(scrollID string) {
+	if scrollID == "" {
+		return
+	}
+	res, err := c.client.ClearScroll(c.client.ClearScroll.WithScrollID(scrollID))
+	if err != nil {
+		log.Printf("Failed to clear scroll: %v", err)
+		return
+	}
+	res.Body.Close()
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScanPage(t *testing.T) {
+	body := `{
+		"_scroll_id": "scroll-1",
+		"hits": {"hits": [
+			{"_id": "a", "_source": {"document_id": "a", "title": "Billing", "text": "Invoices are emailed monthly.", "metadata": {"tag": "billing"}}},
+			{"_id": "b", "_source": {"document_id": "b", "text": "Exports are CSV."}}
+		]}
+	}`
+
+	scrollID, docs, err := parseScanPage(strings.NewReader(body))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "scroll-1", scrollID)
+	assert.Equal(t, []Document{
+		{DocumentID: "a", Title: "Billing", Text: "Invoices are emailed monthly.", Metadata: map[string]string{"tag": "billing"}},
+		{DocumentID: "b", Text: "Exports are CSV."},
+	}, docs)
+
+	_, _, err = parseScanPage(strings.NewReader("not json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Autocompleter is an autogenerated mock type for the Autocompleter type
+type Autocompleter struct {
+	mock.Mock
+}
+
+// Autocomplete provides a mock function with given fields: ctx, prefix, size
+func (_m *Autocompleter) Autocomplete(ctx context.Context, prefix string, size int) ([]string, error) {
+	ret := _m.Called(ctx, prefix, size)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Autocomplete")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]string, error)); ok {
+		return rf(ctx, prefix, size)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []string); ok {
+		r0 = rf(ctx, prefix, size)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, prefix, size)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordQueries provides a mock function with given fields: ctx, counts
+func (_m *Autocompleter) RecordQueries(ctx context.Context, counts map[string]int) error {
+	ret := _m.Called(ctx, counts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordQueries")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, map[string]int) error); ok {
+		r0 = rf(ctx, counts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewAutocompleter creates a new instance of Autocompleter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAutocompleter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Autocompleter {
+	mock := &Autocompleter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
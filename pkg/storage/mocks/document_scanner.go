@@ -0,0 +1,47 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	storage "github.com/chr1sbest/hybrid-search/pkg/storage"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// DocumentScanner is an autogenerated mock type for the DocumentScanner type
+type DocumentScanner struct {
+	mock.Mock
+}
+
+// ScanDocuments provides a mock function with given fields: ctx, fn
+func (_m *DocumentScanner) ScanDocuments(ctx context.Context, fn func([]storage.Document) error) error {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ScanDocuments")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func([]storage.Document) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewDocumentScanner creates a new instance of DocumentScanner. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewDocumentScanner(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *DocumentScanner {
+	mock := &DocumentScanner{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,59 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	storage "github.com/chr1sbest/hybrid-search/pkg/storage"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// HighlightSearcher is an autogenerated mock type for the HighlightSearcher type
+type HighlightSearcher struct {
+	mock.Mock
+}
+
+// SearchWithHighlights provides a mock function with given fields: ctx, queryText, topK, highlight
+func (_m *HighlightSearcher) SearchWithHighlights(ctx context.Context, queryText string, topK int, highlight storage.Highlight) ([]storage.SearchResult, error) {
+	ret := _m.Called(ctx, queryText, topK, highlight)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchWithHighlights")
+	}
+
+	var r0 []storage.SearchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, storage.Highlight) ([]storage.SearchResult, error)); ok {
+		return rf(ctx, queryText, topK, highlight)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, storage.Highlight) []storage.SearchResult); ok {
+		r0 = rf(ctx, queryText, topK, highlight)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.SearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, storage.Highlight) error); ok {
+		r1 = rf(ctx, queryText, topK, highlight)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewHighlightSearcher creates a new instance of HighlightSearcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewHighlightSearcher(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *HighlightSearcher {
+	mock := &HighlightSearcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,46 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IDLister is an autogenerated mock type for the IDLister type
+type IDLister struct {
+	mock.Mock
+}
+
+// ListIDs provides a mock function with given fields: ctx, prefix, fn
+func (_m *IDLister) ListIDs(ctx context.Context, prefix string, fn func([]string) error) error {
+	ret := _m.Called(ctx, prefix, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListIDs")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, func([]string) error) error); ok {
+		r0 = rf(ctx, prefix, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIDLister creates a new instance of IDLister. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIDLister(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IDLister {
+	mock := &IDLister{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
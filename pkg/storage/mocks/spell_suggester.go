@@ -0,0 +1,58 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SpellSuggester is an autogenerated mock type for the SpellSuggester type
+type SpellSuggester struct {
+	mock.Mock
+}
+
+// SuggestSpelling provides a mock function with given fields: ctx, queryText, size
+func (_m *SpellSuggester) SuggestSpelling(ctx context.Context, queryText string, size int) ([]string, error) {
+	ret := _m.Called(ctx, queryText, size)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SuggestSpelling")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]string, error)); ok {
+		return rf(ctx, queryText, size)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []string); ok {
+		r0 = rf(ctx, queryText, size)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, queryText, size)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewSpellSuggester creates a new instance of SpellSuggester. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSpellSuggester(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SpellSuggester {
+	mock := &SpellSuggester{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,46 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SynonymStore is an autogenerated mock type for the SynonymStore type
+type SynonymStore struct {
+	mock.Mock
+}
+
+// UpdateSynonyms provides a mock function with given fields: ctx, rules
+func (_m *SynonymStore) UpdateSynonyms(ctx context.Context, rules []string) error {
+	ret := _m.Called(ctx, rules)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateSynonyms")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) error); ok {
+		r0 = rf(ctx, rules)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewSynonymStore creates a new instance of SynonymStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSynonymStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SynonymStore {
+	mock := &SynonymStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
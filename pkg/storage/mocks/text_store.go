@@ -14,6 +14,24 @@ type TextStore struct {
 	mock.Mock
 }
 
+// Delete provides a mock function with given fields: ctx, id
+func (_m *TextStore) Delete(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Index provides a mock function with given fields: ctx, doc
 func (_m *TextStore) Index(ctx context.Context, doc storage.Document) error {
 	ret := _m.Called(ctx, doc)
@@ -32,6 +50,36 @@ func (_m *TextStore) Index(ctx context.Context, doc storage.Document) error {
 	return r0
 }
 
+// IndexMany provides a mock function with given fields: ctx, docs
+func (_m *TextStore) IndexMany(ctx context.Context, docs []storage.Document) ([]storage.IndexResult, error) {
+	ret := _m.Called(ctx, docs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IndexMany")
+	}
+
+	var r0 []storage.IndexResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.Document) ([]storage.IndexResult, error)); ok {
+		return rf(ctx, docs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.Document) []storage.IndexResult); ok {
+		r0 = rf(ctx, docs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.IndexResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []storage.Document) error); ok {
+		r1 = rf(ctx, docs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Search provides a mock function with given fields: ctx, queryText, topK
 func (_m *TextStore) Search(ctx context.Context, queryText string, topK int) ([]storage.SearchResult, error) {
 	ret := _m.Called(ctx, queryText, topK)
@@ -0,0 +1,58 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// VectorFetcher is an autogenerated mock type for the VectorFetcher type
+type VectorFetcher struct {
+	mock.Mock
+}
+
+// FetchVectors provides a mock function with given fields: ctx, ids
+func (_m *VectorFetcher) FetchVectors(ctx context.Context, ids []string) (map[string][]float32, error) {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FetchVectors")
+	}
+
+	var r0 map[string][]float32
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) (map[string][]float32, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) map[string][]float32); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string][]float32)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewVectorFetcher creates a new instance of VectorFetcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewVectorFetcher(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *VectorFetcher {
+	mock := &VectorFetcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -14,6 +14,24 @@ type VectorStore struct {
 	mock.Mock
 }
 
+// Delete provides a mock function with given fields: ctx, ids
+func (_m *VectorStore) Delete(ctx context.Context, ids []string) error {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) error); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Query provides a mock function with given fields: ctx, queryText, queryVector, topK
 func (_m *VectorStore) Query(ctx context.Context, queryText string, queryVector []float32, topK int) ([]storage.SearchResult, error) {
 	ret := _m.Called(ctx, queryText, queryVector, topK)
@@ -62,6 +80,24 @@ func (_m *VectorStore) Upsert(ctx context.Context, doc storage.Document, vector
 	return r0
 }
 
+// UpsertMany provides a mock function with given fields: ctx, docs, vectors
+func (_m *VectorStore) UpsertMany(ctx context.Context, docs []storage.Document, vectors [][]float32) error {
+	ret := _m.Called(ctx, docs, vectors)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpsertMany")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.Document, [][]float32) error); ok {
+		r0 = rf(ctx, docs, vectors)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewVectorStore creates a new instance of VectorStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewVectorStore(t interface {
@@ -5,5 +5,12 @@ package storage
 type Document struct {
 	DocumentID       string `json:"document_id"`
 	ParentDocumentID string `json:"parent_document_id,omitempty"`
-	Text             string `json:"text"`
+	// Title is optional. Titles are offered as autocomplete suggestions.
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text"`
+	// Metadata holds arbitrary key-value attributes, such as tags, that queries can filter on.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// PageStarts holds the offset in Text at which each page starts, for documents with pages,
+	// such as PDFs. Their chunks never span two pages.
+	PageStarts []int `json:"page_starts,omitempty"`
 }
@@ -7,6 +7,19 @@ import (
 	"github.com/pinecone-io/go-pinecone/v4/pinecone"
 )
 
+// pineconeUpsertBatchSize is the most records Pinecone accepts in one upsert with integrated
+// embedding.
+const pineconeUpsertBatchSize = 96
+
+// pineconeDeleteBatchSize is the most IDs Pinecone accepts in one delete request.
+const pineconeDeleteBatchSize = 1000
+
+// pineconeFetchBatchSize is the most IDs Pinecone accepts in one fetch request.
+const pineconeFetchBatchSize = 1000
+
+// pineconeListPageSize is the most IDs Pinecone returns in one list request.
+const pineconeListPageSize = 100
+
 // PineconeClient wraps the Pinecone index connection and implements the VectorStore interface.
 // This implementation is for an index with an INTEGRATED embedding model.
 type PineconeClient struct {
@@ -32,15 +45,19 @@ func NewPineconeClient(ctx context.Context, apiKey, indexName string) (*Pinecone
 		return nil, fmt.Errorf("failed to create Pinecone index connection: %w", err)
 	}
 
-	return &PineconeClient{idxConn: idxConn}, nil
+	return NewPineconeIndexClient(idxConn), nil
+}
+
+// NewPineconeIndexClient creates a client for an index connection that is already open, such
+// as one to a host that DescribeIndex doesn't report.
+func NewPineconeIndexClient(idxConn *pinecone.IndexConnection) *PineconeClient {
+	return &PineconeClient{idxConn: idxConn}
 }
 
 // Upsert uses the integrated embedding model to add or update a document.
 // It IGNORES the pre-computed vector argument to satisfy the VectorStore interface.
 func (c *PineconeClient) Upsert(ctx context.Context, doc Document, vector []float32) error {
-	records := []*pinecone.IntegratedRecord{
-		{"_id": doc.DocumentID, "chunk_text": doc.Text},
-	}
+	records := []*pinecone.IntegratedRecord{integratedRecord(doc)}
 
 	if err := c.idxConn.UpsertRecords(ctx, records); err != nil {
 		return fmt.Errorf("failed to upsert record to Pinecone: %w", err)
@@ -48,7 +65,166 @@ func (c *PineconeClient) Upsert(ctx context.Context, doc Document, vector []floa
 	return nil
 }
 
-// Query uses the integrated embedding model to perform a semantic search.
+// UpsertMany uses the integrated embedding model to add or update documents, in requests of
+// up to pineconeUpsertBatchSize records. It IGNORES the pre-computed vectors.
+func (c *PineconeClient) UpsertMany(ctx context.Context, docs []Document, vectors [][]float32) error {
+	for start := 0; start < len(docs); start += pineconeUpsertBatchSize {
+		end := min(start+pineconeUpsertBatchSize, len(docs))
+		records := make([]*pinecone.IntegratedRecord, 0, end-start)
+		for _, doc := range docs[start:end] {
+			records = append(records, integratedRecord(doc))
+		}
+
+		if err := c.idxConn.UpsertRecords(ctx, records); err != nil {
+			return fmt.Errorf("failed to upsert records %d to %d to Pinecone: %w", start, end-1, err)
+		}
+	}
+	return nil
+}
+
+// Delete implements the VectorStore interface, in requests of up to pineconeDeleteBatchSize IDs.
+func (c *PineconeClient) Delete(ctx context.Context, ids []string) error {
+	for start := 0; start < len(ids); start += pineconeDeleteBatchSize {
+		end := min(start+pineconeDeleteBatchSize, len(ids))
+		if err := c.idxConn.DeleteVectorsById(ctx, ids[start:end]); err != nil {
+			return fmt.Errorf("failed to delete records from Pinecone: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListIDs implements the IDLister interface, a page of up to pineconeListPageSize IDs at a
+// time. Listing is only supported by serverless indexes.
+func (c *PineconeClient) ListIDs(ctx context.Context, prefix string, fn func(ids []string) error) error {
+	limit := uint32(pineconeListPageSize)
+	req := &pinecone.ListVectorsRequest{Limit: &limit}
+	if prefix != "" {
+		req.Prefix = &prefix
+	}
+	for {
+		res, err := c.idxConn.ListVectors(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to list records in Pinecone: %w", err)
+		}
+
+		ids := make([]string, 0, len(res.VectorIds))
+		for _, id := range res.VectorIds {
+			if id != nil {
+				ids = append(ids, *id)
+			}
+		}
+		if len(ids) > 0 {
+			if err := fn(ids); err != nil {
+				return err
+			}
+		}
+		if res.NextPaginationToken == nil || *res.NextPaginationToken == "" {
+			return nil
+		}
+		req.PaginationToken = res.NextPaginationToken
+	}
+}
+
+// FetchVectors implements the VectorFetcher interface, in requests of up to
+// pineconeFetchBatchSize IDs. The vectors are those computed by the integrated embedding model.
+func (c *PineconeClient) FetchVectors(ctx context.Context, ids []string) (map[string][]float32, error) {
+	vectors := make(map[string][]float32, len(ids))
+	err := c.fetch(ctx, ids, func(id string, vector *pinecone.Vector) {
+		if vector.Values != nil {
+			vectors[id] = *vector.Values
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+// FetchParentIDs implements the ParentFetcher interface, reading the parent_document_id field
+// integratedRecord stores with each chunk.
+func (c *PineconeClient) FetchParentIDs(ctx context.Context, ids []string) (map[string]string, error) {
+	parents := make(map[string]string, len(ids))
+	err := c.fetch(ctx, ids, func(id string, vector *pinecone.Vector) {
+		if vector.Metadata == nil {
+			return
+		}
+		if parent := vector.Metadata.GetFields()[pineconeParentField].GetStringValue(); parent != "" {
+			parents[id] = parent
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parents, nil
+}
+
+// fetch calls fn with each of the records with the given IDs that exist, fetching them in
+// requests of up to pineconeFetchBatchSize IDs.
+func (c *PineconeClient) fetch(ctx context.Context, ids []string, fn func(id string, vector *pinecone.Vector)) error {
+	for start := 0; start < len(ids); start += pineconeFetchBatchSize {
+		end := min(start+pineconeFetchBatchSize, len(ids))
+		res, err := c.idxConn.FetchVectors(ctx, ids[start:end])
+		if err != nil {
+			return fmt.Errorf("failed to fetch vectors from Pinecone: %w", err)
+		}
+		for id, vector := range res.Vectors {
+			if vector != nil {
+				fn(id, vector)
+			}
+		}
+	}
+	return nil
+}
+
+// Record fields other than the document's metadata. Metadata keys that clash with them are
+// not stored.
+const (
+	pineconeIDField     = "_id"
+	pineconeTextField   = "chunk_text"
+	pineconeParentField = "parent_document_id"
+)
+
+// integratedRecord converts a document to a record for the integrated embedding model. The
+// metadata is stored as fields of the record, next to the text, so it is returned with hits.
+func integratedRecord(doc Document) *pinecone.IntegratedRecord {
+	record := pinecone.IntegratedRecord{pineconeIDField: doc.DocumentID, pineconeTextField: doc.Text}
+	if doc.ParentDocumentID != "" {
+		record[pineconeParentField] = doc.ParentDocumentID
+	}
+	for key, value := range doc.Metadata {
+		if _, reserved := record[key]; !reserved && key != pineconeParentField {
+			record[key] = value
+		}
+	}
+	return &record
+}
+
+// hitDocument converts a search hit, returned with all of its record's fields, back to the
+// document integratedRecord stored.
+func hitDocument(hit pinecone.Hit) Document {
+	doc := Document{DocumentID: hit.Id}
+	for key, value := range hit.Fields {
+		text, ok := value.(string)
+		if !ok {
+			text = fmt.Sprint(value)
+		}
+		switch key {
+		case pineconeTextField:
+			doc.Text = text
+		case pineconeParentField:
+			doc.ParentDocumentID = text
+		default:
+			if doc.Metadata == nil {
+				doc.Metadata = make(map[string]string)
+			}
+			doc.Metadata[key] = text
+		}
+	}
+	return doc
+}
+
+// Query uses the integrated embedding model to perform a semantic search. Every field of the
+// matching records is returned, so results carry their metadata.
 // It IGNORES the pre-computed queryVector argument to satisfy the VectorStore interface.
 func (c *PineconeClient) Query(ctx context.Context, queryText string, queryVector []float32, topK int) ([]SearchResult, error) {
 	res, err := c.idxConn.SearchRecords(ctx, &pinecone.SearchRecordsRequest{
@@ -58,7 +234,6 @@ func (c *PineconeClient) Query(ctx context.Context, queryText string, queryVecto
 				"text": queryText,
 			},
 		},
-		Fields: &[]string{"chunk_text"},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to query Pinecone: %w", err)
@@ -67,15 +242,8 @@ func (c *PineconeClient) Query(ctx context.Context, queryText string, queryVecto
 	var results []SearchResult
 	if res != nil {
 		for _, hit := range res.Result.Hits {
-			var text string
-			if chunkText, ok := hit.Fields["chunk_text"]; ok {
-				text = chunkText.(string)
-			}
 			results = append(results, SearchResult{
-				Document: Document{
-					DocumentID: hit.Id,
-					Text:       text,
-				},
+				Document: hitDocument(hit),
 				Score:    float64(hit.Score),
 			})
 		}
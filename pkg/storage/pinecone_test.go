@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/pinecone-io/go-pinecone/v4/pinecone"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntegratedRecord(t *testing.T) {
+	t.Run("StoresMetadataAsFields", func(t *testing.T) {
+		doc := Document{
+			DocumentID:       "faq#0",
+			ParentDocumentID: "faq",
+			Text:             "Invoices are emailed monthly.",
+			Metadata:         map[string]string{"tag": "billing", "chunk_text": "clash", "_id": "clash"},
+		}
+
+		record := integratedRecord(doc)
+
+		assert.Equal(t, pinecone.IntegratedRecord{
+			"_id":                "faq#0",
+			"chunk_text":         "Invoices are emailed monthly.",
+			"parent_document_id": "faq",
+			"tag":                "billing",
+		}, *record)
+	})
+
+	t.Run("RoundTripsThroughHits", func(t *testing.T) {
+		doc := Document{
+			DocumentID:       "faq#0",
+			ParentDocumentID: "faq",
+			Text:             "Invoices are emailed monthly.",
+			Metadata:         map[string]string{"tag": "billing"},
+		}
+		record := *integratedRecord(doc)
+		fields := make(map[string]interface{})
+		for key, value := range record {
+			if key != "_id" {
+				fields[key] = value
+			}
+		}
+
+		assert.Equal(t, doc, hitDocument(pinecone.Hit{Id: "faq#0", Score: 0.9, Fields: fields}))
+	})
+
+	t.Run("HitWithoutMetadata", func(t *testing.T) {
+		hit := pinecone.Hit{Id: "legacy", Fields: map[string]interface{}{"chunk_text": "Old chunk."}}
+
+		assert.Equal(t, Document{DocumentID: "legacy", Text: "Old chunk."}, hitDocument(hit))
+	})
+}
@@ -1,11 +1,27 @@
 package storage
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 // SearchResult represents a single item in a search result set.
 type SearchResult struct {
 	Document Document
 	Score    float64
+	// Highlights are fragments of the document text with matches marked, when requested.
+	Highlights []string
+}
+
+// Highlight configures the highlighted fragments returned with search results.
+type Highlight struct {
+	// PreTag and PostTag are inserted around each match.
+	PreTag  string
+	PostTag string
+	// FragmentSize is the approximate length of each fragment, in characters.
+	FragmentSize int
+	// NumberOfFragments is the maximum number of fragments per result.
+	NumberOfFragments int
 }
 
 // VectorStore defines the interface for vector database operations.
@@ -15,6 +31,13 @@ type VectorStore interface {
 	// If the vector is nil, the store is expected to generate it internally.
 	Upsert(ctx context.Context, doc Document, vector []float32) error
 
+	// UpsertMany adds or updates documents in as few requests as possible. vectors must be
+	// aligned with docs, and like Upsert, may be nil.
+	UpsertMany(ctx context.Context, docs []Document, vectors [][]float32) error
+
+	// Delete removes the documents with the given IDs. IDs that don't exist are ignored.
+	Delete(ctx context.Context, ids []string) error
+
 	// Query searches for documents. It may receive a pre-computed query vector.
 	// If the queryVector is nil, the store is expected to generate it from the queryText.
 	Query(ctx context.Context, queryText string, queryVector []float32, topK int) ([]SearchResult, error)
@@ -24,5 +47,95 @@ type VectorStore interface {
 // This is typically used for keyword matching and full-text search.
 type TextStore interface {
 	Index(ctx context.Context, doc Document) error
+	// IndexMany indexes docs in as few requests as possible and returns the outcome for
+	// each document, in order. A document that fails doesn't stop the others; the error is
+	// only set if the batch could not be sent.
+	IndexMany(ctx context.Context, docs []Document) ([]IndexResult, error)
+	// Delete removes a document. Deleting a document that doesn't exist is not an error.
+	Delete(ctx context.Context, id string) error
 	Search(ctx context.Context, queryText string, topK int) ([]SearchResult, error)
 }
+
+// IndexResult is the outcome of indexing one document of a batch.
+type IndexResult struct {
+	DocumentID string
+	// Created is true if the document was new, and false if it replaced an existing one.
+	Created bool
+	// Err is set if the document failed to index.
+	Err error
+}
+
+// BulkItemError is the failure of a single document in a batch.
+type BulkItemError struct {
+	// Status is the HTTP status the store reported for the document.
+	Status int
+	Reason string
+}
+
+func (e *BulkItemError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.Status, e.Reason)
+}
+
+// HighlightSearcher is implemented by text stores that can return highlighted fragments of
+// the text that matched a query.
+type HighlightSearcher interface {
+	// SearchWithHighlights works like TextStore.Search, and also sets the Highlights of
+	// every result that has a match in its text.
+	SearchWithHighlights(ctx context.Context, queryText string, topK int, highlight Highlight) ([]SearchResult, error)
+}
+
+// SynonymStore is implemented by text stores that support a managed set of synonyms.
+type SynonymStore interface {
+	// UpdateSynonyms replaces the synonym set with rules in the Solr synonym format.
+	UpdateSynonyms(ctx context.Context, rules []string) error
+}
+
+// SpellSuggester is implemented by text stores that can suggest spelling corrections for a
+// query from their indexed vocabulary.
+type SpellSuggester interface {
+	// SuggestSpelling returns up to size corrected versions of queryText, best first. It
+	// returns none if the query looks correctly spelled.
+	SuggestSpelling(ctx context.Context, queryText string, size int) ([]string, error)
+}
+
+// Autocompleter is implemented by text stores that can complete a partially typed query from
+// document titles and frequent queries.
+type Autocompleter interface {
+	// Autocomplete returns up to size completions of prefix, most popular first.
+	Autocomplete(ctx context.Context, prefix string, size int) ([]string, error)
+	// RecordQueries adds to the popularity of each query by its count, making new queries
+	// available as completions.
+	RecordQueries(ctx context.Context, counts map[string]int) error
+}
+
+// DocumentScanner is implemented by text stores that can enumerate every document, such as
+// for consistency checks.
+type DocumentScanner interface {
+	// ScanDocuments calls fn with successive pages of documents until every document has been
+	// seen or fn returns an error, which is then returned.
+	ScanDocuments(ctx context.Context, fn func(docs []Document) error) error
+}
+
+// IDLister is implemented by vector stores that can enumerate the IDs of their documents.
+type IDLister interface {
+	// ListIDs calls fn with successive pages of the IDs that start with prefix, or every ID
+	// when prefix is empty, until all have been seen or fn returns an error, which is then
+	// returned.
+	ListIDs(ctx context.Context, prefix string, fn func(ids []string) error) error
+}
+
+// VectorFetcher is implemented by vector stores that can return the vectors they hold, such as
+// those computed by an integrated embedding model.
+type VectorFetcher interface {
+	// FetchVectors returns the vectors of the documents with the given IDs. IDs that don't
+	// exist are left out of the map.
+	FetchVectors(ctx context.Context, ids []string) (map[string][]float32, error)
+}
+
+// ParentFetcher is implemented by vector stores that keep the parent document ID of each chunk
+// and can return it by chunk ID, such as for chunks whose IDs don't name their parent.
+type ParentFetcher interface {
+	// FetchParentIDs returns the parent document IDs of the chunks with the given IDs. IDs
+	// that don't exist or have no parent are left out of the map.
+	FetchParentIDs(ctx context.Context, ids []string) (map[string]string, error)
+}
@@ -0,0 +1,113 @@
+package synonyms
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// Parse reads synonym rules in the Solr format used by Elasticsearch, one rule per line:
+// either equivalent terms separated by commas ("k8s, kubernetes") or an explicit mapping
+// ("k8s => kubernetes"). Blank lines and lines starting with # are ignored. Rules are
+// returned with their whitespace normalised.
+func Parse(r io.Reader) ([]string, error) {
+	var rules []string
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule, err := parseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading synonyms: %w", err)
+	}
+	return rules, nil
+}
+
+// LoadFile reads synonym rules from the file at path. See Parse for the format.
+func LoadFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening synonyms file: %w", err)
+	}
+	defer f.Close()
+
+	rules, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// parseRule validates a single rule and normalises its spacing.
+func parseRule(line string) (string, error) {
+	if from, to, ok := strings.Cut(line, "=>"); ok {
+		lhs, err := terms(from)
+		if err != nil {
+			return "", err
+		}
+		rhs, err := terms(to)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(lhs, ", ") + " => " + strings.Join(rhs, ", "), nil
+	}
+
+	equivalent, err := terms(line)
+	if err != nil {
+		return "", err
+	}
+	if len(equivalent) < 2 {
+		return "", fmt.Errorf("rule %q needs at least two terms", line)
+	}
+	return strings.Join(equivalent, ", "), nil
+}
+
+// terms splits a comma-separated list, rejecting empty entries.
+func terms(list string) ([]string, error) {
+	var out []string
+	for _, term := range strings.Split(list, ",") {
+		term = strings.Join(strings.Fields(term), " ")
+		if term == "" {
+			return nil, fmt.Errorf("empty term in %q", strings.TrimSpace(list))
+		}
+		out = append(out, term)
+	}
+	return out, nil
+}
+
+// Manager keeps a store's synonym set in sync with a synonyms file.
+type Manager struct {
+	path  string
+	store storage.SynonymStore
+}
+
+// NewManager creates a Manager that loads rules from path into store.
+func NewManager(path string, store storage.SynonymStore) *Manager {
+	return &Manager{path: path, store: store}
+}
+
+// Reload re-reads the synonyms file and replaces the store's synonym set with its rules.
+// It returns the number of rules loaded. The store is left unchanged if the file is invalid.
+func (m *Manager) Reload(ctx context.Context) (int, error) {
+	rules, err := LoadFile(m.path)
+	if err != nil {
+		return 0, err
+	}
+	if err := m.store.UpdateSynonyms(ctx, rules); err != nil {
+		return 0, fmt.Errorf("error updating synonyms: %w", err)
+	}
+	return len(rules), nil
+}
@@ -0,0 +1,84 @@
+package synonyms
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	storage_mocks "github.com/chr1sbest/hybrid-search/pkg/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("ReadsRules", func(t *testing.T) {
+		input := `
+# Acronyms
+k8s,kubernetes
+SLA ,  service level   agreement
+
+tf => terraform, tf
+`
+		rules, err := Parse(strings.NewReader(input))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"k8s, kubernetes",
+			"SLA, service level agreement",
+			"tf => terraform, tf",
+		}, rules)
+	})
+
+	t.Run("InvalidRules", func(t *testing.T) {
+		for name, input := range map[string]string{
+			"SingleTerm":    "kubernetes",
+			"EmptyTerm":     "k8s,,kubernetes",
+			"EmptyMapping":  "k8s =>",
+			"EmptySource":   "=> kubernetes",
+			"TrailingComma": "k8s, kubernetes,",
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := Parse(strings.NewReader("ok, fine\n" + input))
+				assert.ErrorContains(t, err, "line 2")
+			})
+		}
+	})
+}
+
+func TestManager_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "synonyms.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("k8s, kubernetes\n"), 0o644))
+
+	t.Run("UpdatesStore", func(t *testing.T) {
+		mockStore := new(storage_mocks.SynonymStore)
+		mockStore.On("UpdateSynonyms", mock.Anything, []string{"k8s, kubernetes"}).Return(nil)
+
+		count, err := NewManager(path, mockStore).Reload(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("StoreError", func(t *testing.T) {
+		mockStore := new(storage_mocks.SynonymStore)
+		mockStore.On("UpdateSynonyms", mock.Anything, mock.Anything).Return(errors.New("cluster unavailable"))
+
+		_, err := NewManager(path, mockStore).Reload(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("InvalidFileLeavesStoreUnchanged", func(t *testing.T) {
+		badPath := filepath.Join(t.TempDir(), "bad.txt")
+		assert.NoError(t, os.WriteFile(badPath, []byte("lonely\n"), 0o644))
+		mockStore := new(storage_mocks.SynonymStore)
+
+		_, err := NewManager(badPath, mockStore).Reload(context.Background())
+
+		assert.Error(t, err)
+		mockStore.AssertNotCalled(t, "UpdateSynonyms", mock.Anything, mock.Anything)
+	})
+}
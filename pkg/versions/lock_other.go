@@ -0,0 +1,15 @@
+//go:build !unix
+
+package versions
+
+import "os"
+
+// lockFile does nothing where flock isn't available, so only the lock held in memory applies.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile releases the lock taken by lockFile.
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,144 @@
+package versions
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxLine is the longest version a FileStore reads back, which bounds a document's text.
+const maxLine = 64 << 20
+
+// ErrConflict is returned by Store.Append for a version that doesn't follow the document's
+// latest one, such as when another process recorded a version in between.
+var ErrConflict = errors.New("version conflict")
+
+// Store persists the versions of each document.
+type Store interface {
+	// Append adds v after the document's other versions, or returns ErrConflict if v doesn't
+	// directly follow the latest of them. When it returns, v survives a crash.
+	Append(id string, v Version) error
+	// List returns the versions of the document, oldest first, or none if it has no versions.
+	List(id string) ([]Version, error)
+}
+
+// Locker is implemented by stores that can serialize writes to a document across the
+// processes that share the store.
+type Locker interface {
+	// LockDocument waits until no other process is writing the document and returns a
+	// function that releases it.
+	LockDocument(id string) (unlock func(), err error)
+}
+
+// FileStore is a Store that keeps the versions of each document in a directory, as a file
+// with one JSON version per line. Processes that share the directory on one host take turns
+// writing a document, using flock where the system has it.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore in dir, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create versions directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Append implements the Store interface. The file is locked while its latest version is
+// checked and the line appended, and the line is synced before Append returns.
+func (s *FileStore) Append(id string, v Version) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error marshalling version %d of %s: %w", v.Version, id, err)
+	}
+	line = append(line, '\n')
+
+	file, err := os.OpenFile(s.path(id), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error saving version %d of %s: %w", v.Version, id, err)
+	}
+	defer file.Close()
+	if err := lockFile(file); err != nil {
+		return fmt.Errorf("error locking versions of %s: %w", id, err)
+	}
+	defer unlockFile(file)
+
+	versions, err := s.List(id)
+	if err != nil {
+		return err
+	}
+	if current := Current(versions); v.Version != current+1 {
+		return fmt.Errorf("%w: %s is at version %d, so version %d can't be saved", ErrConflict, id, current, v.Version)
+	}
+
+	// Start a new line if the file ends in one cut short by a crash.
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		buf := make([]byte, 1)
+		if _, err := file.ReadAt(buf, info.Size()-1); err == nil && buf[0] != '\n' {
+			line = append([]byte("\n"), line...)
+		}
+	}
+	if _, err := file.Write(line); err != nil {
+		return fmt.Errorf("error saving version %d of %s: %w", v.Version, id, err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("error syncing version %d of %s: %w", v.Version, id, err)
+	}
+	return nil
+}
+
+// List implements the Store interface.
+func (s *FileStore) List(id string) ([]Version, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading versions of %s: %w", id, err)
+	}
+
+	var versions []Version
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLine)
+	for scanner.Scan() {
+		// A line cut short by a crash is ignored. Its version was never acknowledged.
+		var v Version
+		if json.Unmarshal(scanner.Bytes(), &v) == nil {
+			versions = append(versions, v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading versions of %s: %w", id, err)
+	}
+	return versions, nil
+}
+
+// LockDocument implements the Locker interface with a lock file next to the document's
+// versions.
+func (s *FileStore) LockDocument(id string) (unlock func(), err error) {
+	file, err := os.OpenFile(strings.TrimSuffix(s.path(id), ".jsonl")+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error locking %s: %w", id, err)
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error locking %s: %w", id, err)
+	}
+	return func() {
+		unlockFile(file)
+		file.Close()
+	}, nil
+}
+
+// path returns the file holding a document's versions. IDs are hashed, since they may hold
+// characters that aren't allowed in file names, such as the slashes of a crawled URL.
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%x.jsonl", sha256.Sum256([]byte(id))))
+}
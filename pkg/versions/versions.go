@@ -0,0 +1,158 @@
+package versions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+)
+
+// ErrNotFound is returned by History.Get for a version the document doesn't have.
+var ErrNotFound = errors.New("version not found")
+
+// Version is one saved state of a parent document. Versions are numbered from 1, and each
+// write of the document adds the next one.
+type Version struct {
+	Version  int               `json:"version"`
+	Title    string            `json:"title,omitempty"`
+	Text     string            `json:"text"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// PageStarts is where each page of Text starts, for documents with pages.
+	PageStarts []int `json:"page_starts,omitempty"`
+	// RestoredFrom is the version a rollback copied, or zero if the version was written
+	// directly.
+	RestoredFrom int       `json:"restored_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Document returns the version as the parent document with the given ID.
+func (v Version) Document(id string) storage.Document {
+	return storage.Document{DocumentID: id, Title: v.Title, Text: v.Text, Metadata: v.Metadata, PageStarts: v.PageStarts}
+}
+
+// History records the versions of each document in a Store, and serializes writes to a
+// document so that its version numbers increase without gaps. It is safe for concurrent use.
+type History struct {
+	store Store
+
+	mu    sync.Mutex
+	locks map[string]*documentLock
+}
+
+// documentLock is held while a document is written. refs counts the holders and waiters, so
+// the lock can be dropped once no one needs it.
+type documentLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// NewHistory creates a History that keeps its versions in store.
+func NewHistory(store Store) *History {
+	return &History{store: store, locks: make(map[string]*documentLock)}
+}
+
+// Lock waits until no one else is writing the document and returns a function that releases
+// it. Callers hold the lock from reading the current version until Record returns, so a
+// concurrent write can't slip in between. If the store is a Locker, the lock also keeps out
+// other processes that share the store; if that fails, only this process is kept out, and
+// Record still refuses to save a version another process has taken.
+func (h *History) Lock(id string) (unlock func()) {
+	h.mu.Lock()
+	l, ok := h.locks[id]
+	if !ok {
+		l = &documentLock{}
+		h.locks[id] = l
+	}
+	l.refs++
+	h.mu.Unlock()
+
+	l.mu.Lock()
+	unlockStore := func() {}
+	if locker, ok := h.store.(Locker); ok {
+		var err error
+		if unlockStore, err = locker.LockDocument(id); err != nil {
+			log.Printf("Failed to lock %s in the version store: %v", id, err)
+			unlockStore = func() {}
+		}
+	}
+	return func() {
+		unlockStore()
+		l.mu.Unlock()
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(h.locks, id)
+		}
+	}
+}
+
+// lockKey is the context key under which WithLock records a locked document.
+type lockKey struct{}
+
+// WithLock returns a copy of ctx recording that the caller holds the document's lock and
+// records its version itself, such as for a write with a precondition or a rollback. Writers
+// that record the versions of what they store, such as the ingest pipeline, leave the
+// document to the caller rather than waiting for the lock it already holds.
+func WithLock(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, lockKey{}, id)
+}
+
+// HoldsLock reports whether ctx comes from WithLock for the document.
+func HoldsLock(ctx context.Context, id string) bool {
+	held, ok := ctx.Value(lockKey{}).(string)
+	return ok && held == id
+}
+
+// List returns the versions of the document, oldest first, or none if it has no history.
+func (h *History) List(id string) ([]Version, error) {
+	return h.store.List(id)
+}
+
+// Get returns one version of the document, or ErrNotFound.
+func (h *History) Get(id string, version int) (Version, error) {
+	versions, err := h.store.List(id)
+	if err != nil {
+		return Version{}, err
+	}
+	for _, v := range versions {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return Version{}, fmt.Errorf("%w: %s has no version %d", ErrNotFound, id, version)
+}
+
+// Record saves doc as the document's next version and returns it. restoredFrom is the
+// version a rollback copied, or zero. The caller must hold the document's lock. It returns
+// ErrConflict if another writer saved the version first.
+func (h *History) Record(doc storage.Document, restoredFrom int) (Version, error) {
+	versions, err := h.store.List(doc.DocumentID)
+	if err != nil {
+		return Version{}, err
+	}
+	v := Version{
+		Version:      Current(versions) + 1,
+		Title:        doc.Title,
+		Text:         doc.Text,
+		Metadata:     doc.Metadata,
+		PageStarts:   doc.PageStarts,
+		RestoredFrom: restoredFrom,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := h.store.Append(doc.DocumentID, v); err != nil {
+		return Version{}, err
+	}
+	return v, nil
+}
+
+// Current returns the number of the latest of versions, or zero if there are none.
+func Current(versions []Version) int {
+	if len(versions) == 0 {
+		return 0
+	}
+	return versions[len(versions)-1].Version
+}
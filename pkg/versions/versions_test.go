@@ -0,0 +1,161 @@
+package versions
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chr1sbest/hybrid-search/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	t.Run("AppendsAndLists", func(t *testing.T) {
+		store, err := NewFileStore(t.TempDir())
+		assert.NoError(t, err)
+
+		assert.NoError(t, store.Append("https://example.com/docs/a", Version{Version: 1, Text: "one"}))
+		assert.NoError(t, store.Append("https://example.com/docs/a", Version{Version: 2, Text: "two"}))
+		listed, err := store.List("https://example.com/docs/a")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Version{{Version: 1, Text: "one"}, {Version: 2, Text: "two"}}, listed)
+		none, err := store.List("b")
+		assert.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("IgnoresPartialLine", func(t *testing.T) {
+		// 1. Arrange
+		dir := t.TempDir()
+		store, err := NewFileStore(dir)
+		assert.NoError(t, err)
+		assert.NoError(t, store.Append("a", Version{Version: 1, Text: "one"}))
+		file, err := os.OpenFile(store.path("a"), os.O_WRONLY|os.O_APPEND, 0)
+		assert.NoError(t, err)
+		_, err = file.WriteString(`{"version": 2, "te`)
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+
+		// 2. Act
+		assert.NoError(t, store.Append("a", Version{Version: 2, Text: "two"}))
+		listed, err := store.List("a")
+
+		// 3. Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []Version{{Version: 1, Text: "one"}, {Version: 2, Text: "two"}}, listed)
+		entries, err := os.ReadDir(filepath.Dir(store.path("a")))
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+	t.Run("RejectsConflictingVersion", func(t *testing.T) {
+		store, err := NewFileStore(t.TempDir())
+		assert.NoError(t, err)
+		assert.NoError(t, store.Append("a", Version{Version: 1, Text: "one"}))
+
+		repeated := store.Append("a", Version{Version: 1, Text: "other"})
+		skipped := store.Append("a", Version{Version: 3, Text: "three"})
+
+		assert.ErrorIs(t, repeated, ErrConflict)
+		assert.ErrorIs(t, skipped, ErrConflict)
+		listed, err := store.List("a")
+		assert.NoError(t, err)
+		assert.Equal(t, []Version{{Version: 1, Text: "one"}}, listed)
+	})
+}
+
+func TestHistory(t *testing.T) {
+	t.Run("NumbersVersions", func(t *testing.T) {
+		store, err := NewFileStore(t.TempDir())
+		assert.NoError(t, err)
+		h := NewHistory(store)
+		doc := storage.Document{DocumentID: "a", Title: "Billing", Text: "one", Metadata: map[string]string{"tag": "billing"}}
+
+		first, err := h.Record(doc, 0)
+		assert.NoError(t, err)
+		second, err := h.Record(storage.Document{DocumentID: "a", Text: "two"}, 0)
+		assert.NoError(t, err)
+		restored, err := h.Record(first.Document("a"), first.Version)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, first.Version)
+		assert.Equal(t, 2, second.Version)
+		assert.Equal(t, 3, restored.Version)
+		assert.Equal(t, 1, restored.RestoredFrom)
+		assert.Equal(t, doc, restored.Document("a"))
+		got, err := h.Get("a", 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "two", got.Text)
+		_, err = h.Get("a", 4)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("LockSerializesWrites", func(t *testing.T) {
+		store, err := NewFileStore(t.TempDir())
+		assert.NoError(t, err)
+		h := NewHistory(store)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				unlock := h.Lock("a")
+				defer unlock()
+				_, err := h.Record(storage.Document{DocumentID: "a", Text: "text"}, 0)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		listed, err := h.List("a")
+		assert.NoError(t, err)
+		assert.Len(t, listed, 10)
+		for i, v := range listed {
+			assert.Equal(t, i+1, v.Version)
+		}
+		assert.Empty(t, h.locks)
+	})
+	t.Run("LockSerializesProcesses", func(t *testing.T) {
+		// 1. Arrange
+		// Each history has its own store on the same directory, as the server and a
+		// command do, so only the lock in the store keeps them apart.
+		dir := t.TempDir()
+		histories := make([]*History, 2)
+		for i := range histories {
+			store, err := NewFileStore(dir)
+			assert.NoError(t, err)
+			histories[i] = NewHistory(store)
+		}
+
+		// 2. Act
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				h := histories[i%2]
+				unlock := h.Lock("a")
+				defer unlock()
+				// Read the current version and write after a pause, as an If-Match write does.
+				before, err := h.List("a")
+				assert.NoError(t, err)
+				time.Sleep(time.Millisecond)
+				v, err := h.Record(storage.Document{DocumentID: "a", Text: "text"}, 0)
+				assert.NoError(t, err)
+				assert.Equal(t, Current(before)+1, v.Version)
+			}()
+		}
+		wg.Wait()
+
+		// 3. Assert
+		listed, err := histories[0].List("a")
+		assert.NoError(t, err)
+		assert.Len(t, listed, 10)
+		for i, v := range listed {
+			assert.Equal(t, i+1, v.Version)
+		}
+	})
+}